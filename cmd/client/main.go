@@ -1,20 +1,39 @@
 package main
 
 import (
-	"tech-challenge-product/internal/channels/grpc"
-	"tech-challenge-product/internal/channels/rest"
+	"context"
+	"tech-challenge-product/internal/app"
 	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/lifecycle"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/tracing"
 
-	"github.com/sirupsen/logrus"
+	"github.com/rs/zerolog/log"
 )
 
 func main() {
 	config.ParseFromFlags()
-	go func() {
-		logrus.Fatal(grpc.Listen())
-	}()
+	settings := config.Get()
 
-	if err := rest.New(rest.NewProductChannel()).Start(); err != nil {
-		logrus.Panic()
+	if err := logging.Setup(settings.Logging); err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when configure logging")
+	}
+
+	manager := lifecycle.New(settings.Server.ShutdownTimeout)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), settings.Tracing)
+	if err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when configure tracing")
+	}
+	manager.OnShutdown(lifecycle.Closer{Name: "tracing", Close: shutdownTracing})
+
+	application, err := app.New(context.Background(), settings)
+	if err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when build the service")
+	}
+	application.Register(manager)
+
+	if err := manager.Run(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when run the service")
 	}
 }
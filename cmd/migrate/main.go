@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/metrics"
+	"tech-challenge-product/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// migrate applies the pending Mongo migrations, for deployments that set
+// db.skip_migrations and migrate before rolling out.
+func main() {
+	pending := flag.Bool("pending", false, "List the pending migrations without applying them")
+	config.ParseFromFlags()
+	settings := config.Get()
+
+	if err := logging.Setup(settings.Logging); err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when configure logging")
+	}
+
+	ctx := context.Background()
+	// nothing scrapes the migrations, so their metrics are left unserved
+	client, err := repository.NewMongo(ctx, settings.DB, metrics.NewRecorder(metrics.NewRegistry()))
+	if err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when connect to mongo")
+	}
+	defer client.Disconnect(ctx)
+
+	migrator := repository.NewMigrator(repository.Database(client, settings.DB))
+
+	if *pending {
+		migrations, err := migrator.Pending(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("an error occurred when list the pending migrations")
+		}
+		for _, migration := range migrations {
+			log.Info().Int("version", migration.Version).Str("description", migration.Description).Msg("pending migration")
+		}
+		return
+	}
+
+	applied, err := migrator.Migrate(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("an error occurred when migrate")
+	}
+	log.Info().Int("applied", len(applied)).Msg("database is up to date")
+}
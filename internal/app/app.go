@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/channels/grpc"
+	"tech-challenge-product/internal/channels/rest"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/events"
+	"tech-challenge-product/internal/health"
+	"tech-challenge-product/internal/lifecycle"
+	"tech-challenge-product/internal/metrics"
+	"tech-challenge-product/internal/repository"
+	"tech-challenge-product/internal/service"
+	"tech-challenge-product/internal/tenant"
+	"tech-challenge-product/internal/webhook"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// App is the service built from one configuration. Every component is
+// created once, here, and handed to the components depending on it, so the
+// REST and gRPC servers share the same services and repositories.
+type App struct {
+	Config config.Config
+	// Mongo is nil when everything is kept in memory.
+	Mongo *mongo.Client
+	// Postgres is only connected when the catalog is stored in PostgreSQL.
+	Postgres *pgxpool.Pool
+
+	Products service.ProductService
+	Audit    service.AuditService
+	Webhooks service.WebhookService
+	APIKeys  service.APIKeyService
+	Health   health.Checker
+
+	dispatcher *events.Dispatcher
+	worker     *webhook.Worker
+	grpc       *grpc.Server
+	rest       server
+	metrics    *metrics.Server
+}
+
+type server interface {
+	Start() error
+	Shutdown(context.Context) error
+}
+
+// New wires the service: config, then the databases of the db.driver
+// setting and their repositories, the services and finally the channels.
+func New(ctx context.Context, settings config.Config) (*App, error) {
+	a := &App{Config: settings}
+
+	// every collector of the App is registered here, never globally
+	registry := metrics.NewRegistry()
+	recorder := metrics.NewRecorder(registry)
+
+	repos, dependencies, err := a.connect(ctx, recorder)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Products = service.NewProductService(repos.Products, repos.Audit, repos.Outbox, repos.Transactor, repos.Watcher, repos.Prices)
+	a.Audit = service.NewAuditService(repos.Audit)
+	a.Webhooks = service.NewWebhookService(repos.Webhooks, repos.Deliveries)
+	a.APIKeys = service.NewAPIKeyService(repos.APIKeys)
+	a.Health = health.New(settings.Health.Timeout, dependencies...)
+
+	publisher, err := events.NewPublisher(settings.Events)
+	if err != nil {
+		return nil, err
+	}
+	a.dispatcher = events.NewDispatcher(repos.Outbox, events.Fanout(publisher, webhook.NewPublisher(repos.Webhooks, repos.Deliveries)), settings.Events)
+	a.worker = webhook.NewWorker(repos.Webhooks, repos.Deliveries, settings.Webhooks)
+
+	if err = metrics.RegisterCatalog(registry, repos.Stats); err != nil {
+		return nil, err
+	}
+
+	tokens := token.New(settings.Token)
+	policy := rbac.New(settings.Auth, tokens)
+	principals := principal.New(policy, a.APIKeys, settings.Tenancy)
+	stores := tenant.New(settings.Tenancy, policy)
+
+	a.grpc, err = grpc.NewServer(settings, a.Products, principals, stores, a.Health, recorder)
+	if err != nil {
+		return nil, err
+	}
+	a.rest = rest.New(settings, principals, stores, recorder,
+		rest.NewProductChannel(a.Products, settings.SSE, settings.Tenancy),
+		rest.NewAuditChannel(a.Audit),
+		rest.NewWebhookChannel(a.Webhooks),
+		rest.NewAPIKeyChannel(a.APIKeys),
+		rest.NewHealthChannel(a.Health),
+	)
+	a.metrics = metrics.NewServer(settings.Server.Admin, registry)
+
+	return a, nil
+}
+
+// connect opens the databases the db.driver setting needs, runs the Mongo
+// migrations and builds the repositories. The memory driver needs no
+// database, so Mongo stays nil. With postgres the catalog, and everything
+// written in its transactions, moves to PostgreSQL; webhooks and API keys
+// stay in MongoDB.
+func (a *App) connect(ctx context.Context, recorder *metrics.Recorder) (repository.Repositories, []health.Dependency, error) {
+	settings := a.Config.DB
+
+	switch settings.Driver {
+	case "memory":
+		return repository.NewMemoryRepositories(), nil, nil
+	case "", "mongo", "postgres":
+	default:
+		return repository.Repositories{}, nil, fmt.Errorf("unknown db driver %q", settings.Driver)
+	}
+
+	client, err := repository.NewMongo(ctx, settings, recorder)
+	if err != nil {
+		return repository.Repositories{}, nil, err
+	}
+	a.Mongo = client
+	db := repository.Database(client, settings)
+
+	if !settings.SkipMigrations {
+		if _, err := repository.NewMigrator(db).Migrate(ctx); err != nil {
+			return repository.Repositories{}, nil, err
+		}
+	}
+
+	repos := repository.NewMongoRepositories(client, db, settings.OperationTimeout)
+	dependencies := []health.Dependency{{
+		Name: "mongo",
+		Check: func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		},
+	}}
+
+	if settings.Driver == "postgres" {
+		pool, err := repository.NewPostgres(ctx, settings.Postgres)
+		if err != nil {
+			return repository.Repositories{}, nil, err
+		}
+		a.Postgres = pool
+
+		catalog := repository.NewPostgresRepositories(pool, settings.OperationTimeout)
+		catalog.Webhooks, catalog.Deliveries, catalog.APIKeys = repos.Webhooks, repos.Deliveries, repos.APIKeys
+		repos = catalog
+		dependencies = append(dependencies, health.Dependency{Name: "postgres", Check: pool.Ping})
+	}
+
+	return repos, dependencies, nil
+}
+
+// Register hands the servers and workers to manager, along with the
+// database connections to close once they stopped.
+func (a *App) Register(manager *lifecycle.Manager) {
+	if a.Mongo != nil {
+		manager.OnShutdown(lifecycle.Closer{Name: "mongo", Close: a.Mongo.Disconnect})
+	}
+	if a.Postgres != nil {
+		manager.OnShutdown(lifecycle.Closer{Name: "postgres", Close: func(context.Context) error {
+			a.Postgres.Close()
+			return nil
+		}})
+	}
+
+	manager.Add(lifecycle.Worker("events dispatcher", a.dispatcher.Run))
+	manager.Add(lifecycle.Worker("webhook worker", a.worker.Run))
+	manager.AddServer(lifecycle.Component{Name: "grpc server", Run: a.grpc.Serve, Stop: a.grpc.Stop})
+	manager.AddServer(lifecycle.Component{Name: "rest server", Run: a.rest.Start, Stop: a.rest.Shutdown})
+	manager.AddServer(lifecycle.Component{Name: "metrics server", Run: a.metrics.Serve, Stop: a.metrics.Stop})
+}
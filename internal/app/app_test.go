@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"os"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/lifecycle"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mongoURIEnv points the cases needing a MongoDB server to one. They are
+// skipped when it is not set.
+const mongoURIEnv = "MONGODB_URI"
+
+func TestNew(t *testing.T) {
+	settings := func(change func(*config.Config)) config.Config {
+		var c config.Config
+		c.DB.ConnectionString = os.Getenv(mongoURIEnv)
+		c.DB.Database = "app_test"
+		c.DB.ServerSelectionTimeout = 100 * time.Millisecond
+		c.DB.SkipMigrations = true
+		c.Events.Publisher = "memory"
+		c.Health.Timeout = time.Millisecond
+		c.Health.Interval = time.Hour
+		change(&c)
+		return c
+	}
+
+	type Given struct {
+		settings   config.Config
+		needsMongo bool
+	}
+	type Expected struct {
+		err     assert.ErrorAssertionFunc
+		message string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given valid configuration must wire the service": {
+			given:    Given{settings: settings(func(*config.Config) {}), needsMongo: true},
+			expected: Expected{err: assert.NoError},
+		},
+		"given memory driver must wire the service without mongo": {
+			given: Given{settings: settings(func(c *config.Config) {
+				c.DB.Driver = "memory"
+				c.DB.ConnectionString = "mongodb://localhost:1"
+			})},
+			expected: Expected{err: assert.NoError},
+		},
+		"given unknown db driver must fail": {
+			given: Given{settings: settings(func(c *config.Config) {
+				c.DB.Driver = "cassandra"
+			})},
+			expected: Expected{err: assert.Error, message: `unknown db driver "cassandra"`},
+		},
+		"given unknown events publisher must fail": {
+			given: Given{settings: settings(func(c *config.Config) {
+				c.Events.Publisher = "kafka"
+			}), needsMongo: true},
+			expected: Expected{err: assert.Error, message: `unknown events publisher "kafka"`},
+		},
+		"given unreachable mongo must fail fast": {
+			given: Given{settings: settings(func(c *config.Config) {
+				c.DB.ConnectionString = "mongodb://localhost:1"
+			})},
+			expected: Expected{err: assert.Error, message: "mongo: cannot reach [localhost:1]"},
+		},
+		"given invalid connection string must fail": {
+			given: Given{settings: settings(func(c *config.Config) {
+				c.DB.ConnectionString = "postgres://localhost"
+			})},
+			expected: Expected{err: assert.Error},
+		},
+		"given invalid read preference must fail": {
+			given: Given{settings: settings(func(c *config.Config) {
+				c.DB.ConnectionString = "mongodb://localhost:1"
+				c.DB.ReadPreference = "fastest"
+			})},
+			expected: Expected{err: assert.Error, message: `mongo: invalid read preference "fastest"`},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.given.needsMongo && os.Getenv(mongoURIEnv) == "" {
+				t.Skip(mongoURIEnv + " is not set")
+			}
+
+			started := time.Now()
+			a, err := New(context.Background(), tc.given.settings)
+
+			tc.expected.err(t, err)
+			if err != nil {
+				assert.Contains(t, err.Error(), tc.expected.message)
+				assert.Less(t, time.Since(started), 5*time.Second)
+				return
+			}
+			assert.Equal(t, tc.given.settings, a.Config)
+			assert.NotNil(t, a.Products)
+			assert.NotNil(t, a.APIKeys)
+
+			// no server was started, so stopping only releases the health watcher
+			a.Register(lifecycle.New(time.Second))
+			assert.Nil(t, a.grpc.Stop(context.Background()))
+			if a.Mongo != nil {
+				assert.Nil(t, a.Mongo.Disconnect(context.Background()))
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package principal
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeyServiceMock struct {
+	mock.Mock
+}
+
+func (m *APIKeyServiceMock) Create(ctx context.Context, key canonical.APIKey) (*canonical.APIKey, string, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.APIKey), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *APIKeyServiceMock) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]canonical.APIKey), args.Error(1)
+}
+
+func (m *APIKeyServiceMock) Revoke(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *APIKeyServiceMock) Authenticate(ctx context.Context, key string) (*canonical.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,130 @@
+package principal
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// APIKeyHeader carries the API key on REST requests; gRPC reads the
+	// lower case form from the metadata.
+	APIKeyHeader = "X-API-Key"
+
+	// TypeClaim records which kind of principal the claims describe. Tokens
+	// do not carry it and are users.
+	TypeClaim = "principal"
+)
+
+type Type string
+
+const (
+	TYPE_USER    Type = "user"
+	TYPE_API_KEY Type = "api_key"
+)
+
+// ErrorAPIKeysUnavailable is returned for calls presenting an API key to an
+// Authorizer built without APIKeys.
+var ErrorAPIKeysUnavailable = errors.New("api keys are not available")
+
+// APIKeys resolves the plaintext keys presented by callers, failing with
+// canonical.ErrorRejectedAPIKey for keys that are not accepted.
+type APIKeys interface {
+	Authenticate(ctx context.Context, key string) (*canonical.APIKey, error)
+}
+
+// Credentials is what the caller presented. An API key takes precedence
+// over a bearer token.
+type Credentials struct {
+	Token  string
+	APIKey string
+}
+
+// Authorizer identifies the callers of both servers.
+type Authorizer struct {
+	policy     rbac.Policy
+	keys       APIKeys
+	storeClaim string
+}
+
+// New checks tokens with policy and resolves API keys with keys. The store
+// of a key is reported under the claim configured in tenancy.
+func New(policy rbac.Policy, keys APIKeys, tenancy config.Tenancy) *Authorizer {
+	return &Authorizer{
+		policy:     policy,
+		keys:       keys,
+		storeClaim: tenancy.Claim,
+	}
+}
+
+// Authorize authenticates the caller from credentials and checks that it is
+// granted permission: through the roles of a token (see rbac.Authorize) or
+// the scopes of an API key. It returns the claims describing the caller,
+// nil for anonymous calls to public permissions, and fails with
+// rbac.ErrorForbidden when the permission is missing.
+func (a *Authorizer) Authorize(ctx context.Context, credentials Credentials, permission rbac.Permission) (jwt.MapClaims, error) {
+	if credentials.APIKey == "" {
+		return a.policy.Authorize(credentials.Token, permission)
+	}
+
+	if a.keys == nil {
+		return nil, ErrorAPIKeysUnavailable
+	}
+
+	key, err := a.keys.Authenticate(ctx, credentials.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.policy.Public(permission) && !granted(key.Scopes, permission) {
+		return nil, rbac.ErrorForbidden
+	}
+
+	return a.apiKeyClaims(*key), nil
+}
+
+// Allowed reports whether the roles of claims, authenticated by other
+// means, grant permission.
+func (a *Authorizer) Allowed(claims jwt.MapClaims, permission rbac.Permission) bool {
+	return a.policy.Allowed(a.policy.Roles(claims), permission)
+}
+
+// Unauthenticated reports whether err means the credentials are missing or
+// were not accepted, as opposed to a failure looking them up.
+func Unauthenticated(err error) bool {
+	return errors.Is(err, token.ErrorMissingToken) ||
+		errors.Is(err, token.ErrorInvalidToken) ||
+		errors.Is(err, canonical.ErrorRejectedAPIKey)
+}
+
+func (a *Authorizer) apiKeyClaims(key canonical.APIKey) jwt.MapClaims {
+	scopes := make([]interface{}, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, scope)
+	}
+
+	claims := jwt.MapClaims{
+		"sub":     "api_key:" + key.ID,
+		"name":    key.Name,
+		"scopes":  scopes,
+		TypeClaim: string(TYPE_API_KEY),
+	}
+	if key.StoreID != "" {
+		claims[a.storeClaim] = key.StoreID
+	}
+	return claims
+}
+
+func granted(scopes []string, permission rbac.Permission) bool {
+	for _, scope := range scopes {
+		if scope == string(permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package principal
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthorize(t *testing.T) {
+	serviceMock := &APIKeyServiceMock{}
+	serviceMock.On("Authenticate", mock.Anything, "pk_writer").Return(&canonical.APIKey{
+		ID: "api_key_writer", Name: "price-sync", Scopes: []string{"product:read", "product:write"},
+	}, nil)
+	serviceMock.On("Authenticate", mock.Anything, "pk_reader").Return(&canonical.APIKey{
+		ID: "api_key_reader", Name: "menu-export", Scopes: []string{"product:read"},
+	}, nil)
+	serviceMock.On("Authenticate", mock.Anything, "pk_revoked").Return(nil, canonical.ErrorRejectedAPIKey)
+	serviceMock.On("Authenticate", mock.Anything, "pk_unavailable").Return(nil, errors.New("connection refused"))
+
+	ctx := context.Background()
+	policy := rbac.New(config.Auth{}, token.New(config.Token{}))
+	authorizer := New(policy, serviceMock, config.Tenancy{Claim: "store_id"})
+
+	type Given struct {
+		credentials Credentials
+		permission  rbac.Permission
+	}
+	type Expected struct {
+		err     error
+		subject string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given api key with the scope must be authorized": {
+			given: Given{
+				credentials: Credentials{APIKey: "pk_writer"},
+				permission:  rbac.PERMISSION_PRODUCT_WRITE,
+			},
+			expected: Expected{
+				subject: "api_key:api_key_writer",
+			},
+		},
+		"given api key without the scope must be forbidden": {
+			given: Given{
+				credentials: Credentials{APIKey: "pk_reader"},
+				permission:  rbac.PERMISSION_PRODUCT_WRITE,
+			},
+			expected: Expected{
+				err: rbac.ErrorForbidden,
+			},
+		},
+		"given revoked api key must be unauthenticated": {
+			given: Given{
+				credentials: Credentials{APIKey: "pk_revoked"},
+				permission:  rbac.PERMISSION_PRODUCT_READ,
+			},
+			expected: Expected{
+				err: canonical.ErrorRejectedAPIKey,
+			},
+		},
+		"given api key must take precedence over token": {
+			given: Given{
+				credentials: Credentials{APIKey: "pk_reader", Token: "not-a-token"},
+				permission:  rbac.PERMISSION_PRODUCT_READ,
+			},
+			expected: Expected{
+				subject: "api_key:api_key_reader",
+			},
+		},
+		"given no credentials must fall back to token validation": {
+			given: Given{
+				permission: rbac.PERMISSION_PRODUCT_READ,
+			},
+			expected: Expected{
+				err: token.ErrorMissingToken,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims, err := authorizer.Authorize(ctx, tc.given.credentials, tc.given.permission)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			if tc.expected.err == nil {
+				assert.Equal(t, tc.expected.subject, claims["sub"])
+				assert.Equal(t, string(TYPE_API_KEY), claims[TypeClaim])
+			} else {
+				assert.True(t, errors.Is(err, rbac.ErrorForbidden) || Unauthenticated(err))
+			}
+		})
+	}
+
+	_, err := authorizer.Authorize(ctx, Credentials{APIKey: "pk_unavailable"}, rbac.PERMISSION_PRODUCT_READ)
+
+	assert.NotNil(t, err)
+	assert.False(t, Unauthenticated(err))
+
+	_, err = New(policy, nil, config.Tenancy{}).Authorize(ctx, Credentials{APIKey: "pk_writer"}, rbac.PERMISSION_PRODUCT_READ)
+
+	assert.ErrorIs(t, err, ErrorAPIKeysUnavailable)
+	assert.False(t, Unauthenticated(err))
+}
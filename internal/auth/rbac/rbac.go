@@ -0,0 +1,143 @@
+package rbac
+
+import (
+	"errors"
+	"strings"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/config"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrorForbidden = errors.New("permission denied")
+)
+
+type Role string
+
+const (
+	ROLE_ADMIN   Role = "admin"
+	ROLE_MANAGER Role = "manager"
+	ROLE_KIOSK   Role = "kiosk"
+	ROLE_SERVICE Role = "service"
+)
+
+type Permission string
+
+const (
+	PERMISSION_PRODUCT_READ   Permission = "product:read"
+	PERMISSION_PRODUCT_WRITE  Permission = "product:write"
+	PERMISSION_PRODUCT_DELETE Permission = "product:delete"
+	PERMISSION_AUDIT_READ     Permission = "audit:read"
+	PERMISSION_WEBHOOK_MANAGE Permission = "webhook:manage"
+	PERMISSION_API_KEY_MANAGE Permission = "api_key:manage"
+)
+
+// Permissions lists every permission, in the order they are documented.
+var Permissions = []Permission{
+	PERMISSION_PRODUCT_READ, PERMISSION_PRODUCT_WRITE, PERMISSION_PRODUCT_DELETE,
+	PERMISSION_AUDIT_READ, PERMISSION_WEBHOOK_MANAGE, PERMISSION_API_KEY_MANAGE,
+}
+
+// defaultPermissions is used for every role not configured under auth.roles.
+var defaultPermissions = map[Role][]Permission{
+	ROLE_ADMIN: {
+		PERMISSION_PRODUCT_READ, PERMISSION_PRODUCT_WRITE, PERMISSION_PRODUCT_DELETE,
+		PERMISSION_AUDIT_READ, PERMISSION_WEBHOOK_MANAGE, PERMISSION_API_KEY_MANAGE,
+	},
+	ROLE_MANAGER: {
+		PERMISSION_PRODUCT_READ, PERMISSION_PRODUCT_WRITE, PERMISSION_PRODUCT_DELETE,
+		PERMISSION_AUDIT_READ,
+	},
+	ROLE_SERVICE: {PERMISSION_PRODUCT_READ, PERMISSION_PRODUCT_WRITE},
+	ROLE_KIOSK:   {PERMISSION_PRODUCT_READ},
+}
+
+// Policy grants permissions to roles as configured under auth, checking
+// the tokens of the callers with tokens.
+type Policy struct {
+	settings config.Auth
+	tokens   *token.Verifier
+}
+
+func New(settings config.Auth, tokens *token.Verifier) Policy {
+	return Policy{
+		settings: settings,
+		tokens:   tokens,
+	}
+}
+
+// Authorize validates tokenString and checks that its roles grant
+// permission. It fails with token.ErrorMissingToken or
+// token.ErrorInvalidToken when the caller is not authenticated and with
+// ErrorForbidden when it lacks the permission. Public permissions accept
+// an empty token, returning nil claims, but still validate a token sent
+// along so the caller is known.
+func (p Policy) Authorize(tokenString string, permission Permission) (jwt.MapClaims, error) {
+	claims, err := p.tokens.Parse(tokenString)
+	if errors.Is(err, token.ErrorMissingToken) && p.Public(permission) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.Public(permission) && !p.Allowed(p.Roles(claims), permission) {
+		return nil, ErrorForbidden
+	}
+
+	return claims, nil
+}
+
+// Allowed reports whether any of roles grants permission.
+func (p Policy) Allowed(roles []Role, permission Permission) bool {
+	for _, role := range roles {
+		for _, granted := range p.permissions(role) {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Public reports whether permission is granted to anonymous callers, which
+// is the case for reads when auth.public_reads is set.
+func (p Policy) Public(permission Permission) bool {
+	return permission == PERMISSION_PRODUCT_READ && p.settings.PublicReads
+}
+
+// Roles reads the roles claim, either a JSON array or a space or comma
+// separated string.
+func (p Policy) Roles(claims jwt.MapClaims) []Role {
+	var values []string
+	switch claim := claims[p.settings.RolesClaim].(type) {
+	case []interface{}:
+		for _, value := range claim {
+			if role, ok := value.(string); ok {
+				values = append(values, role)
+			}
+		}
+	case string:
+		values = strings.FieldsFunc(claim, func(r rune) bool { return r == ' ' || r == ',' })
+	}
+
+	roles := make([]Role, 0, len(values))
+	for _, value := range values {
+		roles = append(roles, Role(strings.ToLower(value)))
+	}
+	return roles
+}
+
+func (p Policy) permissions(role Role) []Permission {
+	configured, ok := p.settings.Roles[string(role)]
+	if !ok {
+		return defaultPermissions[role]
+	}
+
+	result := make([]Permission, 0, len(configured))
+	for _, permission := range configured {
+		result = append(result, Permission(permission))
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package rbac
+
+import (
+	"tech-challenge-product/internal/config"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	policy := New(config.Auth{}, nil)
+
+	type Given struct {
+		roles      []Role
+		permission Permission
+	}
+	type Expected struct {
+		allowed bool
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given admin must manage webhooks": {
+			given:    Given{roles: []Role{ROLE_ADMIN}, permission: PERMISSION_WEBHOOK_MANAGE},
+			expected: Expected{allowed: true},
+		},
+		"given manager must not manage webhooks": {
+			given:    Given{roles: []Role{ROLE_MANAGER}, permission: PERMISSION_WEBHOOK_MANAGE},
+			expected: Expected{allowed: false},
+		},
+		"given service must write products": {
+			given:    Given{roles: []Role{ROLE_SERVICE}, permission: PERMISSION_PRODUCT_WRITE},
+			expected: Expected{allowed: true},
+		},
+		"given kiosk must not write products": {
+			given:    Given{roles: []Role{ROLE_KIOSK}, permission: PERMISSION_PRODUCT_WRITE},
+			expected: Expected{allowed: false},
+		},
+		"given kiosk and manager must use the broadest role": {
+			given:    Given{roles: []Role{ROLE_KIOSK, ROLE_MANAGER}, permission: PERMISSION_PRODUCT_DELETE},
+			expected: Expected{allowed: true},
+		},
+		"given unknown role must not be allowed": {
+			given:    Given{roles: []Role{"guest"}, permission: PERMISSION_PRODUCT_READ},
+			expected: Expected{allowed: false},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected.allowed, policy.Allowed(tc.given.roles, tc.given.permission))
+		})
+	}
+}
+
+func TestAllowedWithConfiguredRoles(t *testing.T) {
+	policy := New(config.Auth{Roles: map[string][]string{"kiosk": {"product:read", "audit:read"}}}, nil)
+
+	assert.True(t, policy.Allowed([]Role{ROLE_KIOSK}, PERMISSION_AUDIT_READ))
+	assert.True(t, policy.Allowed([]Role{ROLE_ADMIN}, PERMISSION_WEBHOOK_MANAGE))
+}
+
+func TestPublic(t *testing.T) {
+	policy := New(config.Auth{PublicReads: true}, nil)
+	assert.True(t, policy.Public(PERMISSION_PRODUCT_READ))
+	assert.False(t, policy.Public(PERMISSION_PRODUCT_WRITE))
+
+	policy = New(config.Auth{PublicReads: false}, nil)
+	assert.False(t, policy.Public(PERMISSION_PRODUCT_READ))
+}
+
+func TestRoles(t *testing.T) {
+	policy := New(config.Auth{RolesClaim: "roles"}, nil)
+
+	assert.Equal(t, []Role{ROLE_ADMIN, ROLE_KIOSK}, policy.Roles(jwt.MapClaims{"roles": []interface{}{"admin", "Kiosk"}}))
+	assert.Equal(t, []Role{ROLE_MANAGER, ROLE_SERVICE}, policy.Roles(jwt.MapClaims{"roles": "manager, service"}))
+	assert.Empty(t, policy.Roles(jwt.MapClaims{"sub": "user"}))
+}
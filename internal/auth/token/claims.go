@@ -0,0 +1,79 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// validateClaims checks exp, nbf and iat allowing Leeway of clock skew, and
+// iss and aud when they are configured.
+func (v *Verifier) validateClaims(claims jwt.MapClaims) error {
+	now := v.now()
+	leeway := v.settings.Leeway
+
+	if exp, ok, err := timeClaim(claims, "exp"); err != nil {
+		return err
+	} else if ok && now.After(exp.Add(leeway)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok, err := timeClaim(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(leeway).Before(nbf) {
+		return errors.New("token is not valid yet")
+	}
+	if iat, ok, err := timeClaim(claims, "iat"); err != nil {
+		return err
+	} else if ok && now.Add(leeway).Before(iat) {
+		return errors.New("token used before issued")
+	}
+
+	if v.settings.Issuer != "" {
+		if issuer, _ := claims["iss"].(string); issuer != v.settings.Issuer {
+			return errors.New("token has an unexpected issuer")
+		}
+	}
+
+	if len(v.settings.Audience) > 0 && !audienceMatches(claims["aud"], v.settings.Audience) {
+		return errors.New("token has an unexpected audience")
+	}
+
+	return nil
+}
+
+func timeClaim(claims jwt.MapClaims, name string) (time.Time, bool, error) {
+	switch value := claims[name].(type) {
+	case nil:
+		return time.Time{}, false, nil
+	case float64:
+		return time.Unix(int64(value), 0), true, nil
+	default:
+		return time.Time{}, false, errors.New("token has an invalid " + name + " claim")
+	}
+}
+
+// audienceMatches accepts aud as a single string or an array, as allowed by
+// RFC 7519, and requires at least one of the expected audiences.
+func audienceMatches(aud interface{}, expected []string) bool {
+	var audiences []string
+	switch value := aud.(type) {
+	case string:
+		audiences = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			if audience, ok := item.(string); ok {
+				audiences = append(audiences, audience)
+			}
+		}
+	}
+
+	for _, audience := range audiences {
+		for _, accepted := range expected {
+			if audience == accepted {
+				return true
+			}
+		}
+	}
+	return false
+}
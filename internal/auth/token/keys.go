@@ -0,0 +1,254 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"tech-challenge-product/internal/config"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/rs/zerolog/log"
+)
+
+// keyStore caches the public keys read from PublicKeyFile and JWKSFile.
+// The JWKS document is read again whenever it changes on disk, so keys can
+// be rotated by adding the new kid before using it and removing the old one
+// afterwards.
+type keyStore struct {
+	settings config.Token
+
+	mu        sync.Mutex
+	pem       map[string]interface{}
+	jwksPath  string
+	jwksMTime time.Time
+	jwks      map[string]interface{}
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func allowedMethods(settings config.Token) []string {
+	if len(settings.Algorithms) > 0 {
+		return settings.Algorithms
+	}
+
+	var methods []string
+	if settings.Key != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if settings.PublicKeyFile != "" || settings.JWKSFile != "" {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	return methods
+}
+
+// resolve returns the key for token, making sure its type matches the
+// signing method so a public key can never be used as an HMAC secret.
+func (s *keyStore) resolve(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if s.settings.Key == "" {
+			return nil, errors.New("HMAC tokens are not accepted")
+		}
+		return []byte(s.settings.Key), nil
+	case *jwt.SigningMethodRSA:
+		key, err := s.publicKey(token)
+		if _, ok := key.(*rsa.PublicKey); err == nil && !ok {
+			return nil, fmt.Errorf("key for %v is not an RSA key", token.Header["alg"])
+		}
+		return key, err
+	case *jwt.SigningMethodECDSA:
+		key, err := s.publicKey(token)
+		if _, ok := key.(*ecdsa.PublicKey); err == nil && !ok {
+			return nil, fmt.Errorf("key for %v is not an EC key", token.Header["alg"])
+		}
+		return key, err
+	default:
+		return nil, fmt.Errorf("unexpected signature method %v", token.Header["alg"])
+	}
+}
+
+func (s *keyStore) publicKey(token *jwt.Token) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kid, _ := token.Header["kid"].(string)
+	if kid != "" && s.settings.JWKSFile != "" {
+		if err := s.loadJWKS(s.settings.JWKSFile); err != nil {
+			return nil, err
+		}
+		key, ok := s.jwks[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}
+
+	if s.settings.PublicKeyFile == "" {
+		return nil, errors.New("no public key configured")
+	}
+	return s.loadPEM(s.settings.PublicKeyFile)
+}
+
+func (s *keyStore) loadPEM(path string) (interface{}, error) {
+	if key, ok := s.pem[path]; ok {
+		return key, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s.pem[path] = key
+	return key, nil
+}
+
+func (s *keyStore) loadJWKS(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if path == s.jwksPath && info.ModTime().Equal(s.jwksMTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	s.jwksPath = path
+	s.jwksMTime = info.ModTime()
+	s.jwks = parsed
+	return nil
+}
+
+func parsePEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return certificate.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// parseJWKS reads the signing keys of a JWKS document. Encryption keys and
+// keys of an unsupported type or curve are skipped, so a document
+// publishing them along with the signing keys can still be used.
+func parseJWKS(data []byte) (map[string]interface{}, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	parsed := map[string]interface{}{}
+	for _, key := range set.Keys {
+		if key.Use == "enc" {
+			log.Warn().Str("kid", key.Kid).Msg("skipped the JWKS encryption key")
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			log.Warn().Err(err).Str("kid", key.Kid).Msg("skipped an unusable JWKS key")
+			continue
+		}
+		parsed[key.Kid] = publicKey
+	}
+
+	if len(parsed) == 0 {
+		return nil, errors.New("no usable signing key")
+	}
+	return parsed, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -1,48 +1,103 @@
 package token
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"tech-challenge-product/internal/config"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
 var (
-	cfg = &config.Cfg
+	ErrorMissingToken = errors.New("missing bearer token")
+	ErrorInvalidToken = errors.New("invalid token")
 )
 
-func ValidateToken(r *http.Request) error {
-	tokenString := getToken(r)
+type claimsKey struct{}
 
-	token, err := jwt.Parse(tokenString, returnSecretKey)
+// Verifier checks tokens against the keys and claims of its settings.
+type Verifier struct {
+	settings config.Token
+	keys     *keyStore
+	now      func() time.Time
+}
+
+func New(settings config.Token) *Verifier {
+	return &Verifier{
+		settings: settings,
+		keys:     &keyStore{settings: settings, pem: map[string]interface{}{}},
+		now:      time.Now,
+	}
+}
+
+// ValidateToken validates the bearer token of r. See Parse.
+func (v *Verifier) ValidateToken(r *http.Request) (jwt.MapClaims, error) {
+	return v.Parse(getToken(r))
+}
+
+// Parse verifies the signature of tokenString with the configured keys and
+// validates its time, issuer and audience claims.
+func (v *Verifier) Parse(tokenString string) (jwt.MapClaims, error) {
+	if tokenString == "" {
+		return nil, ErrorMissingToken
+	}
+
+	parser := jwt.Parser{
+		ValidMethods: allowedMethods(v.settings),
+		// time claims are checked by validateClaims, which supports a leeway
+		SkipClaimsValidation: true,
+	}
+
+	token, err := parser.Parse(tokenString, v.keys.resolve)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidToken, err)
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrorInvalidToken
 	}
 
-	return errors.New("invalid token")
+	if err := v.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorInvalidToken, err)
+	}
+
+	return claims, nil
 }
 
-func getToken(r *http.Request) string {
-	token := r.Header.Get("Authorization")
+// WithClaims stores the validated claims of the caller in ctx.
+func WithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
 
-	if len(strings.Split(token, " ")) == 2 {
-		return strings.Split(token, " ")[1]
-	}
+// Claims returns the validated claims stored in ctx, or nil for
+// unauthenticated calls.
+func Claims(ctx context.Context) jwt.MapClaims {
+	claims, _ := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	return claims
+}
 
-	return ""
+// Subject returns the authenticated subject (the JWT "sub" claim) stored in
+// ctx, or an empty string for unauthenticated calls.
+func Subject(ctx context.Context) string {
+	subject, _ := Claims(ctx)["sub"].(string)
+	return subject
 }
 
-func returnSecretKey(token *jwt.Token) (interface{}, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected signature method %v", token.Header["alg"])
+func getToken(r *http.Request) string {
+	return BearerToken(r.Header.Get("Authorization"))
+}
+
+// BearerToken extracts the token from an Authorization header value.
+func BearerToken(header string) string {
+	parts := strings.Fields(header)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		return parts[1]
 	}
 
-	return []byte(cfg.Token.Key), nil
+	return ""
 }
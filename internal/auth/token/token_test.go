@@ -0,0 +1,279 @@
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"tech-challenge-product/internal/config"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	rsaKey, _     = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _      = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rotatedKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	assert.Nil(t, err)
+	return signed
+}
+
+func writePEM(t *testing.T, dir string) string {
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, "public.pem")
+	assert.Nil(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	return path
+}
+
+func writeJWKS(t *testing.T, path string, keys map[string]*ecdsa.PublicKey, modTime time.Time) {
+	encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+
+	set := jsonWebKeySet{}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jsonWebKey{Kty: "EC", Kid: kid, Crv: "P-256", X: encode(key.X), Y: encode(key.Y)})
+	}
+	data, _ := json.Marshal(set)
+
+	assert.Nil(t, os.WriteFile(path, data, 0600))
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func configure(t *testing.T) config.Token {
+	dir := t.TempDir()
+	settings := config.Token{
+		Key:           "test-key",
+		PublicKeyFile: writePEM(t, dir),
+		JWKSFile:      filepath.Join(dir, "jwks.json"),
+		Issuer:        "https://auth.example.com",
+		Audience:      []string{"product-api"},
+		Leeway:        30 * time.Second,
+	}
+	writeJWKS(t, settings.JWKSFile, map[string]*ecdsa.PublicKey{"key-1": &ecKey.PublicKey}, time.Now().Add(-time.Hour))
+	return settings
+}
+
+func TestParse(t *testing.T) {
+	verifier := New(configure(t))
+	now := time.Unix(1710072000, 0)
+	verifier.now = func() time.Time { return now }
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "maria",
+			"iss": "https://auth.example.com",
+			"aud": "product-api",
+			"exp": float64(now.Add(time.Hour).Unix()),
+			"iat": float64(now.Unix()),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	type Given struct {
+		token string
+	}
+	type Expected struct {
+		err error
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given HS256 token must be valid": {
+			given: Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(nil))},
+		},
+		"given RS256 token signed with the PEM key must be valid": {
+			given: Given{token: sign(t, jwt.SigningMethodRS256, rsaKey, "", claims(nil))},
+		},
+		"given ES256 token with known kid must be valid": {
+			given: Given{token: sign(t, jwt.SigningMethodES256, ecKey, "key-1", claims(nil))},
+		},
+		"given audience array containing ours must be valid": {
+			given: Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(jwt.MapClaims{"aud": []string{"other", "product-api"}}))},
+		},
+		"given token expired within leeway must be valid": {
+			given: Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(jwt.MapClaims{"exp": float64(now.Add(-10 * time.Second).Unix())}))},
+		},
+		"given empty token must be missing": {
+			given:    Given{token: ""},
+			expected: Expected{err: ErrorMissingToken},
+		},
+		"given token expired beyond leeway must be invalid": {
+			given:    Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(jwt.MapClaims{"exp": float64(now.Add(-time.Minute).Unix())}))},
+			expected: Expected{err: ErrorInvalidToken},
+		},
+		"given token not valid yet must be invalid": {
+			given:    Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(jwt.MapClaims{"nbf": float64(now.Add(time.Hour).Unix())}))},
+			expected: Expected{err: ErrorInvalidToken},
+		},
+		"given other issuer must be invalid": {
+			given:    Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(jwt.MapClaims{"iss": "https://evil.example.com"}))},
+			expected: Expected{err: ErrorInvalidToken},
+		},
+		"given missing audience must be invalid": {
+			given:    Given{token: sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", claims(jwt.MapClaims{"aud": nil}))},
+			expected: Expected{err: ErrorInvalidToken},
+		},
+		"given unknown kid must be invalid": {
+			given:    Given{token: sign(t, jwt.SigningMethodES256, ecKey, "key-9", claims(nil))},
+			expected: Expected{err: ErrorInvalidToken},
+		},
+		"given ES256 token without kid must not be checked with the RSA key": {
+			given:    Given{token: sign(t, jwt.SigningMethodES256, rotatedKey, "", claims(nil))},
+			expected: Expected{err: ErrorInvalidToken},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims, err := verifier.Parse(tc.given.token)
+
+			if tc.expected.err != nil {
+				assert.True(t, errors.Is(err, tc.expected.err), "expected %v, got %v", tc.expected.err, err)
+				assert.Nil(t, claims)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "maria", claims["sub"])
+		})
+	}
+}
+
+func TestParseRejectsAlgorithmConfusion(t *testing.T) {
+	settings := configure(t)
+	settings.Key = ""
+	verifier := New(settings)
+
+	publicPEM, _ := os.ReadFile(settings.PublicKeyFile)
+	forged := sign(t, jwt.SigningMethodHS256, publicPEM, "", jwt.MapClaims{
+		"sub": "attacker", "iss": "https://auth.example.com", "aud": "product-api",
+	})
+
+	_, err := verifier.Parse(forged)
+
+	assert.ErrorIs(t, err, ErrorInvalidToken)
+}
+
+func TestParseWithRotatedJWKS(t *testing.T) {
+	settings := configure(t)
+	verifier := New(settings)
+	claims := jwt.MapClaims{"sub": "svc", "iss": "https://auth.example.com", "aud": "product-api"}
+
+	_, err := verifier.Parse(sign(t, jwt.SigningMethodES256, rotatedKey, "key-2", claims))
+	assert.ErrorIs(t, err, ErrorInvalidToken)
+
+	writeJWKS(t, settings.JWKSFile, map[string]*ecdsa.PublicKey{
+		"key-1": &ecKey.PublicKey,
+		"key-2": &rotatedKey.PublicKey,
+	}, time.Now())
+
+	_, err = verifier.Parse(sign(t, jwt.SigningMethodES256, rotatedKey, "key-2", claims))
+	assert.Nil(t, err)
+
+	writeJWKS(t, settings.JWKSFile, map[string]*ecdsa.PublicKey{
+		"key-2": &rotatedKey.PublicKey,
+	}, time.Now().Add(time.Minute))
+
+	_, err = verifier.Parse(sign(t, jwt.SigningMethodES256, ecKey, "key-1", claims))
+	assert.ErrorIs(t, err, ErrorInvalidToken)
+}
+
+func TestParseJWKS(t *testing.T) {
+	encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	signing := jsonWebKey{Kty: "EC", Kid: "key-1", Crv: "P-256", X: encode(ecKey.X), Y: encode(ecKey.Y)}
+
+	type Given struct {
+		keys []jsonWebKey
+	}
+	type Expected struct {
+		kids []string
+		err  assert.ErrorAssertionFunc
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given only signing keys must read them all": {
+			given: Given{keys: []jsonWebKey{
+				signing,
+				{Kty: "RSA", Kid: "key-2", Use: "sig", N: encode(rsaKey.N), E: encode(big.NewInt(int64(rsaKey.E)))},
+			}},
+			expected: Expected{kids: []string{"key-1", "key-2"}, err: assert.NoError},
+		},
+		"given unsupported and encryption keys must skip them": {
+			given: Given{keys: []jsonWebKey{
+				{Kty: "oct", Kid: "hmac"},
+				{Kty: "OKP", Kid: "ed25519", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+				{Kty: "EC", Kid: "secp256k1", Crv: "secp256k1"},
+				{Kty: "RSA", Kid: "encryption", Use: "enc", N: encode(rsaKey.N), E: encode(big.NewInt(int64(rsaKey.E)))},
+				signing,
+			}},
+			expected: Expected{kids: []string{"key-1"}, err: assert.NoError},
+		},
+		"given no usable key must fail": {
+			given:    Given{keys: []jsonWebKey{{Kty: "oct", Kid: "hmac"}}},
+			expected: Expected{err: assert.Error},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, _ := json.Marshal(jsonWebKeySet{Keys: tc.given.keys})
+
+			keys, err := parseJWKS(data)
+
+			tc.expected.err(t, err)
+			var kids []string
+			for kid := range keys {
+				kids = append(kids, kid)
+			}
+			assert.ElementsMatch(t, tc.expected.kids, kids)
+		})
+	}
+}
+
+func TestClaimsContext(t *testing.T) {
+	verifier := New(configure(t))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "bearer "+sign(t, jwt.SigningMethodHS256, []byte("test-key"), "", jwt.MapClaims{
+		"sub": "maria", "iss": "https://auth.example.com", "aud": "product-api", "store": "store-1",
+	}))
+
+	claims, err := verifier.ValidateToken(req)
+	assert.Nil(t, err)
+
+	ctx := WithClaims(context.Background(), claims)
+
+	assert.Equal(t, "maria", Subject(ctx))
+	assert.Equal(t, "store-1", Claims(ctx)["store"])
+	assert.Equal(t, "", Subject(context.Background()))
+}
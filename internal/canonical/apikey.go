@@ -0,0 +1,35 @@
+package canonical
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrorInvalidAPIKey  = fmt.Errorf("invalid api key")
+	ErrorRejectedAPIKey = fmt.Errorf("api key is unknown, expired or revoked")
+)
+
+// APIKey is a credential for internal jobs. Only the SHA-256 hash of the key
+// is stored; Prefix keeps its first characters so it can be recognized.
+// A key with a StoreID only works on that store.
+type APIKey struct {
+	ID        string     `bson:"_id"`
+	Name      string     `bson:"name"`
+	StoreID   string     `bson:"store_id"`
+	Prefix    string     `bson:"prefix"`
+	Hash      string     `bson:"hash"`
+	Scopes    []string   `bson:"scopes"`
+	ExpiresAt *time.Time `bson:"expires_at"`
+	RevokedAt *time.Time `bson:"revoked_at"`
+	CreatedAt time.Time  `bson:"created_at"`
+	CreatedBy string     `bson:"created_by"`
+}
+
+// Active reports whether the key can still be used at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,54 @@
+package canonical
+
+import "time"
+
+type AuditAction string
+
+const (
+	AUDIT_CREATE AuditAction = "create"
+	AUDIT_UPDATE AuditAction = "update"
+	AUDIT_REMOVE AuditAction = "remove"
+)
+
+type AuditEntry struct {
+	ID        string        `bson:"_id"`
+	EntityID  string        `bson:"entity_id"`
+	StoreID   string        `bson:"store_id"`
+	Action    AuditAction   `bson:"action"`
+	Actor     string        `bson:"actor"`
+	Timestamp time.Time     `bson:"timestamp"`
+	Changes   []FieldChange `bson:"changes"`
+}
+
+type FieldChange struct {
+	Field  string      `bson:"field"`
+	Before interface{} `bson:"before"`
+	After  interface{} `bson:"after"`
+}
+
+type AuditFilter struct {
+	Actor string
+	From  time.Time
+	To    time.Time
+}
+
+// Diff lists the catalog fields that differ between two versions of a
+// product. Bookkeeping fields (version, timestamps, authors) are left out.
+func Diff(before, after Product) []FieldChange {
+	var changes []FieldChange
+
+	add := func(field string, old, new interface{}) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Before: old, After: new})
+		}
+	}
+
+	add("name", before.Name, after.Name)
+	add("description", before.Description, after.Description)
+	add("price", before.Price, after.Price)
+	add("category", before.Category, after.Category)
+	add("status", before.Status, after.Status)
+	add("image_path", before.ImagePath, after.ImagePath)
+
+	return changes
+}
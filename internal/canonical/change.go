@@ -0,0 +1,28 @@
+package canonical
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrorInvalidResumeToken = fmt.Errorf("invalid resume token")
+	ErrorResumeTokenExpired = fmt.Errorf("resume token is no longer available")
+)
+
+// ProductChange is a change read from the product collection. Token is an
+// opaque position in the change history that can be passed back to resume
+// right after this change.
+type ProductChange struct {
+	Token      string
+	Type       EventType
+	ProductID  string
+	Product    *Product
+	OccurredAt time.Time
+}
+
+// ChangeFilter narrows a watch to one category and/or a set of product IDs.
+type ChangeFilter struct {
+	Category string
+	IDs      []string
+}
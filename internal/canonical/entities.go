@@ -3,12 +3,19 @@ package canonical
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrorNotFound = fmt.Errorf("entity not found")
+	ErrorNotFound        = fmt.Errorf("entity not found")
+	ErrorVersionMismatch = fmt.Errorf("entity version mismatch")
+	ErrorInvalidSort     = fmt.Errorf("invalid sort key")
+	ErrorInvalidWebhook  = fmt.Errorf("invalid webhook")
+	ErrorBaseProduct     = fmt.Errorf("product belongs to the base catalog")
+	ErrorStoreRequired   = fmt.Errorf("a store is required")
 )
 
 type BaseStatus int
@@ -23,14 +30,60 @@ var MapBaseStatus = map[string]BaseStatus{ //ajustar chaves
 	"INACTIVE": STATUS_INACTIVE,
 }
 
+// Product belongs to the store in StoreID, or to the base catalog shared by
+// every store when StoreID is empty.
 type Product struct {
 	ID          string     `bson:"_id"`
+	StoreID     string     `bson:"store_id"`
 	Name        string     `bson:"name"`
 	Description string     `bson:"description"`
 	Price       float64    `bson:"price"`
 	Category    string     `bson:"category"`
 	Status      BaseStatus `bson:"status"`
 	ImagePath   string     `bson:"image_path"`
+	Version     int64      `bson:"version"`
+	CreatedAt   time.Time  `bson:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at"`
+	CreatedBy   string     `bson:"created_by"`
+	UpdatedBy   string     `bson:"updated_by"`
+	// Override is the price override applied for the store the product was
+	// read from, if any. It is never stored with the product.
+	Override *PriceOverride `bson:"-" json:"-"`
+}
+
+// CategoryCount is the number of active products of a category in a store.
+type CategoryCount struct {
+	StoreID  string
+	Category string
+	Count    int64
+}
+
+// ProductFilter narrows product listings. When UpdatedSince is set inactive
+// products are returned too, so incremental sync clients see removals.
+type ProductFilter struct {
+	Category     string
+	UpdatedSince time.Time
+	SortBy       string
+	Descending   bool
+}
+
+var productSortKeys = map[string]bool{
+	"name":       true,
+	"price":      true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// ParseSort reads a sort key such as "updated_at" or "-updated_at" (descending).
+func ParseSort(value string) (string, bool, error) {
+	descending := strings.HasPrefix(value, "-")
+	key := strings.TrimPrefix(value, "-")
+
+	if !productSortKeys[key] {
+		return "", false, fmt.Errorf("%w: %s", ErrorInvalidSort, key)
+	}
+
+	return key, descending, nil
 }
 
 func NewUUID() string {
@@ -0,0 +1,67 @@
+package canonical
+
+import "time"
+
+type EventType string
+
+const (
+	EVENT_PRODUCT_CREATED       EventType = "ProductCreated"
+	EVENT_PRODUCT_UPDATED       EventType = "ProductUpdated"
+	EVENT_PRODUCT_REMOVED       EventType = "ProductRemoved"
+	EVENT_PRODUCT_PRICE_CHANGED EventType = "ProductPriceChanged"
+)
+
+// Event is a domain event describing a change to a product. Product holds
+// the state after the change and Changes the fields that were modified.
+// StoreID is the store the change was made for, empty for the base catalog.
+type Event struct {
+	ID          string        `bson:"_id"`
+	Type        EventType     `bson:"type"`
+	AggregateID string        `bson:"aggregate_id"`
+	StoreID     string        `bson:"store_id,omitempty"`
+	OccurredAt  time.Time     `bson:"occurred_at"`
+	Product     Product       `bson:"product"`
+	Changes     []FieldChange `bson:"changes,omitempty"`
+}
+
+// OutboxMessage is an event waiting in the outbox to be delivered.
+type OutboxMessage struct {
+	Event         `bson:",inline"`
+	Attempts      int        `bson:"attempts"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at"`
+	DispatchedAt  *time.Time `bson:"dispatched_at"`
+	LastError     string     `bson:"last_error,omitempty"`
+}
+
+func NewEvent(eventType EventType, product Product, changes []FieldChange) Event {
+	return Event{
+		ID:          NewUUID(),
+		Type:        eventType,
+		AggregateID: product.ID,
+		OccurredAt:  product.UpdatedAt,
+		Product:     product,
+		Changes:     changes,
+	}
+}
+
+// ProductEvents lists the events raised by a product going from before to
+// after under the given audit action.
+func ProductEvents(action AuditAction, before, after Product) []Event {
+	changes := Diff(before, after)
+
+	switch action {
+	case AUDIT_CREATE:
+		return []Event{NewEvent(EVENT_PRODUCT_CREATED, after, changes)}
+	case AUDIT_REMOVE:
+		return []Event{NewEvent(EVENT_PRODUCT_REMOVED, after, changes)}
+	}
+
+	events := []Event{NewEvent(EVENT_PRODUCT_UPDATED, after, changes)}
+	if before.Price != after.Price {
+		events = append(events, NewEvent(EVENT_PRODUCT_PRICE_CHANGED, after, []FieldChange{
+			{Field: "price", Before: before.Price, After: after.Price},
+		}))
+	}
+
+	return events
+}
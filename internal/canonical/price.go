@@ -0,0 +1,26 @@
+package canonical
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrorInvalidPriceOverride = fmt.Errorf("invalid price override")
+)
+
+// PriceOverride replaces the price of a base catalog product for one store.
+type PriceOverride struct {
+	ID        string    `bson:"_id"`
+	StoreID   string    `bson:"store_id"`
+	ProductID string    `bson:"product_id"`
+	Price     float64   `bson:"price"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	UpdatedBy string    `bson:"updated_by"`
+}
+
+// PriceOverrideID is the ID of the override of productID in store, so a
+// store has at most one per product.
+func PriceOverrideID(store, productID string) string {
+	return store + ":" + productID
+}
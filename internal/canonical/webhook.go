@@ -0,0 +1,56 @@
+package canonical
+
+import "time"
+
+type DeliveryStatus string
+
+const (
+	DELIVERY_PENDING   DeliveryStatus = "pending"
+	DELIVERY_DELIVERED DeliveryStatus = "delivered"
+	DELIVERY_FAILED    DeliveryStatus = "failed"
+)
+
+// Webhook is a partner subscription to product events. An empty Events list
+// subscribes to every event type. A webhook of a store gets the events of
+// that store and of the base catalog; one without a StoreID only gets the
+// events of the base catalog.
+type Webhook struct {
+	ID        string      `bson:"_id"`
+	StoreID   string      `bson:"store_id"`
+	URL       string      `bson:"url"`
+	Events    []EventType `bson:"events"`
+	Secret    string      `bson:"secret"`
+	CreatedAt time.Time   `bson:"created_at"`
+	CreatedBy string      `bson:"created_by"`
+}
+
+func (w Webhook) Accepts(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, accepted := range w.Events {
+		if accepted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery tracks the delivery of one event to one webhook, including
+// every attempt made so far.
+type WebhookDelivery struct {
+	ID            string            `bson:"_id"`
+	WebhookID     string            `bson:"webhook_id"`
+	Event         Event             `bson:"event"`
+	Status        DeliveryStatus    `bson:"status"`
+	Attempts      []DeliveryAttempt `bson:"attempts"`
+	NextAttemptAt time.Time         `bson:"next_attempt_at"`
+	CreatedAt     time.Time         `bson:"created_at"`
+}
+
+type DeliveryAttempt struct {
+	Timestamp  time.Time     `bson:"timestamp"`
+	StatusCode int           `bson:"status_code"`
+	Error      string        `bson:"error,omitempty"`
+	Duration   time.Duration `bson:"duration"`
+}
@@ -0,0 +1,131 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"tech-challenge-product/internal/config"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// reloader serves the certificate and client CAs of a config.TLS, reading
+// the files again when they change so certificates can be renewed without a
+// restart. If a changed file can not be loaded the previous one stays in use.
+type reloader struct {
+	settings config.TLS
+
+	mu         sync.Mutex
+	lastCheck  time.Time
+	modTimes   map[string]time.Time
+	serverConf *tls.Config
+}
+
+// NewTLSConfig returns the server TLS configuration for settings, or nil
+// when TLS is disabled.
+func NewTLSConfig(settings config.TLS) (*tls.Config, error) {
+	if settings.CertFile == "" {
+		return nil, nil
+	}
+	if settings.KeyFile == "" {
+		return nil, errors.New("tls key_file is required with cert_file")
+	}
+	if settings.RequireClientCert && settings.ClientCAFile == "" {
+		return nil, errors.New("tls client_ca_file is required to verify client certificates")
+	}
+
+	r := &reloader{settings: settings, modTimes: map[string]time.Time{}}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		NextProtos:         []string{"h2", "http/1.1"},
+		GetConfigForClient: r.configForClient,
+	}, nil
+}
+
+func (r *reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastCheck) >= r.settings.ReloadInterval {
+		r.lastCheck = time.Now()
+		if r.changed() {
+			if err := r.load(); err != nil {
+				log.Error().Err(err).Str("cert_file", r.settings.CertFile).Msg("an error occurred when reload certificates")
+			} else {
+				log.Info().Str("cert_file", r.settings.CertFile).Msg("certificates reloaded")
+			}
+		}
+	}
+
+	return r.serverConf, nil
+}
+
+func (r *reloader) files() []string {
+	files := []string{r.settings.CertFile, r.settings.KeyFile}
+	if r.settings.ClientCAFile != "" {
+		files = append(files, r.settings.ClientCAFile)
+	}
+	return files
+}
+
+func (r *reloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err == nil && !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.settings.CertFile, r.settings.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	serverConf := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		// both listeners speak HTTP/2; gRPC clients require it through ALPN
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	if r.settings.ClientCAFile != "" {
+		data, err := os.ReadFile(r.settings.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("%s: no certificates found", r.settings.ClientCAFile)
+		}
+
+		serverConf.ClientCAs = pool
+		serverConf.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.settings.RequireClientCert {
+			serverConf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	r.serverConf = serverConf
+	r.modTimes = modTimes
+	return nil
+}
@@ -0,0 +1,159 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"tech-challenge-product/internal/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type authority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newAuthority(t *testing.T) authority {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	certificate, _ := x509.ParseCertificate(der)
+
+	return authority{certificate, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by the authority.
+func (a authority) issue(t *testing.T, serial int64, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.certificate, &key.PublicKey, a.key)
+	assert.Nil(t, err)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func write(t *testing.T, path string, data []byte, modTime time.Time) {
+	assert.Nil(t, os.WriteFile(path, data, 0600))
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+// handshake connects to a listener using conf and returns the server
+// certificate serial, or the error seen by the server.
+func handshake(t *testing.T, conf *tls.Config, client *tls.Config) (int64, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", conf)
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err == nil {
+		defer conn.Close()
+	}
+	if err := <-serverErr; err != nil {
+		return 0, err
+	}
+	assert.Nil(t, err)
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newAuthority(t)
+	serverCert, serverKey := ca.issue(t, 10, "localhost", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, 20, "batch-job", x509.ExtKeyUsageClientAuth)
+
+	settings := config.TLS{
+		CertFile:          filepath.Join(dir, "server.pem"),
+		KeyFile:           filepath.Join(dir, "server-key.pem"),
+		ClientCAFile:      filepath.Join(dir, "ca.pem"),
+		RequireClientCert: true,
+	}
+	past := time.Now().Add(-time.Minute)
+	write(t, settings.CertFile, serverCert, past)
+	write(t, settings.KeyFile, serverKey, past)
+	write(t, settings.ClientCAFile, ca.pem, past)
+
+	conf, err := NewTLSConfig(settings)
+	assert.Nil(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	client, _ := tls.X509KeyPair(clientCert, clientKey)
+
+	serial, err := handshake(t, conf, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{client}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), serial)
+
+	_, err = handshake(t, conf, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	assert.NotNil(t, err, "client without certificate must be refused")
+
+	renewedCert, renewedKey := ca.issue(t, 11, "localhost", x509.ExtKeyUsageServerAuth)
+	write(t, settings.CertFile, renewedCert, time.Now())
+	write(t, settings.KeyFile, renewedKey, time.Now())
+
+	serial, err = handshake(t, conf, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{client}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), serial, "renewed certificate must be served without restart")
+
+	write(t, settings.CertFile, []byte("broken"), time.Now().Add(time.Minute))
+
+	serial, err = handshake(t, conf, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{client}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), serial, "broken files must keep the previous certificate")
+}
+
+func TestNewTLSConfigSettings(t *testing.T) {
+	conf, err := NewTLSConfig(config.TLS{})
+	assert.Nil(t, err)
+	assert.Nil(t, conf)
+
+	_, err = NewTLSConfig(config.TLS{CertFile: "server.pem"})
+	assert.NotNil(t, err)
+
+	_, err = NewTLSConfig(config.TLS{CertFile: "server.pem", KeyFile: "server-key.pem", RequireClientCert: true})
+	assert.NotNil(t, err)
+
+	_, err = NewTLSConfig(config.TLS{CertFile: "missing.pem", KeyFile: "missing-key.pem"})
+	assert.NotNil(t, err)
+}
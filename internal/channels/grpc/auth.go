@@ -0,0 +1,224 @@
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/tenant"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	protocol "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// methodPermissions is the permission required by each RPC. Methods not
+// listed here nor in the allowlist are refused.
+var methodPermissions = map[string]rbac.Permission{
+	"/ProductService/GetProduct":    rbac.PERMISSION_PRODUCT_READ,
+	"/ProductService/ListProducts":  rbac.PERMISSION_PRODUCT_READ,
+	"/ProductService/WatchProducts": rbac.PERMISSION_PRODUCT_READ,
+	"/ProductService/UpdateProduct": rbac.PERMISSION_PRODUCT_WRITE,
+}
+
+// defaultAllowlist holds the methods callable without credentials, so
+// orchestrators can probe the server.
+var defaultAllowlist = []string{
+	"/grpc.health.v1.Health/Check",
+	"/grpc.health.v1.Health/Watch",
+}
+
+// Authorizer identifies the callers of the gRPC methods.
+type Authorizer struct {
+	principals *principal.Authorizer
+	stores     tenant.Resolver
+	settings   config.Auth
+}
+
+// NewAuthorizer authenticates callers with principals, scopes calls with
+// stores and lets the methods of the auth.grpc_allowlist setting through.
+func NewAuthorizer(principals *principal.Authorizer, stores tenant.Resolver, settings config.Auth) Authorizer {
+	return Authorizer{
+		principals: principals,
+		stores:     stores,
+		settings:   settings,
+	}
+}
+
+// UnaryAuthInterceptor authorizes every call (see authorize).
+func UnaryAuthInterceptor(authorizer Authorizer) protocol.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *protocol.UnaryServerInfo, handler protocol.UnaryHandler) (interface{}, error) {
+		ctx, err := authorizer.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuthInterceptor(authorizer Authorizer) protocol.StreamServerInterceptor {
+	return func(srv interface{}, stream protocol.ServerStream, info *protocol.StreamServerInfo, handler protocol.StreamHandler) error {
+		ctx, err := authorizer.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream exposes the context built by an interceptor, carrying the
+// caller claims or the request logger, to stream handlers.
+type contextStream struct {
+	protocol.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize checks the API key in the "x-api-key" metadata, or else the
+// bearer token in "authorization", against the permission of method,
+// answering Unauthenticated or PermissionDenied, and scopes the call to the
+// store of the caller (see tenant.Resolve).
+func (a Authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	if a.allowlisted(method) {
+		return ctx, nil
+	}
+
+	permission, ok := methodPermissions[method]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+
+	header := incoming(ctx, "authorization")
+	apiKey := incoming(ctx, strings.ToLower(principal.APIKeyHeader))
+
+	claims, err := a.authenticate(ctx, header, apiKey, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := a.stores.Resolve(claims, incoming(ctx, strings.ToLower(a.stores.Header())))
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	ctx = tenant.WithStore(ctx, store)
+	if claims != nil {
+		ctx = token.WithClaims(ctx, claims)
+	}
+	return ctx, nil
+}
+
+// authenticate returns the claims of the caller, or nil for anonymous calls
+// to public methods.
+func (a Authorizer) authenticate(ctx context.Context, header, apiKey string, permission rbac.Permission) (jwt.MapClaims, error) {
+	if header == "" && apiKey == "" {
+		if claims, ok := a.certificateClaims(ctx); ok {
+			if !a.principals.Allowed(claims, permission) {
+				return nil, status.Error(codes.PermissionDenied, "missing permission "+string(permission))
+			}
+			return claims, nil
+		}
+	}
+
+	claims, err := a.principals.Authorize(ctx, principal.Credentials{
+		Token:  token.BearerToken(header),
+		APIKey: apiKey,
+	}, permission)
+	if errors.Is(err, rbac.ErrorForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "missing permission "+string(permission))
+	}
+	if principal.Unauthenticated(err) {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+	if err != nil {
+		logging.From(ctx).Error().Err(err).Msg("could not authenticate call")
+		return nil, status.Error(codes.Internal, "could not authenticate call")
+	}
+
+	return claims, nil
+}
+
+// certificateClaims describes a caller presenting a client certificate
+// verified by mutual TLS, with the roles and store granted to it in the
+// auth.certificates setting. Certificates without an entry are not
+// recognized, and the caller is treated as anonymous.
+func (a Authorizer) certificateClaims(ctx context.Context) (jwt.MapClaims, bool) {
+	caller, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := caller.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	for _, identity := range identities(info.State.VerifiedChains[0][0]) {
+		granted, ok := a.settings.Certificates[identity]
+		if !ok {
+			continue
+		}
+
+		roles := make([]interface{}, 0, len(granted.Roles))
+		for _, role := range granted.Roles {
+			roles = append(roles, role)
+		}
+		claims := jwt.MapClaims{
+			"sub":                 identity,
+			a.settings.RolesClaim: roles,
+		}
+		if granted.Store != "" {
+			claims[a.stores.Claim()] = granted.Store
+		}
+		return claims, true
+	}
+
+	logging.From(ctx).Warn().Str("subject", info.State.VerifiedChains[0][0].Subject.String()).Msg("client certificate is not granted any role")
+	return nil, false
+}
+
+// identities lists what a certificate can be granted roles under, most
+// specific first: its URI SANs (such as SPIFFE IDs), its DNS SANs, then its
+// subject common name.
+func identities(certificate *x509.Certificate) []string {
+	var identities []string
+	for _, uri := range certificate.URIs {
+		identities = append(identities, uri.String())
+	}
+	identities = append(identities, certificate.DNSNames...)
+	if certificate.Subject.CommonName != "" {
+		identities = append(identities, certificate.Subject.CommonName)
+	}
+	return identities
+}
+
+func incoming(ctx context.Context, key string) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func (a Authorizer) allowlisted(method string) bool {
+	for _, allowed := range append(defaultAllowlist, a.settings.GRPCAllowlist...) {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
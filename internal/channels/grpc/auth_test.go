@@ -0,0 +1,249 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"net/url"
+	"strings"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func bearer(roles ...string) string {
+	signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "caller", "roles": roles}).SignedString([]byte("test-key"))
+	return "Bearer " + signed
+}
+
+// testAuthorizer builds the Authorizer of NewServer from settings.
+func testAuthorizer(settings config.Config, keys principal.APIKeys) Authorizer {
+	policy := rbac.New(settings.Auth, token.New(settings.Token))
+	stores := tenant.New(settings.Tenancy, policy)
+	return NewAuthorizer(principal.New(policy, keys, settings.Tenancy), stores, settings.Auth)
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	settings := config.Config{}
+	settings.Token.Key = "test-key"
+	settings.Auth.RolesClaim = "roles"
+
+	mockS.On("GetProductsWithId", []string{"auth"}).Return([]canonical.Product{{ID: "auth"}}, nil)
+	mockS.On("Update", mock.MatchedBy(func(ctx context.Context) bool { return token.Subject(ctx) == "caller" }), "auth", mock.Anything).Return(nil)
+	mockS.On("GetByID", mock.Anything, "auth").Return(&canonical.Product{ID: "auth", Version: 1}, nil)
+	mockS.On("Watch", mock.Anything, canonical.ChangeFilter{IDs: []string{"auth"}}, "").Return([]canonical.ProductChange{}, nil)
+
+	apiKeys := &APIKeysMock{}
+	apiKeys.On("Authenticate", mock.Anything, "pk_reader").Return(&canonical.APIKey{ID: "reader", Scopes: []string{"product:read"}}, nil)
+
+	authorizer := testAuthorizer(settings, apiKeys)
+	client, f := server(
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor(authorizer)),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor(authorizer)),
+	)
+	defer f()
+
+	withToken := func(authorization string) context.Context {
+		if authorization == "" {
+			return context.Background()
+		}
+		if strings.HasPrefix(authorization, "pk_") {
+			return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", authorization)
+		}
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", authorization)
+	}
+
+	type Given struct {
+		// authorization is a bearer token, or an API key when prefixed by pk_
+		authorization string
+		call          func(ctx context.Context) error
+	}
+	type Expected struct {
+		code codes.Code
+	}
+
+	getProduct := func(ctx context.Context) error {
+		_, err := client.GetProduct(ctx, &Ids{Ids: []string{"auth"}})
+		return err
+	}
+	updateProduct := func(ctx context.Context) error {
+		_, err := client.UpdateProduct(ctx, &UpdateProductRequest{Id: "auth", Price: "1.00"})
+		return err
+	}
+	watchProducts := func(ctx context.Context) error {
+		stream, err := client.WatchProducts(ctx, &WatchProductsRequest{Ids: []string{"auth"}})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given no token on read must be unauthenticated": {
+			given:    Given{call: getProduct},
+			expected: Expected{code: codes.Unauthenticated},
+		},
+		"given invalid token must be unauthenticated": {
+			given:    Given{authorization: "Bearer invalid", call: getProduct},
+			expected: Expected{code: codes.Unauthenticated},
+		},
+		"given kiosk token on read must be allowed": {
+			given:    Given{authorization: bearer("kiosk"), call: getProduct},
+			expected: Expected{code: codes.OK},
+		},
+		"given kiosk token on update must be denied": {
+			given:    Given{authorization: bearer("kiosk"), call: updateProduct},
+			expected: Expected{code: codes.PermissionDenied},
+		},
+		"given service token on update must reach the service with the caller": {
+			given:    Given{authorization: bearer("service"), call: updateProduct},
+			expected: Expected{code: codes.OK},
+		},
+		"given api key with the scope on read must be allowed": {
+			given:    Given{authorization: "pk_reader", call: getProduct},
+			expected: Expected{code: codes.OK},
+		},
+		"given api key without the scope on update must be denied": {
+			given:    Given{authorization: "pk_reader", call: updateProduct},
+			expected: Expected{code: codes.PermissionDenied},
+		},
+		"given no token on stream must be unauthenticated": {
+			given:    Given{call: watchProducts},
+			expected: Expected{code: codes.Unauthenticated},
+		},
+		"given kiosk token on stream must be allowed": {
+			given:    Given{authorization: bearer("kiosk"), call: watchProducts},
+			expected: Expected{code: codes.OK},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.given.call(withToken(tc.given.authorization))
+
+			assert.Equal(t, tc.expected.code, status.Code(err))
+		})
+	}
+}
+
+func TestAuthorizeAllowlist(t *testing.T) {
+	settings := config.Config{}
+	settings.Auth.GRPCAllowlist = []string{"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"}
+	authorizer := testAuthorizer(settings, nil)
+
+	_, err := authorizer.authorize(context.Background(), "/grpc.health.v1.Health/Check")
+	assert.Nil(t, err)
+
+	_, err = authorizer.authorize(context.Background(), "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo")
+	assert.Nil(t, err)
+
+	_, err = authorizer.authorize(context.Background(), "/ProductService/Unknown")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAuthorizeClientCertificate(t *testing.T) {
+	settings := config.Config{}
+	settings.Auth.RolesClaim = "roles"
+	settings.Auth.Certificates = map[string]config.Certificate{
+		"batch-job":                      {Roles: []string{"service"}},
+		"spiffe://cluster/store-1/kiosk": {Roles: []string{"kiosk"}, Store: "store_1"},
+	}
+	settings.Tenancy.Claim = "store_id"
+	authorizer := testAuthorizer(settings, nil)
+
+	verified := func(certificate *x509.Certificate) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{certificate}},
+			}},
+		})
+	}
+	kiosk, _ := url.Parse("spiffe://cluster/store-1/kiosk")
+
+	type Given struct {
+		ctx    context.Context
+		method string
+	}
+	type Expected struct {
+		code    codes.Code
+		subject string
+		store   string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given certificate granted by common name must get its roles": {
+			given: Given{
+				ctx:    verified(&x509.Certificate{Subject: pkix.Name{CommonName: "batch-job"}}),
+				method: "/ProductService/UpdateProduct",
+			},
+			expected: Expected{code: codes.OK, subject: "batch-job"},
+		},
+		"given certificate granted by URI SAN must be bound to its store": {
+			given: Given{
+				ctx:    verified(&x509.Certificate{Subject: pkix.Name{CommonName: "kiosk"}, URIs: []*url.URL{kiosk}}),
+				method: "/ProductService/GetProduct",
+			},
+			expected: Expected{code: codes.OK, subject: "spiffe://cluster/store-1/kiosk", store: "store_1"},
+		},
+		"given certificate without the permission must be denied": {
+			given: Given{
+				ctx:    verified(&x509.Certificate{URIs: []*url.URL{kiosk}}),
+				method: "/ProductService/UpdateProduct",
+			},
+			expected: Expected{code: codes.PermissionDenied},
+		},
+		"given certificate not granted any role must be unauthenticated": {
+			given: Given{
+				ctx:    verified(&x509.Certificate{Subject: pkix.Name{CommonName: "someone"}, DNSNames: []string{"someone.example.com"}}),
+				method: "/ProductService/UpdateProduct",
+			},
+			expected: Expected{code: codes.Unauthenticated},
+		},
+		"given unverified certificate must be unauthenticated": {
+			given: Given{
+				ctx: peer.NewContext(context.Background(), &peer.Peer{
+					AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{}},
+				}),
+				method: "/ProductService/UpdateProduct",
+			},
+			expected: Expected{code: codes.Unauthenticated},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, err := authorizer.authorize(tc.given.ctx, tc.given.method)
+
+			assert.Equal(t, tc.expected.code, status.Code(err))
+			if err == nil {
+				assert.Equal(t, tc.expected.subject, token.Subject(ctx))
+				assert.Equal(t, tc.expected.store, tenant.Store(ctx))
+			}
+		})
+	}
+}
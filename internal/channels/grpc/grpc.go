@@ -4,40 +4,208 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/certs"
 	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/health"
+	"tech-challenge-product/internal/metrics"
+	"tech-challenge-product/internal/ratelimit"
 	"tech-challenge-product/internal/service"
+	"tech-challenge-product/internal/tenant"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	protocol "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 type productGRPCServer struct {
 	service.ProductService
 	UnimplementedProductServiceServer
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func New(products service.ProductService) ProductServiceServer {
+	return newProductServer(products)
 }
 
-func New() ProductServiceServer {
+func newProductServer(products service.ProductService) *productGRPCServer {
 	return &productGRPCServer{
-		ProductService: service.NewProductService(),
+		ProductService: products,
+		closing:        make(chan struct{}),
 	}
 }
 
-func Listen() error {
-	server := protocol.NewServer()
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", config.Get().Server.GRPC))
+// CloseStreams ends the WatchProducts streams with Unavailable, so a stop
+// does not wait for them and clients reconnect with their last resume
+// token.
+func (p *productGRPCServer) CloseStreams() {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+}
+
+// Server serves ProductService and grpc.health.v1.
+type Server struct {
+	server   *protocol.Server
+	products *productGRPCServer
+	health   *grpchealth.Server
+	stop     context.CancelFunc
+	port     string
+}
+
+// NewServer serves products, authorizing the callers with principals,
+// scoping their calls to a store with stores, reporting the health given
+// by checker and recording the calls with recorder. Peers are rate limited
+// before their credentials are checked, then each authorized caller gets
+// its own buckets.
+func NewServer(settings config.Config, products service.ProductService, principals *principal.Authorizer, stores tenant.Resolver, checker health.Checker, recorder *metrics.Recorder) (*Server, error) {
+	limiter := ratelimit.New(settings.RateLimit)
+	authorizer := NewAuthorizer(principals, stores, settings.Auth)
+	options := []protocol.ServerOption{
+		// reads the W3C trace context of the caller from the metadata
+		protocol.StatsHandler(otelgrpc.NewServerHandler()),
+		protocol.ChainUnaryInterceptor(UnaryLoggingInterceptor, UnaryMetricsInterceptor(recorder), UnaryPeerRateLimitInterceptor(limiter), UnaryAuthInterceptor(authorizer), UnaryRateLimitInterceptor(limiter)),
+		protocol.ChainStreamInterceptor(StreamLoggingInterceptor, StreamMetricsInterceptor(recorder), StreamPeerRateLimitInterceptor(limiter), StreamAuthInterceptor(authorizer), StreamRateLimitInterceptor(limiter)),
+	}
+
+	tlsConfig, err := certs.NewTLSConfig(settings.TLS.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options = append(options, protocol.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := protocol.NewServer(options...)
+	productServer := newProductServer(products)
+	RegisterProductServiceServer(server, productServer)
+
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	ctx, stop := context.WithCancel(context.Background())
+	go watchHealth(ctx, checker, healthServer, settings.Health.Interval)
+
+	return &Server{
+		server:   server,
+		products: productServer,
+		health:   healthServer,
+		stop:     stop,
+		port:     settings.Server.GRPC,
+	}, nil
+}
+
+// Serve listens on the gRPC port until Stop is called.
+func (s *Server) Serve() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.port))
 	if err != nil {
 		return err
 	}
 
-	RegisterProductServiceServer(server, New())
+	return s.server.Serve(listener)
+}
+
+// Stop reports the server as not serving, ends the WatchProducts streams,
+// stops accepting calls and waits for the calls in progress until ctx is
+// done. Calls still running then are cancelled.
+func (s *Server) Stop(ctx context.Context) error {
+	s.stop()
+	s.health.Shutdown()
+	s.products.CloseStreams()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
 
-	return server.Serve(listener)
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
 }
 
 func (p *productGRPCServer) GetProduct(ctx context.Context, ids *Ids) (*Products, error) {
-	products, err := p.ProductService.GetProductsWithId(context.Background(), ids.Ids)
+	products, err := p.ProductService.GetProductsWithId(ctx, ids.Ids)
 	if err != nil {
 		return nil, err
 	}
 
 	return toResult(products), nil
 }
+
+func (p *productGRPCServer) UpdateProduct(ctx context.Context, req *UpdateProductRequest) (*Product, error) {
+	product, err := fromUpdateRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := p.ProductService.Update(ctx, req.Id, *product); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	updated, err := p.ProductService.GetByID(ctx, req.Id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toProduct(*updated), nil
+}
+
+func (p *productGRPCServer) ListProducts(ctx context.Context, req *ListProductsRequest) (*Products, error) {
+	filter, err := fromListRequest(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	products, err := p.ProductService.Find(ctx, filter)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return toResult(products), nil
+}
+
+// WatchProducts streams product changes until the client goes away or
+// CloseStreams is called. Clients that reconnect send the resume_token of
+// the last change they received.
+func (p *productGRPCServer) WatchProducts(req *WatchProductsRequest, stream ProductService_WatchProductsServer) error {
+	filter := canonical.ChangeFilter{
+		Category: req.Category,
+		IDs:      req.Ids,
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := p.ProductService.Watch(ctx, filter, req.ResumeToken, func(change canonical.ProductChange) error {
+		return stream.Send(toChange(change))
+	})
+	select {
+	case <-p.closing:
+		return status.Error(codes.Unavailable, "server is shutting down")
+	default:
+	}
+	if err != nil {
+		return toStatusError(err)
+	}
+	return nil
+}
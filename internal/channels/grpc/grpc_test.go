@@ -2,16 +2,22 @@ package grpc
 
 import (
 	"context"
+	"io"
 	"log"
 	"net"
 	"tech-challenge-product/internal/canonical"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
@@ -20,10 +26,10 @@ var (
 
 var buff int = 10 * 1024
 
-func server() (ProductServiceClient, func()) {
+func server(opts ...grpc.ServerOption) (ProductServiceClient, func()) {
 	lis := bufconn.Listen(buff)
 
-	server := grpc.NewServer()
+	server := grpc.NewServer(opts...)
 
 	RegisterProductServiceServer(server, &productGRPCServer{
 		ProductService: &mockS,
@@ -93,3 +99,154 @@ func TestGetProduct(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, products)
 }
+
+func TestUpdateProduct(t *testing.T) {
+	updated := canonical.Product{
+		ID:          "789",
+		Name:        "test",
+		Description: "desc",
+		Price:       12.5,
+		Category:    "cat",
+		Status:      canonical.STATUS_ACTIVE,
+		ImagePath:   "path",
+		Version:     4,
+	}
+	mockS.On("Update", mock.Anything, "789", canonical.Product{
+		ID:          "789",
+		Name:        "test",
+		Description: "desc",
+		Price:       12.5,
+		Category:    "cat",
+		Status:      canonical.STATUS_ACTIVE,
+		ImagePath:   "path",
+		Version:     3,
+	}).Return(nil)
+	mockS.On("Update", mock.Anything, "789", mock.Anything).Return(canonical.ErrorVersionMismatch)
+	mockS.On("GetByID", mock.Anything, "789").Return(&updated, nil)
+
+	server, f := server()
+
+	defer f()
+
+	request := &UpdateProductRequest{
+		Id:          "789",
+		Name:        "test",
+		Description: "desc",
+		Price:       "12.50",
+		Category:    "cat",
+		ImagePath:   "path",
+		Version:     3,
+	}
+
+	product, err := server.UpdateProduct(context.Background(), request)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), product.Version)
+
+	request.Version = 2
+	_, err = server.UpdateProduct(context.Background(), request)
+
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	request.Price = "abc"
+	_, err = server.UpdateProduct(context.Background(), request)
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListProducts(t *testing.T) {
+	since := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockS.On("Find", mock.Anything, canonical.ProductFilter{
+		Category:     "cat",
+		UpdatedSince: since,
+		SortBy:       "updated_at",
+		Descending:   true,
+	}).Return([]canonical.Product{
+		{
+			ID:        "123",
+			Name:      "test",
+			Price:     123,
+			Category:  "cat",
+			Status:    canonical.STATUS_INACTIVE,
+			UpdatedAt: since.Add(time.Minute),
+			UpdatedBy: "admin",
+		},
+	}, nil)
+
+	server, f := server()
+
+	defer f()
+
+	products, err := server.ListProducts(context.Background(), &ListProductsRequest{
+		Category:     "cat",
+		UpdatedSince: timestamppb.New(since),
+		Sort:         "-updated_at",
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, products.Products, 1)
+	assert.Equal(t, "admin", products.Products[0].UpdatedBy)
+	assert.Equal(t, since.Add(time.Minute), products.Products[0].UpdatedAt.AsTime())
+
+	_, err = server.ListProducts(context.Background(), &ListProductsRequest{Sort: "description"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatchProducts(t *testing.T) {
+	occurredAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockS.On("Watch", mock.Anything, canonical.ChangeFilter{Category: "drinks", IDs: []string{"123"}}, "token_1").Return([]canonical.ProductChange{
+		{
+			Token:      "token_2",
+			Type:       canonical.EVENT_PRODUCT_UPDATED,
+			ProductID:  "123",
+			Product:    &canonical.Product{ID: "123", Name: "Soda", Price: 5, Category: "drinks", Version: 2},
+			OccurredAt: occurredAt,
+		},
+		{
+			Token:      "token_3",
+			Type:       canonical.EVENT_PRODUCT_REMOVED,
+			ProductID:  "123",
+			OccurredAt: occurredAt.Add(time.Minute),
+		},
+	}, nil)
+	mockS.On("Watch", mock.Anything, canonical.ChangeFilter{}, "expired").Return([]canonical.ProductChange{}, canonical.ErrorResumeTokenExpired)
+
+	server, f := server()
+
+	defer f()
+
+	stream, err := server.WatchProducts(context.Background(), &WatchProductsRequest{
+		Category:    "drinks",
+		Ids:         []string{"123"},
+		ResumeToken: "token_1",
+	})
+	assert.Nil(t, err)
+
+	change, err := stream.Recv()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ProductUpdated", change.Type)
+	assert.Equal(t, "token_2", change.ResumeToken)
+	assert.Equal(t, "5.00", change.Product.Price)
+	assert.Equal(t, occurredAt, change.OccurredAt.AsTime())
+
+	change, err = stream.Recv()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ProductRemoved", change.Type)
+	assert.Nil(t, change.Product)
+
+	_, err = stream.Recv()
+
+	assert.Equal(t, io.EOF, err)
+
+	stream, err = server.WatchProducts(context.Background(), &WatchProductsRequest{ResumeToken: "expired"})
+	assert.Nil(t, err)
+
+	_, err = stream.Recv()
+
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"context"
+	"tech-challenge-product/internal/health"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// watchHealth refreshes the grpc.health.v1 status of the server, and of
+// ProductService, with the readiness of the dependencies every interval
+// until ctx is done.
+func watchHealth(ctx context.Context, checker health.Checker, server *grpchealth.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		updateHealth(ctx, checker, server)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func updateHealth(ctx context.Context, checker health.Checker, server *grpchealth.Server) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if checker.Ready(ctx).Status != health.STATUS_UP {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	server.SetServingStatus("", status)
+	server.SetServingStatus(ProductService_ServiceDesc.ServiceName, status)
+}
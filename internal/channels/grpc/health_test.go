@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/health"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestUpdateHealth(t *testing.T) {
+	var mongoErr error
+	checker := health.New(time.Second, health.Dependency{
+		Name:  "mongo",
+		Check: func(context.Context) error { return mongoErr },
+	})
+	server := grpchealth.NewServer()
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		assert.Nil(t, err)
+		return resp.GetStatus()
+	}
+
+	updateHealth(context.Background(), checker, server)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status("ProductService"))
+
+	mongoErr = errors.New("connection refused")
+	updateHealth(context.Background(), checker, server)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("ProductService"))
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"tech-challenge-product/internal/logging"
+	"time"
+
+	"github.com/rs/zerolog"
+	protocol "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	requestIDKey = strings.ToLower(logging.RequestIDHeader)
+)
+
+// UnaryLoggingInterceptor scopes every call to the request ID in the
+// x-request-id metadata, or a generated one, sends it back as a header and
+// logs the call once it returns.
+func UnaryLoggingInterceptor(ctx context.Context, req interface{}, info *protocol.UnaryServerInfo, handler protocol.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	protocol.SetHeader(ctx, metadata.Pairs(requestIDKey, logging.RequestID(ctx)))
+
+	resp, err := handler(ctx, req)
+
+	logCall(ctx, info.FullMethod, err, time.Since(start))
+	return resp, err
+}
+
+func StreamLoggingInterceptor(srv interface{}, stream protocol.ServerStream, info *protocol.StreamServerInfo, handler protocol.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(stream.Context())
+	stream.SetHeader(metadata.Pairs(requestIDKey, logging.RequestID(ctx)))
+
+	err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+
+	logCall(ctx, info.FullMethod, err, time.Since(start))
+	return err
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	return logging.WithRequestID(ctx, logging.ResolveRequestID(incoming(ctx, requestIDKey)))
+}
+
+func logCall(ctx context.Context, method string, err error, elapsed time.Duration) {
+	code := status.Code(err)
+
+	var entry *zerolog.Event
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.ResourceExhausted, codes.OutOfRange, codes.Aborted:
+		entry = logging.From(ctx).Info()
+	default:
+		entry = logging.From(ctx).Error().Err(err)
+	}
+
+	entry.
+		Str("method", method).
+		Str("code", code.String()).
+		Dur("latency", elapsed).
+		Str("peer_ip", peerIP(ctx)).
+		Msg("call handled")
+}
@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLoggingInterceptors(t *testing.T) {
+	mockS.On("GetProductsWithId", []string{"logged"}).Return([]canonical.Product{{ID: "logged"}}, nil)
+
+	var handled string
+	client, f := server(
+		grpc.ChainUnaryInterceptor(UnaryLoggingInterceptor, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			handled = logging.RequestID(ctx)
+			return handler(ctx, req)
+		}),
+	)
+	defer f()
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-1")
+	_, err := client.GetProduct(ctx, &Ids{Ids: []string{"logged"}}, grpc.Header(&header))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "req-1", handled)
+	assert.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+
+	_, err = client.GetProduct(context.Background(), &Ids{Ids: []string{"logged"}}, grpc.Header(&header))
+
+	assert.Nil(t, err)
+	assert.Len(t, handled, 32)
+	assert.Equal(t, []string{handled}, header.Get("x-request-id"))
+}
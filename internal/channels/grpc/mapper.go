@@ -1,25 +1,107 @@
 package grpc
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"tech-challenge-product/internal/canonical"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func toResult(products []canonical.Product) *Products {
 	var result []*Product
 
 	for _, product := range products {
-		productResult := Product{
-			Id:       product.ID,
-			Name:     product.Name,
-			Price:    fmt.Sprintf("%.2f", product.Price),
-			Category: product.Category,
-		}
-
-		result = append(result, &productResult)
+		result = append(result, toProduct(product))
 	}
 
 	return &Products{
 		Products: result,
 	}
 }
+
+func toProduct(product canonical.Product) *Product {
+	return &Product{
+		Id:        product.ID,
+		Name:      product.Name,
+		Price:     fmt.Sprintf("%.2f", product.Price),
+		Category:  product.Category,
+		Version:   product.Version,
+		CreatedAt: timestamppb.New(product.CreatedAt),
+		UpdatedAt: timestamppb.New(product.UpdatedAt),
+		CreatedBy: product.CreatedBy,
+		UpdatedBy: product.UpdatedBy,
+	}
+}
+
+func toChange(change canonical.ProductChange) *ProductChange {
+	result := &ProductChange{
+		Type:        string(change.Type),
+		ProductId:   change.ProductID,
+		OccurredAt:  timestamppb.New(change.OccurredAt),
+		ResumeToken: change.Token,
+	}
+	if change.Product != nil {
+		result.Product = toProduct(*change.Product)
+	}
+
+	return result
+}
+
+func fromListRequest(req *ListProductsRequest) (canonical.ProductFilter, error) {
+	filter := canonical.ProductFilter{
+		Category: req.Category,
+	}
+
+	if req.Sort != "" {
+		sortBy, descending, err := canonical.ParseSort(req.Sort)
+		if err != nil {
+			return filter, err
+		}
+		filter.SortBy = sortBy
+		filter.Descending = descending
+	}
+
+	if req.UpdatedSince != nil {
+		filter.UpdatedSince = req.UpdatedSince.AsTime()
+	}
+
+	return filter, nil
+}
+
+func fromUpdateRequest(req *UpdateProductRequest) (*canonical.Product, error) {
+	price, err := strconv.ParseFloat(req.Price, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price %q", req.Price)
+	}
+
+	return &canonical.Product{
+		ID:          req.Id,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       price,
+		Category:    req.Category,
+		ImagePath:   req.ImagePath,
+		Version:     req.Version,
+	}, nil
+}
+
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, canonical.ErrorVersionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, canonical.ErrorNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, canonical.ErrorBaseProduct):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, canonical.ErrorInvalidResumeToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, canonical.ErrorResumeTokenExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"tech-challenge-product/internal/metrics"
+	"time"
+
+	protocol "google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryMetricsInterceptor records the status code and latency of every
+// call with recorder (see metrics.Recorder.ObserveGRPC).
+func UnaryMetricsInterceptor(recorder *metrics.Recorder) protocol.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *protocol.UnaryServerInfo, handler protocol.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		recorder.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+func StreamMetricsInterceptor(recorder *metrics.Recorder) protocol.StreamServerInterceptor {
+	return func(srv interface{}, stream protocol.ServerStream, info *protocol.StreamServerInfo, handler protocol.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, stream)
+
+		recorder.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
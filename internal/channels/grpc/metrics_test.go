@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/metrics"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestMetricsInterceptors(t *testing.T) {
+	mockS.On("GetProductsWithId", []string{"measured"}).Return([]canonical.Product{{ID: "measured"}}, nil)
+
+	registry := metrics.NewRegistry()
+	recorder := metrics.NewRecorder(registry)
+	client, f := server(
+		grpc.ChainUnaryInterceptor(UnaryMetricsInterceptor(recorder)),
+		grpc.ChainStreamInterceptor(StreamMetricsInterceptor(recorder)),
+	)
+	defer f()
+
+	_, err := client.GetProduct(context.Background(), &Ids{Ids: []string{"measured"}})
+	assert.Nil(t, err)
+	_, err = client.UpdateProduct(context.Background(), &UpdateProductRequest{Id: "measured", Price: "invalid"})
+	assert.NotNil(t, err)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(registry).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	assert.Contains(t, body, `product_grpc_requests_total{code="OK",method="/ProductService/GetProduct"} 1`)
+	assert.Contains(t, body, `product_grpc_requests_total{code="InvalidArgument",method="/ProductService/UpdateProduct"} 1`)
+}
@@ -36,8 +36,13 @@ func (m *ProductServiceMock) GetByCategory(ctx context.Context, category string)
 	return args.Get(0).([]canonical.Product), args.Error(1)
 }
 
-func (m *ProductServiceMock) Remove(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *ProductServiceMock) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]canonical.Product), args.Error(1)
+}
+
+func (m *ProductServiceMock) Remove(ctx context.Context, id string, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
@@ -46,3 +51,36 @@ func (m *ProductServiceMock) GetProductsWithId(ctx context.Context, ids []string
 
 	return args.Get(0).([]canonical.Product), args.Error(1)
 }
+
+// Watch hands the changes given to Return to handle, then returns the error.
+func (m *ProductServiceMock) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	args := m.Called(ctx, filter, resumeToken)
+	for _, change := range args.Get(0).([]canonical.ProductChange) {
+		if err := handle(change); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *ProductServiceMock) SetPrice(ctx context.Context, id string, price float64) error {
+	args := m.Called(ctx, id, price)
+	return args.Error(0)
+}
+
+func (m *ProductServiceMock) RemovePrice(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type APIKeysMock struct {
+	mock.Mock
+}
+
+func (m *APIKeysMock) Authenticate(ctx context.Context, key string) (*canonical.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*canonical.APIKey), args.Error(1)
+}
@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/ratelimit"
+
+	protocol "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRateLimitInterceptor answers ResourceExhausted, with a retry-after
+// header in seconds, once the caller has used up the calls allowed on the
+// method (see ratelimit.Limiter). It runs after the auth interceptor, so the
+// caller is the authenticated one, or the peer for anonymous calls.
+func UnaryRateLimitInterceptor(limiter *ratelimit.Limiter) protocol.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *protocol.UnaryServerInfo, handler protocol.UnaryHandler) (interface{}, error) {
+		if retryAfter, limited := limit(ctx, limiter, info.FullMethod); limited {
+			protocol.SetHeader(ctx, retryAfter)
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamRateLimitInterceptor(limiter *ratelimit.Limiter) protocol.StreamServerInterceptor {
+	return func(srv interface{}, stream protocol.ServerStream, info *protocol.StreamServerInfo, handler protocol.StreamHandler) error {
+		if retryAfter, limited := limit(stream.Context(), limiter, info.FullMethod); limited {
+			stream.SetHeader(retryAfter)
+			return status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// UnaryPeerRateLimitInterceptor answers ResourceExhausted like
+// UnaryRateLimitInterceptor once the peer address has used up its own
+// bucket. It runs before the auth interceptor, so calls with missing or
+// invalid credentials are limited before they are looked up.
+func UnaryPeerRateLimitInterceptor(limiter *ratelimit.Limiter) protocol.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *protocol.UnaryServerInfo, handler protocol.UnaryHandler) (interface{}, error) {
+		if retryAfter, limited := limitPeer(ctx, limiter); limited {
+			protocol.SetHeader(ctx, retryAfter)
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamPeerRateLimitInterceptor(limiter *ratelimit.Limiter) protocol.StreamServerInterceptor {
+	return func(srv interface{}, stream protocol.ServerStream, info *protocol.StreamServerInfo, handler protocol.StreamHandler) error {
+		if retryAfter, limited := limitPeer(stream.Context(), limiter); limited {
+			stream.SetHeader(retryAfter)
+			return status.Error(codes.ResourceExhausted, "too many requests")
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+func limit(ctx context.Context, limiter *ratelimit.Limiter, method string) (metadata.MD, bool) {
+	client := ratelimit.Client(token.Claims(ctx), peerIP(ctx))
+
+	allowed, wait := limiter.Allow(method, client)
+	if allowed {
+		return nil, false
+	}
+	return metadata.Pairs("retry-after", ratelimit.RetryAfter(wait)), true
+}
+
+func limitPeer(ctx context.Context, limiter *ratelimit.Limiter) (metadata.MD, bool) {
+	allowed, wait := limiter.AllowPeer(peerIP(ctx))
+	if allowed {
+		return nil, false
+	}
+	return metadata.Pairs("retry-after", ratelimit.RetryAfter(wait)), true
+}
+
+func peerIP(ctx context.Context) string {
+	caller, ok := peer.FromContext(ctx)
+	if !ok || caller.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(caller.Addr.String())
+	if err != nil {
+		return caller.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/ratelimit"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitInterceptors(t *testing.T) {
+	mockS.On("GetProductsWithId", []string{"limited"}).Return([]canonical.Product{{ID: "limited"}}, nil)
+	mockS.On("Watch", mock.Anything, canonical.ChangeFilter{IDs: []string{"limited"}}, "").Return([]canonical.ProductChange{}, nil)
+
+	limiter := ratelimit.New(config.RateLimit{
+		Default: config.Limit{Rate: 0.01, Burst: 1},
+		Routes: map[string]config.Limit{
+			"/ProductService/ListProducts": {},
+		},
+	})
+	// stands for the auth interceptor, taking the subject of the caller from
+	// the authorization metadata
+	authenticated := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if subject := incoming(ctx, "authorization"); subject != "" {
+			ctx = token.WithClaims(ctx, jwt.MapClaims{"sub": subject})
+		}
+		return handler(ctx, req)
+	}
+	client, f := server(
+		grpc.ChainUnaryInterceptor(authenticated, UnaryRateLimitInterceptor(limiter)),
+		grpc.ChainStreamInterceptor(StreamRateLimitInterceptor(limiter)),
+	)
+	defer f()
+
+	_, err := client.GetProduct(context.Background(), &Ids{Ids: []string{"limited"}})
+	assert.Nil(t, err)
+
+	var header metadata.MD
+	_, err = client.GetProduct(context.Background(), &Ids{Ids: []string{"limited"}}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"100"}, header.Get("retry-after"))
+
+	authorized := metadata.AppendToOutgoingContext(context.Background(), "authorization", "kiosk-1")
+	_, err = client.GetProduct(authorized, &Ids{Ids: []string{"limited"}})
+	assert.Nil(t, err)
+
+	stream, err := client.WatchProducts(context.Background(), &WatchProductsRequest{Ids: []string{"limited"}})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.NotEqual(t, codes.ResourceExhausted, status.Code(err))
+
+	stream, err = client.WatchProducts(context.Background(), &WatchProductsRequest{Ids: []string{"limited"}})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	streamHeader, _ := stream.Header()
+	assert.Equal(t, []string{"100"}, streamHeader.Get("retry-after"))
+}
+
+func TestPeerRateLimitInterceptors(t *testing.T) {
+	limiter := ratelimit.New(config.RateLimit{Peer: config.Limit{Rate: 0.01, Burst: 2}})
+	// stands for the auth interceptor refusing made up credentials
+	unauthenticated := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	client, f := server(
+		grpc.ChainUnaryInterceptor(UnaryPeerRateLimitInterceptor(limiter), unauthenticated),
+		grpc.ChainStreamInterceptor(StreamPeerRateLimitInterceptor(limiter)),
+	)
+	defer f()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetProduct(context.Background(), &Ids{Ids: []string{"limited"}})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	var header metadata.MD
+	_, err := client.GetProduct(context.Background(), &Ids{Ids: []string{"limited"}}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"100"}, header.Get("retry-after"))
+
+	stream, err := client.WatchProducts(context.Background(), &WatchProductsRequest{Ids: []string{"limited"}})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServerStop(t *testing.T) {
+	mockS.On("Watch", mock.Anything, canonical.ChangeFilter{IDs: []string{"stopping"}}, "").
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return([]canonical.ProductChange{}, nil)
+
+	newServer := func() (*Server, *grpc.ClientConn, func() bool) {
+		lis := bufconn.Listen(buff)
+		stopped := false
+		s := &Server{
+			server:   grpc.NewServer(),
+			products: newProductServer(&mockS),
+			health:   grpchealth.NewServer(),
+			stop:     func() { stopped = true },
+		}
+		RegisterProductServiceServer(s.server, s.products)
+		healthpb.RegisterHealthServer(s.server, s.health)
+		go s.server.Serve(lis)
+
+		conn, _ := grpc.DialContext(context.Background(), "",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return lis.Dial()
+			}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+		return s, conn, func() bool { return stopped }
+	}
+
+	t.Run("given no calls in progress must stop at once", func(t *testing.T) {
+		s, conn, watcherStopped := newServer()
+		client := healthpb.NewHealthClient(conn)
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.Nil(t, err)
+
+		err = s.Stop(context.Background())
+
+		assert.Nil(t, err)
+		assert.True(t, watcherStopped())
+		resp, _ := s.health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	})
+
+	t.Run("given stream still open must cancel it once the deadline passes", func(t *testing.T) {
+		s, conn, _ := newServer()
+		client := healthpb.NewHealthClient(conn)
+		stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		assert.Nil(t, err)
+		_, err = stream.Recv()
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err = s.Stop(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		// the NOT_SERVING update may still be received before the stream ends
+		for {
+			if _, err := stream.Recv(); err != nil {
+				break
+			}
+		}
+	})
+
+	t.Run("given WatchProducts stream must end it as soon as stop starts", func(t *testing.T) {
+		s, conn, _ := newServer()
+		stream, err := NewProductServiceClient(conn).WatchProducts(context.Background(), &WatchProductsRequest{Ids: []string{"stopping"}})
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		err = s.Stop(ctx)
+
+		assert.Nil(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
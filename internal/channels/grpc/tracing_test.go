@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	mockS.On("GetProductsWithId", []string{"traced"}).Return([]canonical.Product{{ID: "traced"}}, nil)
+
+	var handled trace.SpanContext
+	client, f := server(
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithPropagators(propagation.TraceContext{}))),
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			handled = trace.SpanContextFromContext(ctx)
+			return handler(ctx, req)
+		}),
+	)
+	defer f()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, err := client.GetProduct(ctx, &Ids{Ids: []string{"traced"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handled.TraceID().String())
+	assert.True(t, handled.IsRemote())
+}
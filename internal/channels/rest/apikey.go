@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/middlewares"
+	"tech-challenge-product/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type APIKey interface {
+	RegisterGroup(g *echo.Group, authorize middlewares.Authorizer)
+	Create(c echo.Context) error
+	List(c echo.Context) error
+	Revoke(c echo.Context) error
+}
+
+type apiKeyChannel struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyChannel(service service.APIKeyService) APIKey {
+	return &apiKeyChannel{
+		service: service,
+	}
+}
+
+func (a *apiKeyChannel) RegisterGroup(g *echo.Group, authorize middlewares.Authorizer) {
+	manage := authorize(rbac.PERMISSION_API_KEY_MANAGE)
+
+	g.POST("", a.Create, manage)
+	g.GET("", a.List, manage)
+	g.DELETE("/:id", a.Revoke, manage)
+}
+
+func (a *apiKeyChannel) Create(c echo.Context) error {
+	var request APIKeyRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	key, plaintext, err := a.service.Create(c.Request().Context(), request.toCanonical())
+	if errors.Is(err, canonical.ErrorInvalidAPIKey) {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	response := apiKeyToResponse(*key)
+	response.Key = plaintext
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (a *apiKeyChannel) List(c echo.Context) error {
+	keys, err := a.service.GetAll(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	response := []APIKeyResponse{}
+	for _, key := range keys {
+		response = append(response, apiKeyToResponse(key))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (a *apiKeyChannel) Revoke(c echo.Context) error {
+	err := a.service.Revoke(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, canonical.ErrorNotFound) {
+		return c.JSON(http.StatusNotFound, "API key not found")
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyCreate(t *testing.T) {
+	createdAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		request   interface{}
+		result    *canonical.APIKey
+		plaintext string
+		err       error
+	}
+	type Expected struct {
+		statusCode int
+		body       string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given valid api key must return it with the plaintext key": {
+			given: Given{
+				request: APIKeyRequest{Name: "price-sync", Scopes: []string{"product:write"}},
+				result: &canonical.APIKey{
+					ID:        "api_key_valid_id",
+					Name:      "price-sync",
+					Prefix:    "pk_abcdefgh",
+					Hash:      "hash",
+					Scopes:    []string{"product:write"},
+					CreatedAt: createdAt,
+				},
+				plaintext: "pk_abcdefghijkl",
+			},
+			expected: Expected{
+				statusCode: http.StatusCreated,
+				body:       `{"id":"api_key_valid_id","name":"price-sync","key":"pk_abcdefghijkl","prefix":"pk_abcdefgh","scopes":["product:write"],"created_at":"2024-03-10T12:00:00Z"}`,
+			},
+		},
+		"given invalid api key must return bad request": {
+			given: Given{
+				request: APIKeyRequest{Name: "price-sync"},
+				err:     fmt.Errorf("%w: at least one scope is required", canonical.ErrorInvalidAPIKey),
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+				body:       `"invalid api key: at least one scope is required"`,
+			},
+		},
+		"given invalid payload must return bad request": {
+			given: Given{
+				request: "name",
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+				body:       `"Invalid request payload"`,
+			},
+		},
+		"given error storing must return internal server error": {
+			given: Given{
+				request: APIKeyRequest{Name: "price-sync", Scopes: []string{"product:write"}},
+				err:     errors.New("connection refused"),
+			},
+			expected: Expected{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			serviceMock := &APIKeyServiceMock{}
+			serviceMock.On("Create", mock.Anything, mock.Anything).Return(tc.given.result, tc.given.plaintext, tc.given.err)
+
+			rec := httptest.NewRecorder()
+			e := echo.New().NewContext(createJsonRequest(http.MethodPost, "/api-keys", tc.given.request), rec)
+
+			err := (&apiKeyChannel{serviceMock}).Create(e)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+			if tc.expected.body != "" {
+				assert.Equal(t, tc.expected.body, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+}
+
+func TestAPIKeyList(t *testing.T) {
+	serviceMock := &APIKeyServiceMock{}
+	serviceMock.On("GetAll", mock.Anything).Return([]canonical.APIKey{
+		{ID: "api_key_valid_id", Name: "price-sync", Prefix: "pk_abcdefgh", Hash: "hash", Scopes: []string{"product:read"}},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	e := echo.New().NewContext(createRequest(http.MethodGet, "/api-keys"), rec)
+
+	err := (&apiKeyChannel{serviceMock}).List(e)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "hash")
+	assert.NotContains(t, rec.Body.String(), `"key"`)
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	serviceMock := &APIKeyServiceMock{}
+	serviceMock.On("Revoke", mock.Anything, "api_key_valid_id").Return(nil)
+	serviceMock.On("Revoke", mock.Anything, "api_key_invalid_id").Return(canonical.ErrorNotFound)
+
+	for id, expected := range map[string]int{
+		"api_key_valid_id":   http.StatusNoContent,
+		"api_key_invalid_id": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(createRequest(http.MethodDelete, "/api-keys/"+id), rec)
+		e.SetPath("/api-keys/:id")
+		e.SetParamNames("id")
+		e.SetParamValues(id)
+
+		err := (&apiKeyChannel{serviceMock}).Revoke(e)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rec.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"net/http"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/middlewares"
+	"tech-challenge-product/internal/service"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Audit interface {
+	RegisterGroup(g *echo.Group, authorize middlewares.Authorizer)
+	History(c echo.Context) error
+	Search(c echo.Context) error
+}
+
+type auditChannel struct {
+	service service.AuditService
+}
+
+func NewAuditChannel(service service.AuditService) Audit {
+	return &auditChannel{
+		service: service,
+	}
+}
+
+func (a *auditChannel) RegisterGroup(g *echo.Group, authorize middlewares.Authorizer) {
+	read := authorize(rbac.PERMISSION_AUDIT_READ)
+
+	g.GET("/product/:id/history", a.History, read)
+	g.GET("/audit", a.Search, read)
+}
+
+func (a *auditChannel) History(c echo.Context) error {
+	entries, err := a.service.History(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, auditToResponse(entries))
+}
+
+func (a *auditChannel) Search(c echo.Context) error {
+	filter := canonical.AuditFilter{
+		Actor: c.QueryParam("actor"),
+	}
+
+	var err error
+	if filter.From, err = parseOptionalTime(c.QueryParam("from")); err != nil {
+		return c.JSON(http.StatusBadRequest, "from must be an RFC 3339 timestamp")
+	}
+	if filter.To, err = parseOptionalTime(c.QueryParam("to")); err != nil {
+		return c.JSON(http.StatusBadRequest, "to must be an RFC 3339 timestamp")
+	}
+
+	entries, err := a.service.Search(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, auditToResponse(entries))
+}
+
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/service"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHistory(t *testing.T) {
+	timestamp := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		pathParamID  string
+		auditService service.AuditService
+	}
+	type Expected struct {
+		statusCode int
+		body       string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given product with changes must return its history": {
+			given: Given{
+				pathParamID: "valid_ID",
+				auditService: mockAuditServiceForHistory("valid_ID", []canonical.AuditEntry{{
+					ID:        "1",
+					EntityID:  "valid_ID",
+					Action:    canonical.AUDIT_UPDATE,
+					Actor:     "admin",
+					Timestamp: timestamp,
+					Changes:   []canonical.FieldChange{{Field: "price", Before: 10.0, After: 12.5}},
+				}}, nil),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				body:       `[{"id":"1","product_id":"valid_ID","action":"update","actor":"admin","timestamp":"2024-03-10T12:00:00Z","changes":[{"field":"price","before":10,"after":12.5}]}]`,
+			},
+		},
+		"given product without changes must return empty history": {
+			given: Given{
+				pathParamID:  "valid_ID",
+				auditService: mockAuditServiceForHistory("valid_ID", nil, nil),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				body:       `[]`,
+			},
+		},
+		"given error reading history must return internal server error": {
+			given: Given{
+				pathParamID:  "valid_ID",
+				auditService: mockAuditServiceForHistory("valid_ID", nil, errors.New("")),
+			},
+			expected: Expected{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(createRequest(http.MethodGet, "/product/"+tc.given.pathParamID+"/history"), rec)
+		e.SetPath("/product/:id/history")
+		e.SetParamNames("id")
+		e.SetParamValues(tc.given.pathParamID)
+
+		channel := auditChannel{tc.given.auditService}
+
+		err := channel.History(e)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected.statusCode, rec.Result().StatusCode)
+		if tc.expected.body != "" {
+			assert.JSONEq(t, tc.expected.body, rec.Body.String())
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	type Given struct {
+		query        string
+		auditService service.AuditService
+	}
+	type Expected struct {
+		statusCode int
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given actor and date range must search with filter": {
+			given: Given{
+				query: "?actor=admin&from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z",
+				auditService: mockAuditServiceForSearch(canonical.AuditFilter{
+					Actor: "admin",
+					From:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				}),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+			},
+		},
+		"given invalid date must return bad request": {
+			given: Given{
+				query:        "?from=yesterday",
+				auditService: &AuditServiceMock{},
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(createRequest(http.MethodGet, "/audit"+tc.given.query), rec)
+
+		channel := auditChannel{tc.given.auditService}
+
+		err := channel.Search(e)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected.statusCode, rec.Result().StatusCode)
+	}
+}
+
+func mockAuditServiceForHistory(productID string, entries []canonical.AuditEntry, err error) *AuditServiceMock {
+	mockAuditSvc := new(AuditServiceMock)
+
+	mockAuditSvc.
+		On("History", mock.Anything, productID).
+		Return(entries, err)
+
+	return mockAuditSvc
+}
+
+func mockAuditServiceForSearch(filter canonical.AuditFilter) *AuditServiceMock {
+	mockAuditSvc := new(AuditServiceMock)
+
+	mockAuditSvc.
+		On("Search", mock.Anything, filter).
+		Return([]canonical.AuditEntry{{ID: "1", Actor: "admin"}}, nil)
+
+	return mockAuditSvc
+}
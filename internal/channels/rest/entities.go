@@ -1,17 +1,32 @@
 package rest
 
+import (
+	"tech-challenge-product/internal/canonical"
+	"time"
+)
+
 type Response struct {
 	Message string `json:"message"`
 }
 
 type ProductResponse struct {
-	ID          string  `json:"id,omitempty"`
-	Name        string  `json:"name,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Price       float64 `json:"price,omitempty"`
-	Category    string  `json:"category,omitempty"`
-	Status      int     `json:"status"`
-	ImagePath   string  `json:"image_path,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	StoreID     string    `json:"store_id,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Price       float64   `json:"price,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Status      int       `json:"status"`
+	ImagePath   string    `json:"image_path,omitempty"`
+	Version     int64     `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+
+	// override is the store price override in Price, only used to build
+	// the ETag and Last-Modified
+	override *canonical.PriceOverride
 }
 
 type ProductRequest struct {
@@ -21,3 +36,102 @@ type ProductRequest struct {
 	Category    string  `json:"category"`
 	ImagePath   string  `json:"image_path"`
 }
+
+type ProductPatchRequest struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Price       *float64 `json:"price"`
+	Category    *string  `json:"category"`
+	ImagePath   *string  `json:"image_path"`
+}
+
+type AuditEntryResponse struct {
+	ID        string                `json:"id"`
+	ProductID string                `json:"product_id"`
+	Action    string                `json:"action"`
+	Actor     string                `json:"actor"`
+	Timestamp time.Time             `json:"timestamp"`
+	Changes   []FieldChangeResponse `json:"changes"`
+}
+
+type FieldChangeResponse struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	StoreID   string    `json:"store_id,omitempty"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+type PriceRequest struct {
+	Price float64 `json:"price"`
+}
+
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	StoreID   string     `json:"store_id"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Key       string     `json:"key,omitempty"`
+	StoreID   string     `json:"store_id,omitempty"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy string     `json:"created_by,omitempty"`
+}
+
+type DeliveryResponse struct {
+	ID            string                    `json:"id"`
+	EventID       string                    `json:"event_id"`
+	EventType     string                    `json:"event_type"`
+	Status        string                    `json:"status"`
+	Attempts      []DeliveryAttemptResponse `json:"attempts"`
+	NextAttemptAt time.Time                 `json:"next_attempt_at"`
+	CreatedAt     time.Time                 `json:"created_at"`
+}
+
+type DeliveryAttemptResponse struct {
+	Timestamp  time.Time `json:"timestamp"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type ProductChangeResponse struct {
+	Type       string           `json:"type"`
+	ProductID  string           `json:"product_id"`
+	Available  bool             `json:"available"`
+	Product    *ProductResponse `json:"product,omitempty"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+type HealthResponse struct {
+	Status string                         `json:"status"`
+	Checks map[string]HealthCheckResponse `json:"checks,omitempty"`
+}
+
+type HealthCheckResponse struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
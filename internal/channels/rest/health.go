@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"net/http"
+	"tech-challenge-product/internal/health"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Health interface {
+	RegisterGroup(g *echo.Group)
+	Live(c echo.Context) error
+	Ready(c echo.Context) error
+}
+
+type healthChannel struct {
+	checker health.Checker
+}
+
+func NewHealthChannel(checker health.Checker) Health {
+	return &healthChannel{
+		checker: checker,
+	}
+}
+
+func (h *healthChannel) RegisterGroup(g *echo.Group) {
+	g.GET("/healthz", h.Live)
+	g.GET("/readyz", h.Ready)
+}
+
+// Live answers as long as the process can serve requests, whatever the
+// state of its dependencies, so it is not restarted while Mongo is down.
+func (h *healthChannel) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, HealthResponse{Status: string(health.STATUS_UP)})
+}
+
+// Ready answers 503 while a dependency is down, so no traffic is routed
+// to the instance.
+func (h *healthChannel) Ready(c echo.Context) error {
+	report := h.checker.Ready(c.Request().Context())
+
+	status := http.StatusOK
+	if report.Status != health.STATUS_UP {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, healthToResponse(report))
+}
@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tech-challenge-product/internal/health"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthLive(t *testing.T) {
+	channel := healthChannel{checker: health.New(time.Second, health.Dependency{
+		Name:  "mongo",
+		Check: func(context.Context) error { return errors.New("connection refused") },
+	})}
+
+	rec := httptest.NewRecorder()
+	err := channel.Live(echo.New().NewContext(createRequest(http.MethodGet, "/healthz"), rec))
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"status":"up"}`, strings.TrimSpace(rec.Body.String()))
+}
+
+func TestHealthReady(t *testing.T) {
+	type Given struct {
+		err error
+	}
+	type Expected struct {
+		statusCode int
+		body       string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given mongo up must be ready": {
+			given: Given{},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				body:       `{"status":"up","checks":{"mongo":{"status":"up","latency_ms":0}}}`,
+			},
+		},
+		"given mongo down must be unavailable": {
+			given: Given{err: errors.New("connection refused")},
+			expected: Expected{
+				statusCode: http.StatusServiceUnavailable,
+				body:       `{"status":"down","checks":{"mongo":{"status":"down","latency_ms":0,"error":"connection refused"}}}`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			channel := healthChannel{checker: health.New(time.Second, health.Dependency{
+				Name:  "mongo",
+				Check: func(context.Context) error { return tc.given.err },
+			})}
+
+			rec := httptest.NewRecorder()
+			err := channel.Ready(echo.New().NewContext(createRequest(http.MethodGet, "/readyz"), rec))
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+			assert.Equal(t, tc.expected.body, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
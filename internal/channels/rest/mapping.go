@@ -1,6 +1,9 @@
 package rest
 
-import "tech-challenge-product/internal/canonical"
+import (
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/health"
+)
 
 func (p *ProductRequest) toCanonical() *canonical.Product {
 	return &canonical.Product{
@@ -13,14 +16,180 @@ func (p *ProductRequest) toCanonical() *canonical.Product {
 	}
 }
 
+func (p *ProductPatchRequest) applyTo(product *canonical.Product) {
+	if p.Name != nil {
+		product.Name = *p.Name
+	}
+	if p.Description != nil {
+		product.Description = *p.Description
+	}
+	if p.Price != nil {
+		product.Price = *p.Price
+	}
+	if p.Category != nil {
+		product.Category = *p.Category
+	}
+	if p.ImagePath != nil {
+		product.ImagePath = *p.ImagePath
+	}
+}
+
 func productToResponse(p *canonical.Product) ProductResponse {
 	return ProductResponse{
 		ID:          p.ID,
+		StoreID:     p.StoreID,
 		Name:        p.Name,
 		Description: p.Description,
 		Price:       p.Price,
 		Category:    p.Category,
 		Status:      int(p.Status),
 		ImagePath:   p.ImagePath,
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		CreatedBy:   p.CreatedBy,
+		UpdatedBy:   p.UpdatedBy,
+		override:    p.Override,
+	}
+}
+
+func changeToResponse(change canonical.ProductChange) ProductChangeResponse {
+	response := ProductChangeResponse{
+		Type:       string(change.Type),
+		ProductID:  change.ProductID,
+		OccurredAt: change.OccurredAt,
+	}
+	if change.Product != nil {
+		product := productToResponse(change.Product)
+		response.Product = &product
+		response.Available = change.Product.Status == canonical.STATUS_ACTIVE
+	}
+
+	return response
+}
+
+func auditToResponse(entries []canonical.AuditEntry) []AuditEntryResponse {
+	response := []AuditEntryResponse{}
+
+	for _, entry := range entries {
+		changes := []FieldChangeResponse{}
+		for _, change := range entry.Changes {
+			changes = append(changes, FieldChangeResponse{
+				Field:  change.Field,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+
+		response = append(response, AuditEntryResponse{
+			ID:        entry.ID,
+			ProductID: entry.EntityID,
+			Action:    string(entry.Action),
+			Actor:     entry.Actor,
+			Timestamp: entry.Timestamp,
+			Changes:   changes,
+		})
+	}
+
+	return response
+}
+
+func (w *WebhookRequest) toCanonical() canonical.Webhook {
+	events := []canonical.EventType{}
+	for _, event := range w.Events {
+		events = append(events, canonical.EventType(event))
+	}
+
+	return canonical.Webhook{
+		URL:    w.URL,
+		Events: events,
+		Secret: w.Secret,
+	}
+}
+
+// webhookToResponse never includes the secret; it is only shown on creation.
+func webhookToResponse(w canonical.Webhook) WebhookResponse {
+	events := []string{}
+	for _, event := range w.Events {
+		events = append(events, string(event))
+	}
+
+	return WebhookResponse{
+		ID:        w.ID,
+		StoreID:   w.StoreID,
+		URL:       w.URL,
+		Events:    events,
+		CreatedAt: w.CreatedAt,
+		CreatedBy: w.CreatedBy,
+	}
+}
+
+func (k *APIKeyRequest) toCanonical() canonical.APIKey {
+	return canonical.APIKey{
+		Name:      k.Name,
+		StoreID:   k.StoreID,
+		Scopes:    k.Scopes,
+		ExpiresAt: k.ExpiresAt,
+	}
+}
+
+// apiKeyToResponse never includes the key; it is only shown on creation.
+func apiKeyToResponse(k canonical.APIKey) APIKeyResponse {
+	scopes := []string{}
+	scopes = append(scopes, k.Scopes...)
+
+	return APIKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		StoreID:   k.StoreID,
+		Prefix:    k.Prefix,
+		Scopes:    scopes,
+		ExpiresAt: k.ExpiresAt,
+		RevokedAt: k.RevokedAt,
+		CreatedAt: k.CreatedAt,
+		CreatedBy: k.CreatedBy,
+	}
+}
+
+func deliveriesToResponse(deliveries []canonical.WebhookDelivery) []DeliveryResponse {
+	response := []DeliveryResponse{}
+
+	for _, delivery := range deliveries {
+		attempts := []DeliveryAttemptResponse{}
+		for _, attempt := range delivery.Attempts {
+			attempts = append(attempts, DeliveryAttemptResponse{
+				Timestamp:  attempt.Timestamp,
+				StatusCode: attempt.StatusCode,
+				Error:      attempt.Error,
+				DurationMs: attempt.Duration.Milliseconds(),
+			})
+		}
+
+		response = append(response, DeliveryResponse{
+			ID:            delivery.ID,
+			EventID:       delivery.Event.ID,
+			EventType:     string(delivery.Event.Type),
+			Status:        string(delivery.Status),
+			Attempts:      attempts,
+			NextAttemptAt: delivery.NextAttemptAt,
+			CreatedAt:     delivery.CreatedAt,
+		})
+	}
+
+	return response
+}
+
+func healthToResponse(report health.Report) HealthResponse {
+	response := HealthResponse{
+		Status: string(report.Status),
+		Checks: map[string]HealthCheckResponse{},
+	}
+	for name, result := range report.Checks {
+		response.Checks[name] = HealthCheckResponse{
+			Status:    string(result.Status),
+			LatencyMs: result.Latency.Milliseconds(),
+			Error:     result.Error,
+		}
 	}
+	return response
 }
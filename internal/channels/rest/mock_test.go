@@ -36,8 +36,13 @@ func (m *ProductServiceMock) GetByCategory(ctx context.Context, category string)
 	return args.Get(0).([]canonical.Product), args.Error(1)
 }
 
-func (m *ProductServiceMock) Remove(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *ProductServiceMock) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]canonical.Product), args.Error(1)
+}
+
+func (m *ProductServiceMock) Remove(ctx context.Context, id string, version int64) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
@@ -46,3 +51,103 @@ func (m *ProductServiceMock) GetProductsWithId(ctx context.Context, ids []string
 
 	return args.Get(0).([]canonical.Product), args.Error(1)
 }
+
+// Watch hands the changes given to Return to handle, then returns the error.
+func (m *ProductServiceMock) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	args := m.Called(ctx, filter, resumeToken)
+	for _, change := range args.Get(0).([]canonical.ProductChange) {
+		if err := handle(change); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *ProductServiceMock) SetPrice(ctx context.Context, id string, price float64) error {
+	args := m.Called(ctx, id, price)
+	return args.Error(0)
+}
+
+func (m *ProductServiceMock) RemovePrice(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type AuditServiceMock struct {
+	mock.Mock
+}
+
+func (m *AuditServiceMock) History(ctx context.Context, productID string) ([]canonical.AuditEntry, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).([]canonical.AuditEntry), args.Error(1)
+}
+
+func (m *AuditServiceMock) Search(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]canonical.AuditEntry), args.Error(1)
+}
+
+type WebhookServiceMock struct {
+	mock.Mock
+}
+
+func (m *WebhookServiceMock) Create(ctx context.Context, webhook canonical.Webhook) (*canonical.Webhook, error) {
+	args := m.Called(ctx, webhook)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *WebhookServiceMock) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]canonical.Webhook), args.Error(1)
+}
+
+func (m *WebhookServiceMock) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *WebhookServiceMock) Remove(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *WebhookServiceMock) Deliveries(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID)
+	return args.Get(0).([]canonical.WebhookDelivery), args.Error(1)
+}
+
+type APIKeyServiceMock struct {
+	mock.Mock
+}
+
+func (m *APIKeyServiceMock) Create(ctx context.Context, key canonical.APIKey) (*canonical.APIKey, string, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.APIKey), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *APIKeyServiceMock) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]canonical.APIKey), args.Error(1)
+}
+
+func (m *APIKeyServiceMock) Revoke(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *APIKeyServiceMock) Authenticate(ctx context.Context, key string) (*canonical.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
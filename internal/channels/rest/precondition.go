@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errMissingIfMatch = errors.New("If-Match header is required")
+	errInvalidIfMatch = errors.New("If-Match header must be a product ETag")
+)
+
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// productETag identifies product as the caller sees it: the price override
+// of the caller's store changes the representation, so its store and
+// update time are part of the tag next to the version.
+func productETag(product ProductResponse) string {
+	if product.override == nil {
+		return etag(product.Version)
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", product.override.StoreID, product.override.UpdatedAt.UnixNano())))
+	return strconv.Quote(fmt.Sprintf("%d-%s", product.Version, hex.EncodeToString(hash[:8])))
+}
+
+// lastModified is the last time product or the price override applied to
+// it changed.
+func lastModified(product ProductResponse) time.Time {
+	if product.override != nil && product.override.UpdatedAt.After(product.UpdatedAt) {
+		return product.override.UpdatedAt
+	}
+	return product.UpdatedAt
+}
+
+// ifMatchVersion reads the product version the client expects to be
+// modifying from the If-Match header.
+func ifMatchVersion(c echo.Context) (int64, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" {
+		return 0, errMissingIfMatch
+	}
+
+	value, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	// tags of products with a price override carry it after the version
+	value, _, _ = strings.Cut(value, "-")
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+
+	return version, nil
+}
+
+func preconditionStatus(err error) int {
+	if errors.Is(err, errMissingIfMatch) {
+		return http.StatusPreconditionRequired
+	}
+	return http.StatusPreconditionFailed
+}
+
+// listETag identifies a product list by the ids and tags of the products
+// it contains, so it changes whenever any listed product is added, removed,
+// updated or gets a new price override.
+func listETag(products []ProductResponse) string {
+	hash := sha256.New()
+	for _, product := range products {
+		fmt.Fprintf(hash, "%s:%s;", product.ID, productETag(product))
+	}
+
+	return strconv.Quote(hex.EncodeToString(hash.Sum(nil)[:16]))
+}
+
+// notModified evaluates If-None-Match and, when it is absent,
+// If-Modified-Since against the current representation.
+func notModified(r *http.Request, tag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" && !modified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return false
+		}
+		return !modified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
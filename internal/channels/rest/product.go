@@ -3,7 +3,15 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/middlewares"
 	"tech-challenge-product/internal/service"
+	"time"
 
 	"net/http"
 
@@ -11,55 +19,136 @@ import (
 )
 
 type Product interface {
-	RegisterGroup(g *echo.Group)
+	RegisterGroup(g *echo.Group, authorize middlewares.Authorizer)
 	Get(c echo.Context) error
 	Add(c echo.Context) error
 	Update(c echo.Context) error
+	Patch(c echo.Context) error
 	Remove(c echo.Context) error
-	HealthCheck(c echo.Context) error
+	SetPrice(c echo.Context) error
+	RemovePrice(c echo.Context) error
+	Events(c echo.Context) error
+	// CloseStreams ends the open event streams, so a shutdown does not wait
+	// for them. Clients reconnect with their last event id.
+	CloseStreams()
 }
 
 type productChannel struct {
-	service service.ProductService
+	service     service.ProductService
+	sse         config.SSE
+	storeHeader string
+
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 
-func NewProductChannel() Product {
+// NewProductChannel serves products, streaming their changes as configured
+// in sse. Callers pick a store with the header of tenancy.
+func NewProductChannel(service service.ProductService, sse config.SSE, tenancy config.Tenancy) Product {
 	return &productChannel{
-		service: service.NewProductService(),
+		service:     service,
+		sse:         sse,
+		storeHeader: tenancy.Header,
+		closing:     make(chan struct{}),
 	}
 }
 
-func (p *productChannel) RegisterGroup(g *echo.Group) {
-	indexPath := "/"
-	g.GET("", p.Get)
-	g.GET(indexPath, p.Get)
-	g.POST(indexPath, p.Add)
-	g.PUT(indexPath+":id", p.Update)
-	g.DELETE(indexPath+":id", p.Remove)
+func (p *productChannel) CloseStreams() {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
 }
 
-func (r *productChannel) HealthCheck(c echo.Context) error {
-	return c.NoContent(http.StatusOK)
+func (p *productChannel) RegisterGroup(g *echo.Group, authorize middlewares.Authorizer) {
+	indexPath := "/"
+	read := authorize(rbac.PERMISSION_PRODUCT_READ)
+	write := authorize(rbac.PERMISSION_PRODUCT_WRITE)
+	remove := authorize(rbac.PERMISSION_PRODUCT_DELETE)
+
+	g.GET("", p.Get, read)
+	g.GET(indexPath, p.Get, read)
+	g.GET(indexPath+"events", p.Events, read)
+	g.POST(indexPath, p.Add, write)
+	g.PUT(indexPath+":id", p.Update, write)
+	g.PATCH(indexPath+":id", p.Patch, write)
+	g.DELETE(indexPath+":id", p.Remove, remove)
+	g.PUT(indexPath+":id/price", p.SetPrice, write)
+	g.DELETE(indexPath+":id/price", p.RemovePrice, write)
 }
 
 func (p *productChannel) Get(ctx echo.Context) error {
 	productID := ctx.QueryParam("id")
-	category := ctx.QueryParam("category")
 
-	response, err := p.get(ctx.Request().Context(), productID, category)
+	filter, err := productFilter(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+	// sorted or incremental listings always answer with an array
+	listing := filter.SortBy != "" || !filter.UpdatedSince.IsZero()
+
+	response, err := p.get(ctx.Request().Context(), productID, filter)
+	if errors.Is(err, canonical.ErrorNotFound) {
+		return ctx.JSON(http.StatusNotFound, nil)
+	}
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, err)
 	}
 	if len(response) == 0 {
+		if listing && productID == "" {
+			return ctx.JSON(http.StatusOK, []ProductResponse{})
+		}
 		return ctx.JSON(http.StatusNotFound, nil)
 	}
-	if len(response) == 1 {
+
+	// a list only validates by ETag: its newest update does not move
+	// when a product leaves it
+	tag := listETag(response)
+	var modified time.Time
+	if productID != "" {
+		tag = productETag(response[0])
+		modified = lastModified(response[0])
+	}
+
+	ctx.Response().Header().Set("ETag", tag)
+	if !modified.IsZero() {
+		ctx.Response().Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(ctx.Request(), tag, modified) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+
+	if len(response) == 1 && !listing {
 		return ctx.JSON(http.StatusOK, response[0])
 	}
 	return ctx.JSON(http.StatusOK, response)
 }
 
-func (p *productChannel) get(ctx context.Context, productID string, category string) ([]ProductResponse, error) {
+func productFilter(ctx echo.Context) (canonical.ProductFilter, error) {
+	filter := canonical.ProductFilter{
+		Category: ctx.QueryParam("category"),
+	}
+
+	if sort := ctx.QueryParam("sort"); sort != "" {
+		sortBy, descending, err := canonical.ParseSort(sort)
+		if err != nil {
+			return filter, err
+		}
+		filter.SortBy = sortBy
+		filter.Descending = descending
+	}
+
+	if updatedSince := ctx.QueryParam("updated_since"); updatedSince != "" {
+		since, err := time.Parse(time.RFC3339, updatedSince)
+		if err != nil {
+			return filter, fmt.Errorf("updated_since must be an RFC 3339 timestamp")
+		}
+		filter.UpdatedSince = since
+	}
+
+	return filter, nil
+}
+
+func (p *productChannel) get(ctx context.Context, productID string, filter canonical.ProductFilter) ([]ProductResponse, error) {
 
 	var response []ProductResponse
 
@@ -71,8 +160,20 @@ func (p *productChannel) get(ctx context.Context, productID string, category str
 		return []ProductResponse{productToResponse(product)}, nil
 	}
 
-	if category != "" {
-		products, err := p.service.GetByCategory(ctx, category)
+	if filter.SortBy != "" || !filter.UpdatedSince.IsZero() {
+		products, err := p.service.Find(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range products {
+			response = append(response, productToResponse(&product))
+		}
+		return response, nil
+	}
+
+	if filter.Category != "" {
+		products, err := p.service.GetByCategory(ctx, filter.Category)
 		if err != nil {
 			return nil, err
 		}
@@ -112,28 +213,113 @@ func (p *productChannel) Add(c echo.Context) error {
 
 func (p *productChannel) Update(c echo.Context) error {
 	productID := c.Param("id")
-
 	var updatedProduct *ProductRequest
+
 	err := json.NewDecoder(c.Request().Body).Decode(&updatedProduct)
 	if err != nil || updatedProduct == nil {
 		return c.JSON(http.StatusBadRequest, "Invalid request payload")
 	}
 
-	err = p.service.Update(c.Request().Context(), productID, *updatedProduct.toCanonical())
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.JSON(preconditionStatus(err), err.Error())
+	}
+
+	product := updatedProduct.toCanonical()
+	product.Version = version
+
+	return p.update(c, productID, *product)
+}
+
+func (p *productChannel) Patch(c echo.Context) error {
+	productID := c.Param("id")
+	var patch *ProductPatchRequest
+
+	err := json.NewDecoder(c.Request().Body).Decode(&patch)
+	if err != nil || patch == nil {
+		return c.JSON(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.JSON(preconditionStatus(err), err.Error())
+	}
+
+	product, err := p.service.GetByID(c.Request().Context(), productID)
+	if err != nil || product == nil {
+		return c.JSON(http.StatusNotFound, "Product not found")
+	}
+
+	patch.applyTo(product)
+	product.Version = version
+
+	return p.update(c, productID, *product)
+}
+
+func (p *productChannel) update(c echo.Context, productID string, product canonical.Product) error {
+	err := p.service.Update(c.Request().Context(), productID, product)
+	if errors.Is(err, canonical.ErrorVersionMismatch) {
+		return c.JSON(http.StatusPreconditionFailed, "Product was modified by another request")
+	}
+	if errors.Is(err, canonical.ErrorBaseProduct) {
+		return c.JSON(http.StatusForbidden, "Product belongs to the base catalog, override its price instead")
+	}
 	if err != nil {
 		return c.JSON(http.StatusNotFound, "Product not found")
 	}
 
+	c.Response().Header().Set("ETag", etag(product.Version+1))
 	return c.JSON(http.StatusOK, nil)
 }
 
 func (p *productChannel) Remove(c echo.Context) error {
 	productID := c.Param("id")
 
-	err := p.service.Remove(c.Request().Context(), productID)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return c.JSON(preconditionStatus(err), err.Error())
+	}
+
+	err = p.service.Remove(c.Request().Context(), productID, version)
+	if errors.Is(err, canonical.ErrorVersionMismatch) {
+		return c.JSON(http.StatusPreconditionFailed, "Product was modified by another request")
+	}
+	if errors.Is(err, canonical.ErrorBaseProduct) {
+		return c.JSON(http.StatusForbidden, "Product belongs to the base catalog")
+	}
 	if err != nil {
 		return c.JSON(http.StatusNotFound, "Product not found")
 	}
 
 	return c.NoContent(http.StatusOK)
 }
+
+// SetPrice overrides the price of a base catalog product for the store of
+// the request.
+func (p *productChannel) SetPrice(c echo.Context) error {
+	var request PriceRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	return p.priceResult(c, p.service.SetPrice(c.Request().Context(), c.Param("id"), request.Price))
+}
+
+func (p *productChannel) RemovePrice(c echo.Context) error {
+	return p.priceResult(c, p.service.RemovePrice(c.Request().Context(), c.Param("id")))
+}
+
+func (p *productChannel) priceResult(c echo.Context, err error) error {
+	switch {
+	case err == nil:
+		return c.NoContent(http.StatusNoContent)
+	case errors.Is(err, canonical.ErrorStoreRequired):
+		return c.JSON(http.StatusBadRequest, "Price overrides need a store, set "+p.storeHeader)
+	case errors.Is(err, canonical.ErrorInvalidPriceOverride):
+		return c.JSON(http.StatusBadRequest, err.Error())
+	case errors.Is(err, canonical.ErrorNotFound):
+		return c.JSON(http.StatusNotFound, "Product not found")
+	default:
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+}
@@ -6,10 +6,18 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
 	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/middlewares"
 	"tech-challenge-product/internal/service"
+	"tech-challenge-product/internal/tenant"
 	"testing"
+	"time"
 
+	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -43,9 +51,9 @@ func TestRegisterGroup(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		p := productChannel{(tc.given.paymenyService)}
+		p := productChannel{service: (tc.given.paymenyService)}
 
-		p.RegisterGroup(tc.given.group)
+		p.RegisterGroup(tc.given.group, authorizer(config.Config{}))
 
 		rec := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodGet, endpoint+"/123", nil)
@@ -62,6 +70,63 @@ func TestRegisterGroup(t *testing.T) {
 	}
 }
 
+// authorizer builds the middlewares.Authorizer of the server from settings.
+func authorizer(settings config.Config) middlewares.Authorizer {
+	policy := rbac.New(settings.Auth, token.New(settings.Token))
+	return middlewares.Authorize(principal.New(policy, nil, settings.Tenancy), tenant.New(settings.Tenancy, policy))
+}
+
+func TestRegisterGroupAuthorization(t *testing.T) {
+	settings := config.Config{}
+	settings.Token.Key = "test-key"
+	settings.Auth.RolesClaim = "roles"
+	settings.Auth.PublicReads = true
+
+	kiosk, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "kiosk-1", "roles": []string{"kiosk"}}).SignedString([]byte("test-key"))
+
+	serviceMock := &ProductServiceMock{}
+	serviceMock.On("GetAll", mock.Anything).Return([]canonical.Product{{ID: "123"}}, nil)
+
+	router := echo.New()
+	p := productChannel{service: serviceMock}
+	p.RegisterGroup(router.Group("/product"), authorizer(settings))
+
+	type Given struct {
+		method        string
+		authorization string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected int
+	}{
+		"given anonymous read must be allowed": {
+			given:    Given{method: http.MethodGet},
+			expected: http.StatusOK,
+		},
+		"given anonymous write must be unauthorized": {
+			given:    Given{method: http.MethodPost},
+			expected: http.StatusUnauthorized,
+		},
+		"given kiosk write must be forbidden": {
+			given:    Given{method: http.MethodPost, authorization: "Bearer " + kiosk},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := createJsonRequest(tc.given.method, "/product/", ProductRequest{Name: "Soda"})
+			if tc.given.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.given.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+}
+
 func TestCreate(t *testing.T) {
 	endpoint := "/product"
 
@@ -112,7 +177,7 @@ func TestCreate(t *testing.T) {
 	for _, tc := range tests {
 		rec := httptest.NewRecorder()
 
-		channel := productChannel{tc.given.paymenyService}
+		channel := productChannel{service: tc.given.paymenyService}
 
 		err := channel.Add(echo.New().NewContext(tc.given.request, rec))
 		statusCode := rec.Result().StatusCode
@@ -142,7 +207,7 @@ func TestUpdate(t *testing.T) {
 		"given normal json income must process normally": {
 			given: Given{
 				pathParamID:    "valid_ID",
-				request:        createJsonRequest(http.MethodPost, endpoint, ProductRequest{}),
+				request:        withIfMatch(createJsonRequest(http.MethodPost, endpoint, ProductRequest{}), `"0"`),
 				paymenyService: mockProductServiceForUpdate("valid_ID", canonical.Product{}),
 			},
 			expected: Expected{
@@ -150,6 +215,28 @@ func TestUpdate(t *testing.T) {
 				statusCode: http.StatusOK,
 			},
 		},
+		"given no If-Match header must return precondition required": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        createJsonRequest(http.MethodPost, endpoint, ProductRequest{}),
+				paymenyService: mockProductServiceForUpdate("valid_ID", canonical.Product{}),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusPreconditionRequired,
+			},
+		},
+		"given stale If-Match header must return precondition failed": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        withIfMatch(createJsonRequest(http.MethodPost, endpoint, ProductRequest{}), `"3"`),
+				paymenyService: mockProductServiceForUpdate("valid_ID", canonical.Product{}),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusPreconditionFailed,
+			},
+		},
 		"given wrong format must return error": {
 			given: Given{
 				pathParamID:    "valid_ID",
@@ -181,7 +268,7 @@ func TestUpdate(t *testing.T) {
 		e.SetParamNames("id")
 		e.SetParamValues(tc.given.pathParamID)
 
-		channel := productChannel{tc.given.paymenyService}
+		channel := productChannel{service: tc.given.paymenyService}
 
 		err := channel.Update(e)
 		statusCode := rec.Result().StatusCode
@@ -192,6 +279,90 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestPatch(t *testing.T) {
+	endpoint := "/product"
+	name := "patched_name"
+
+	type Given struct {
+		request        *http.Request
+		pathParamID    string
+		paymenyService service.ProductService
+	}
+	type Expected struct {
+		err        assert.ErrorAssertionFunc
+		statusCode int
+		etag       string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given partial json income must update only informed fields": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        withIfMatch(createJsonRequest(http.MethodPatch, endpoint, ProductPatchRequest{Name: &name}), `"2"`),
+				paymenyService: mockProductServiceForPatch("valid_ID"),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusOK,
+				etag:       `"3"`,
+			},
+		},
+		"given stale If-Match header must return precondition failed": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        withIfMatch(createJsonRequest(http.MethodPatch, endpoint, ProductPatchRequest{Name: &name}), `"1"`),
+				paymenyService: mockProductServiceForPatch("valid_ID"),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusPreconditionFailed,
+			},
+		},
+		"given no If-Match header must return precondition required": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        createJsonRequest(http.MethodPatch, endpoint, ProductPatchRequest{Name: &name}),
+				paymenyService: mockProductServiceForPatch("valid_ID"),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusPreconditionRequired,
+			},
+		},
+		"given unknown product must return not found": {
+			given: Given{
+				pathParamID:    "invalid_ID",
+				request:        withIfMatch(createJsonRequest(http.MethodPatch, endpoint, ProductPatchRequest{Name: &name}), `"2"`),
+				paymenyService: mockProductServiceForPatch("valid_ID"),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusNotFound,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(tc.given.request, rec)
+		e.SetPath("/:id")
+		e.SetParamNames("id")
+		e.SetParamValues(tc.given.pathParamID)
+
+		channel := productChannel{service: tc.given.paymenyService}
+
+		err := channel.Patch(e)
+		statusCode := rec.Result().StatusCode
+
+		assert.Equal(t, tc.expected.statusCode, statusCode)
+		assert.Equal(t, tc.expected.etag, rec.Header().Get("ETag"))
+
+		tc.expected.err(t, err)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	endpoint := "/product"
 
@@ -211,7 +382,7 @@ func TestRemove(t *testing.T) {
 		"given normal json income must process normally": {
 			given: Given{
 				pathParamID:    "valid_ID",
-				request:        createRequest(http.MethodPost, endpoint),
+				request:        withIfMatch(createRequest(http.MethodPost, endpoint), `"0"`),
 				paymenyService: mockProductServiceForRemove("valid_ID"),
 			},
 			expected: Expected{
@@ -222,7 +393,7 @@ func TestRemove(t *testing.T) {
 		"given wrong format must return error": {
 			given: Given{
 				pathParamID:    "invalid_ID",
-				request:        createRequest(http.MethodPost, endpoint),
+				request:        withIfMatch(createRequest(http.MethodPost, endpoint), `"0"`),
 				paymenyService: mockProductServiceForRemove("valid_ID"),
 			},
 			expected: Expected{
@@ -230,6 +401,28 @@ func TestRemove(t *testing.T) {
 				statusCode: http.StatusNotFound,
 			},
 		},
+		"given unparsable If-Match header must return precondition failed": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        withIfMatch(createRequest(http.MethodPost, endpoint), "abc"),
+				paymenyService: mockProductServiceForRemove("valid_ID"),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusPreconditionFailed,
+			},
+		},
+		"given stale If-Match header must return precondition failed": {
+			given: Given{
+				pathParamID:    "valid_ID",
+				request:        withIfMatch(createRequest(http.MethodPost, endpoint), `"3"`),
+				paymenyService: mockProductServiceForRemove("valid_ID"),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusPreconditionFailed,
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -239,7 +432,7 @@ func TestRemove(t *testing.T) {
 		e.SetParamNames("id")
 		e.SetParamValues(tc.given.pathParamID)
 
-		channel := productChannel{tc.given.paymenyService}
+		channel := productChannel{service: tc.given.paymenyService}
 
 		err := channel.Remove(e)
 		statusCode := rec.Result().StatusCode
@@ -307,6 +500,18 @@ func TestGet(t *testing.T) {
 				statusCode: http.StatusOK,
 			},
 		},
+		"given unknown id returns no product and status 404": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "4321",
+				paymenyService: mockProductServiceForGetByID_error("4321", canonical.ErrorNotFound),
+			},
+			expected: Expected{
+				err:        assert.NoError,
+				statusCode: http.StatusNotFound,
+			},
+		},
 		"given empty id returns no product and status 400": {
 			given: Given{
 				request:        createRequest(http.MethodGet, endpoint),
@@ -337,7 +542,7 @@ func TestGet(t *testing.T) {
 			e.QueryParams().Add(tc.given.pathParamKey, tc.given.pathParamValue)
 		}
 
-		channel := productChannel{tc.given.paymenyService}
+		channel := productChannel{service: tc.given.paymenyService}
 
 		err := channel.Get(e)
 		statusCode := rec.Result().StatusCode
@@ -348,15 +553,269 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetFiltered(t *testing.T) {
+	since := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		query          string
+		paymenyService service.ProductService
+	}
+	type Expected struct {
+		statusCode int
+		body       string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given sort and updated_since must search with filter and return array": {
+			given: Given{
+				query: "?category=drinks&sort=-updated_at&updated_since=2024-03-10T12:00:00Z",
+				paymenyService: mockProductServiceForFind(canonical.ProductFilter{
+					Category:     "drinks",
+					UpdatedSince: since,
+					SortBy:       "updated_at",
+					Descending:   true,
+				}, []canonical.Product{{ID: "1234", Status: canonical.STATUS_INACTIVE, UpdatedBy: "admin"}}),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				body:       `[{"id":"1234","status":1,"version":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","updated_by":"admin"}]`,
+			},
+		},
+		"given no changes since timestamp must return empty array": {
+			given: Given{
+				query: "?updated_since=2024-03-10T12:00:00Z",
+				paymenyService: mockProductServiceForFind(canonical.ProductFilter{
+					UpdatedSince: since,
+				}, nil),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				body:       `[]`,
+			},
+		},
+		"given unknown sort key must return bad request": {
+			given: Given{
+				query:          "?sort=description",
+				paymenyService: &ProductServiceMock{},
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		"given invalid updated_since must return bad request": {
+			given: Given{
+				query:          "?updated_since=yesterday",
+				paymenyService: &ProductServiceMock{},
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(createRequest(http.MethodGet, "/product/"+tc.given.query), rec)
+
+		channel := productChannel{service: tc.given.paymenyService}
+
+		err := channel.Get(e)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected.statusCode, rec.Result().StatusCode)
+		if tc.expected.body != "" {
+			assert.JSONEq(t, tc.expected.body, rec.Body.String())
+		}
+	}
+}
+
+func TestGetConditional(t *testing.T) {
+	endpoint := "/product/"
+	updatedAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	products := []canonical.Product{
+		{ID: "1234", Version: 4, UpdatedAt: updatedAt},
+		{ID: "5678", Version: 1, UpdatedAt: updatedAt.Add(-time.Hour)},
+	}
+	listTag := listETag([]ProductResponse{productToResponse(&products[0]), productToResponse(&products[1])})
+	overridden := products[0]
+	overridden.Price = 12.5
+	overridden.Override = &canonical.PriceOverride{StoreID: "store_1", ProductID: "1234", Price: 12.5, UpdatedAt: updatedAt.Add(time.Hour)}
+	overriddenTag := productETag(productToResponse(&overridden))
+
+	type Given struct {
+		request        *http.Request
+		pathParamKey   string
+		pathParamValue string
+		headers        map[string]string
+		paymenyService service.ProductService
+	}
+	type Expected struct {
+		statusCode   int
+		etag         string
+		lastModified string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given matching If-None-Match for product must return not modified": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-None-Match": `"4"`},
+				paymenyService: mockProductServiceForGetByID("1234", &products[0]),
+			},
+			expected: Expected{
+				statusCode:   http.StatusNotModified,
+				etag:         `"4"`,
+				lastModified: "Sun, 10 Mar 2024 12:00:00 GMT",
+			},
+		},
+		"given stale If-None-Match for product must return product": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-None-Match": `"3"`, "If-Modified-Since": "Sun, 10 Mar 2024 12:00:00 GMT"},
+				paymenyService: mockProductServiceForGetByID("1234", &products[0]),
+			},
+			expected: Expected{
+				statusCode:   http.StatusOK,
+				etag:         `"4"`,
+				lastModified: "Sun, 10 Mar 2024 12:00:00 GMT",
+			},
+		},
+		"given matching weak If-None-Match for list must return not modified": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				headers:        map[string]string{"If-None-Match": `"other", W/` + listTag},
+				paymenyService: mockProductServiceForGetAll(products),
+			},
+			expected: Expected{
+				statusCode: http.StatusNotModified,
+				etag:       listTag,
+			},
+		},
+		"given If-Modified-Since after product update must return not modified": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-Modified-Since": "Sun, 10 Mar 2024 12:00:00 GMT"},
+				paymenyService: mockProductServiceForGetByID("1234", &products[0]),
+			},
+			expected: Expected{
+				statusCode:   http.StatusNotModified,
+				etag:         `"4"`,
+				lastModified: "Sun, 10 Mar 2024 12:00:00 GMT",
+			},
+		},
+		"given If-Modified-Since before product update must return product": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-Modified-Since": "Sun, 10 Mar 2024 11:59:59 GMT"},
+				paymenyService: mockProductServiceForGetByID("1234", &products[0]),
+			},
+			expected: Expected{
+				statusCode:   http.StatusOK,
+				etag:         `"4"`,
+				lastModified: "Sun, 10 Mar 2024 12:00:00 GMT",
+			},
+		},
+		"given If-None-Match from before a price override must return product": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-None-Match": `"4"`},
+				paymenyService: mockProductServiceForGetByID("1234", &overridden),
+			},
+			expected: Expected{
+				statusCode:   http.StatusOK,
+				etag:         overriddenTag,
+				lastModified: "Sun, 10 Mar 2024 13:00:00 GMT",
+			},
+		},
+		"given If-Modified-Since from before a price override must return product": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-Modified-Since": "Sun, 10 Mar 2024 12:00:00 GMT"},
+				paymenyService: mockProductServiceForGetByID("1234", &overridden),
+			},
+			expected: Expected{
+				statusCode:   http.StatusOK,
+				etag:         overriddenTag,
+				lastModified: "Sun, 10 Mar 2024 13:00:00 GMT",
+			},
+		},
+		"given matching If-None-Match after a price override must return not modified": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				pathParamKey:   "id",
+				pathParamValue: "1234",
+				headers:        map[string]string{"If-None-Match": overriddenTag},
+				paymenyService: mockProductServiceForGetByID("1234", &overridden),
+			},
+			expected: Expected{
+				statusCode:   http.StatusNotModified,
+				etag:         overriddenTag,
+				lastModified: "Sun, 10 Mar 2024 13:00:00 GMT",
+			},
+		},
+		"given If-Modified-Since for list must ignore it and return list": {
+			given: Given{
+				request:        createRequest(http.MethodGet, endpoint),
+				headers:        map[string]string{"If-Modified-Since": "Sun, 10 Mar 2024 12:00:00 GMT"},
+				paymenyService: mockProductServiceForGetAll(products),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				etag:       listTag,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		for key, value := range tc.given.headers {
+			tc.given.request.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(tc.given.request, rec)
+		if tc.given.pathParamKey != "" {
+			e.QueryParams().Add(tc.given.pathParamKey, tc.given.pathParamValue)
+		}
+
+		channel := productChannel{service: tc.given.paymenyService}
+
+		err := channel.Get(e)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected.statusCode, rec.Result().StatusCode)
+		assert.Equal(t, tc.expected.etag, rec.Header().Get("ETag"))
+		assert.Equal(t, tc.expected.lastModified, rec.Header().Get("Last-Modified"))
+	}
+}
+
 func mockProductServiceForRemove(id string) *ProductServiceMock {
 	mockProductSvc := new(ProductServiceMock)
 
 	mockProductSvc.
-		On("Remove", mock.Anything, id).
+		On("Remove", mock.Anything, id, int64(0)).
 		Return(nil)
 
 	mockProductSvc.
-		On("Remove", mock.Anything, "invalid_ID").
+		On("Remove", mock.Anything, id, int64(3)).
+		Return(canonical.ErrorVersionMismatch)
+
+	mockProductSvc.
+		On("Remove", mock.Anything, "invalid_ID", int64(0)).
 		Return(errors.New(""))
 
 	return mockProductSvc
@@ -373,6 +832,44 @@ func mockProductServiceForUpdate(id string, productReturned canonical.Product) *
 		On("Update", mock.Anything, "invalid_ID", productReturned).
 		Return(errors.New(""))
 
+	staleProduct := productReturned
+	staleProduct.Version = 3
+	mockProductSvc.
+		On("Update", mock.Anything, id, staleProduct).
+		Return(canonical.ErrorVersionMismatch)
+
+	return mockProductSvc
+}
+
+func mockProductServiceForPatch(id string) *ProductServiceMock {
+	mockProductSvc := new(ProductServiceMock)
+
+	mockProductSvc.
+		On("GetByID", mock.Anything, id).
+		Return(&canonical.Product{ID: id, Name: "product_valid_name", Price: 10}, nil)
+
+	mockProductSvc.
+		On("GetByID", mock.Anything, "invalid_ID").
+		Return((*canonical.Product)(nil), errors.New(""))
+
+	mockProductSvc.
+		On("Update", mock.Anything, id, canonical.Product{ID: id, Name: "patched_name", Price: 10, Version: 2}).
+		Return(nil)
+
+	mockProductSvc.
+		On("Update", mock.Anything, id, canonical.Product{ID: id, Name: "patched_name", Price: 10, Version: 1}).
+		Return(canonical.ErrorVersionMismatch)
+
+	return mockProductSvc
+}
+
+func mockProductServiceForFind(filter canonical.ProductFilter, productReturned []canonical.Product) *ProductServiceMock {
+	mockProductSvc := new(ProductServiceMock)
+
+	mockProductSvc.
+		On("Find", mock.Anything, filter).
+		Return(productReturned, nil)
+
 	return mockProductSvc
 }
 
@@ -396,6 +893,16 @@ func mockProductServiceForGetByID(productID string, productReturned *canonical.P
 	return mockProductSvc
 }
 
+func mockProductServiceForGetByID_error(productID string, err error) *ProductServiceMock {
+	mockProductSvc := new(ProductServiceMock)
+
+	mockProductSvc.
+		On("GetByID", mock.Anything, productID).
+		Return((*canonical.Product)(nil), err)
+
+	return mockProductSvc
+}
+
 func mockProductServiceForGetAll(productReturned []canonical.Product) *ProductServiceMock {
 	mockProductSvc := new(ProductServiceMock)
 
@@ -443,3 +950,79 @@ func createJsonRequest(method, endpoint string, request interface{}) *http.Reque
 	req.Header.Set("Content-Type", "application/json")
 	return req
 }
+
+func withIfMatch(req *http.Request, value string) *http.Request {
+	req.Header.Set("If-Match", value)
+	return req
+}
+
+func TestSetPrice(t *testing.T) {
+	type Given struct {
+		request interface{}
+		err     error
+	}
+	type Expected struct {
+		statusCode int
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given valid price must return no content": {
+			given:    Given{request: PriceRequest{Price: 12.5}},
+			expected: Expected{statusCode: http.StatusNoContent},
+		},
+		"given invalid payload must return bad request": {
+			given:    Given{request: "price"},
+			expected: Expected{statusCode: http.StatusBadRequest},
+		},
+		"given no store must return bad request": {
+			given:    Given{request: PriceRequest{Price: 12.5}, err: canonical.ErrorStoreRequired},
+			expected: Expected{statusCode: http.StatusBadRequest},
+		},
+		"given product of the store must return bad request": {
+			given:    Given{request: PriceRequest{Price: 12.5}, err: canonical.ErrorInvalidPriceOverride},
+			expected: Expected{statusCode: http.StatusBadRequest},
+		},
+		"given unknown product must return not found": {
+			given:    Given{request: PriceRequest{Price: 12.5}, err: canonical.ErrorNotFound},
+			expected: Expected{statusCode: http.StatusNotFound},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			serviceMock := &ProductServiceMock{}
+			serviceMock.On("SetPrice", mock.Anything, "product_valid_id", 12.5).Return(tc.given.err)
+
+			rec := httptest.NewRecorder()
+			e := echo.New().NewContext(createJsonRequest(http.MethodPut, "/product/product_valid_id/price", tc.given.request), rec)
+			e.SetPath("/product/:id/price")
+			e.SetParamNames("id")
+			e.SetParamValues("product_valid_id")
+
+			err := (&productChannel{service: serviceMock, storeHeader: "X-Store-ID"}).SetPrice(e)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+		})
+	}
+}
+
+func TestUpdateBaseProductFromStore(t *testing.T) {
+	serviceMock := &ProductServiceMock{}
+	serviceMock.On("Update", mock.Anything, "product_valid_id", mock.Anything).Return(canonical.ErrorBaseProduct)
+
+	rec := httptest.NewRecorder()
+	req := createJsonRequest(http.MethodPut, "/product/product_valid_id", ProductRequest{Name: "Soda"})
+	req.Header.Set("If-Match", `"1"`)
+	e := echo.New().NewContext(req, rec)
+	e.SetPath("/product/:id")
+	e.SetParamNames("id")
+	e.SetParamValues("product_valid_id")
+
+	err := (&productChannel{service: serviceMock}).Update(e)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
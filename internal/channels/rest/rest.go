@@ -1,37 +1,139 @@
 package rest
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/certs"
 	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/metrics"
 	"tech-challenge-product/internal/middlewares"
+	"tech-challenge-product/internal/ratelimit"
+	"tech-challenge-product/internal/tenant"
 
 	"github.com/labstack/echo/v4"
-)
-
-var (
-	cfg = &config.Cfg
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
 type rest struct {
-	product Product
+	settings   config.Config
+	principals *principal.Authorizer
+	stores     tenant.Resolver
+	recorder   *metrics.Recorder
+	product    Product
+	audit      Audit
+	webhook    Webhook
+	apiKey     APIKey
+	health     Health
+	router     *echo.Echo
 }
 
-func New(product Product) rest {
+// New serves the channels, authorizing the callers with principals,
+// scoping their requests to a store with stores and recording them with
+// recorder.
+func New(settings config.Config, principals *principal.Authorizer, stores tenant.Resolver, recorder *metrics.Recorder, product Product, audit Audit, webhook Webhook, apiKey APIKey, health Health) rest {
 	return rest{
-		product: product,
+		settings:   settings,
+		principals: principals,
+		stores:     stores,
+		recorder:   recorder,
+		product:    product,
+		audit:      audit,
+		webhook:    webhook,
+		apiKey:     apiKey,
+		health:     health,
+		router:     echo.New(),
 	}
 }
 
+// Start serves the API until Shutdown is called.
 func (r rest) Start() error {
-	router := echo.New()
+	router := r.router
+
+	extractor, err := ipExtractor(r.settings.Server.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	router.IPExtractor = extractor
 
+	router.Use(otelecho.Middleware(r.settings.Tracing.ServiceName))
 	router.Use(middlewares.Logger)
+	router.Use(middlewares.Metrics(r.recorder))
+	router.Use(middlewares.CacheControl(r.settings.Cache.Control))
+
+	// peers are limited before their credentials are checked, then each
+	// authorized caller gets its own buckets
+	limiter := ratelimit.New(r.settings.RateLimit)
+	authorize := middlewares.Authorize(r.principals, r.stores).
+		After(middlewares.PeerRateLimit(limiter)).
+		Then(middlewares.RateLimit(limiter))
 
 	mainGroup := router.Group("/api")
 
-	mainGroup.GET("/healthz", r.product.HealthCheck)
+	r.health.RegisterGroup(mainGroup)
 	productGroup := mainGroup.Group("/product")
-	r.product.RegisterGroup(productGroup)
-	//productGroup.Use(middlewares.Authorization)
+	r.product.RegisterGroup(productGroup, authorize)
+	r.audit.RegisterGroup(mainGroup, authorize)
+	r.webhook.RegisterGroup(mainGroup.Group("/webhooks"), authorize)
+	r.apiKey.RegisterGroup(mainGroup.Group("/api-keys"), authorize)
+
+	tlsConfig, err := certs.NewTLSConfig(r.settings.TLS.REST)
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		router.TLSServer.Addr = ":" + r.settings.Server.Port
+		router.TLSServer.TLSConfig = tlsConfig
+		return serving(router.StartServer(router.TLSServer))
+	}
+
+	return serving(router.Start(":" + r.settings.Server.Port))
+}
+
+// Shutdown ends the event streams, stops accepting connections and waits
+// for the requests in progress until ctx is done. Connections still open
+// then are closed.
+func (r rest) Shutdown(ctx context.Context) error {
+	r.product.CloseStreams()
 
-	return router.Start(":" + cfg.Server.Port)
+	if err := r.router.Shutdown(ctx); err != nil {
+		r.router.Close()
+		return err
+	}
+	return nil
+}
+
+// ipExtractor reads the client address from X-Forwarded-For, up to the
+// first hop that is not one of proxies, and uses the peer address when no
+// proxy is trusted. Headers sent by anyone else are ignored, so callers
+// cannot pick the address they are rate limited and logged under.
+func ipExtractor(proxies []string) (echo.IPExtractor, error) {
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range proxies {
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// serving hides the error returned once the server was shut down.
+func serving(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
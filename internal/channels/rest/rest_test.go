@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestShutdown(t *testing.T) {
+	type Given struct {
+		path string
+	}
+	type Expected struct {
+		err      error
+		response bool
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given request in progress must wait for it": {
+			given:    Given{path: "/slow"},
+			expected: Expected{response: true},
+		},
+		"given event stream must end it as soon as shutdown starts": {
+			given:    Given{path: "/events"},
+			expected: Expected{response: true},
+		},
+		"given stream still open must close it once the deadline passes": {
+			given:    Given{path: "/stream"},
+			expected: Expected{err: context.DeadlineExceeded},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			serviceMock := &ProductServiceMock{}
+			serviceMock.On("Watch", mock.Anything, canonical.ChangeFilter{}, "").
+				Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+				Return([]canonical.ProductChange{}, nil)
+			product := NewProductChannel(serviceMock, config.SSE{Retry: time.Second, Heartbeat: time.Hour}, config.Tenancy{})
+
+			r := rest{router: echo.New(), product: product}
+			started := make(chan struct{})
+			r.router.GET("/events", func(c echo.Context) error {
+				close(started)
+				return product.Events(c)
+			})
+			r.router.GET("/slow", func(c echo.Context) error {
+				close(started)
+				time.Sleep(20 * time.Millisecond)
+				return c.NoContent(http.StatusOK)
+			})
+			r.router.GET("/stream", func(c echo.Context) error {
+				close(started)
+				<-c.Request().Context().Done()
+				return nil
+			})
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.Nil(t, err)
+			r.router.Listener = listener
+			served := make(chan error)
+			go func() { served <- serving(r.router.Start("")) }()
+
+			responded := make(chan bool)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String() + tc.given.path)
+				if err == nil {
+					_, err = io.ReadAll(resp.Body)
+					resp.Body.Close()
+				}
+				responded <- err == nil
+			}()
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			err = r.Shutdown(ctx)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			assert.Equal(t, tc.expected.response, <-responded)
+			assert.Nil(t, <-served)
+		})
+	}
+}
+
+func TestIPExtractor(t *testing.T) {
+	type Given struct {
+		proxies    []string
+		remoteAddr string
+	}
+	type Expected struct {
+		ip  string
+		err bool
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given no trusted proxy must use the peer address": {
+			given:    Given{remoteAddr: "10.0.0.1:41234"},
+			expected: Expected{ip: "10.0.0.1"},
+		},
+		"given request through a trusted proxy must use the forwarded address": {
+			given:    Given{proxies: []string{"10.0.0.0/24"}, remoteAddr: "10.0.0.1:41234"},
+			expected: Expected{ip: "203.0.113.7"},
+		},
+		"given request from outside the trusted proxies must use the peer address": {
+			given:    Given{proxies: []string{"10.0.0.0/24"}, remoteAddr: "192.168.1.5:41234"},
+			expected: Expected{ip: "192.168.1.5"},
+		},
+		"given invalid proxy network must fail": {
+			given:    Given{proxies: []string{"10.0.0.1"}},
+			expected: Expected{err: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			extractor, err := ipExtractor(tc.given.proxies)
+			if tc.expected.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/product", nil)
+			req.RemoteAddr = tc.given.remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+
+			assert.Equal(t, tc.expected.ip, extractor(req))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Events streams product changes as Server-Sent Events. Each event id is
+// the change resume token, so browsers reconnecting with Last-Event-ID pick
+// up where they stopped. Idle streams get a comment every sse.heartbeat.
+// Streams end when the client goes away or CloseStreams is called.
+func (p *productChannel) Events(c echo.Context) error {
+	filter := canonical.ChangeFilter{
+		Category: c.QueryParam("category"),
+	}
+	if ids := c.QueryParam("ids"); ids != "" {
+		filter.IDs = strings.Split(ids, ",")
+	}
+
+	resumeToken := c.Request().Header.Get("Last-Event-ID")
+	if resumeToken == "" {
+		// EventSource polyfills that can not set headers send it in the query
+		resumeToken = c.QueryParam("last_event_id")
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	fmt.Fprintf(response, "retry: %d\n\n", p.sse.Retry.Milliseconds())
+	response.Flush()
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	changes := make(chan canonical.ProductChange)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- p.service.Watch(ctx, filter, resumeToken, func(change canonical.ProductChange) error {
+			select {
+			case changes <- change:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	heartbeat := time.NewTicker(p.sse.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case change := <-changes:
+			if err := writeChange(response, change); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(response, ": heartbeat\n\n")
+			response.Flush()
+		case err := <-watchErr:
+			writeStreamError(response, err)
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-p.closing:
+			return nil
+		}
+	}
+}
+
+func writeChange(response *echo.Response, change canonical.ProductChange) error {
+	data, err := json.Marshal(changeToResponse(change))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(response, "id: %s\nevent: %s\ndata: %s\n\n", change.Token, change.Type, data)
+	response.Flush()
+	return nil
+}
+
+// writeStreamError ends the stream. When the resume token can not be used
+// the client is told to reload the catalog, and the empty id clears its
+// Last-Event-ID so the next connection starts from now.
+func writeStreamError(response *echo.Response, err error) {
+	if err == nil {
+		return
+	}
+
+	event := "error"
+	if errors.Is(err, canonical.ErrorResumeTokenExpired) || errors.Is(err, canonical.ErrorInvalidResumeToken) {
+		event = "reset"
+	}
+
+	data, _ := json.Marshal(Response{Message: err.Error()})
+	fmt.Fprintf(response, "id\nevent: %s\ndata: %s\n\n", event, data)
+	response.Flush()
+}
@@ -0,0 +1,136 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEvents(t *testing.T) {
+	occurredAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		url         string
+		lastEventID string
+		filter      canonical.ChangeFilter
+		resumeToken string
+		changes     []canonical.ProductChange
+		err         error
+	}
+	type Expected struct {
+		body string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given changes must stream them with their resume token as id": {
+			given: Given{
+				url:    "/product/events?category=drinks&ids=1,2",
+				filter: canonical.ChangeFilter{Category: "drinks", IDs: []string{"1", "2"}},
+				changes: []canonical.ProductChange{
+					{
+						Token:      "token_1",
+						Type:       canonical.EVENT_PRODUCT_UPDATED,
+						ProductID:  "1",
+						Product:    &canonical.Product{ID: "1", Name: "Soda", Price: 5, Category: "drinks", Version: 2, UpdatedAt: occurredAt},
+						OccurredAt: occurredAt,
+					},
+					{
+						Token:      "token_2",
+						Type:       canonical.EVENT_PRODUCT_REMOVED,
+						ProductID:  "2",
+						Product:    &canonical.Product{ID: "2", Status: canonical.STATUS_INACTIVE},
+						OccurredAt: occurredAt,
+					},
+				},
+			},
+			expected: Expected{
+				body: "retry: 3000\n\n" +
+					"id: token_1\nevent: ProductUpdated\n" +
+					`data: {"type":"ProductUpdated","product_id":"1","available":true,"product":{"id":"1","name":"Soda","price":5,"category":"drinks","status":0,"version":2,"created_at":"0001-01-01T00:00:00Z","updated_at":"2024-03-10T12:00:00Z"},"occurred_at":"2024-03-10T12:00:00Z"}` + "\n\n" +
+					"id: token_2\nevent: ProductRemoved\n" +
+					`data: {"type":"ProductRemoved","product_id":"2","available":false,"product":{"id":"2","status":1,"version":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"},"occurred_at":"2024-03-10T12:00:00Z"}` + "\n\n",
+			},
+		},
+		"given Last-Event-ID must resume after it": {
+			given: Given{
+				url:         "/product/events",
+				lastEventID: "token_1",
+				resumeToken: "token_1",
+				changes:     []canonical.ProductChange{},
+			},
+			expected: Expected{
+				body: "retry: 3000\n\n",
+			},
+		},
+		"given last_event_id query must resume after it": {
+			given: Given{
+				url:         "/product/events?last_event_id=token_7",
+				resumeToken: "token_7",
+				changes:     []canonical.ProductChange{},
+			},
+			expected: Expected{
+				body: "retry: 3000\n\n",
+			},
+		},
+		"given expired resume token must ask for a reset": {
+			given: Given{
+				url:         "/product/events",
+				lastEventID: "expired",
+				resumeToken: "expired",
+				changes:     []canonical.ProductChange{},
+				err:         canonical.ErrorResumeTokenExpired,
+			},
+			expected: Expected{
+				body: "retry: 3000\n\n" +
+					"id\nevent: reset\ndata: {\"message\":\"resume token is no longer available\"}\n\n",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			serviceMock := &ProductServiceMock{}
+			serviceMock.On("Watch", mock.Anything, tc.given.filter, tc.given.resumeToken).Return(tc.given.changes, tc.given.err)
+
+			req := createRequest(http.MethodGet, tc.given.url)
+			if tc.given.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.given.lastEventID)
+			}
+			rec := httptest.NewRecorder()
+			e := echo.New().NewContext(req, rec)
+
+			err := (&productChannel{service: serviceMock, sse: config.SSE{Retry: 3 * time.Second, Heartbeat: time.Hour}}).Events(e)
+
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expected.body, rec.Body.String())
+		})
+	}
+}
+
+func TestEventsHeartbeat(t *testing.T) {
+
+	serviceMock := &ProductServiceMock{}
+	serviceMock.On("Watch", mock.Anything, canonical.ChangeFilter{}, "").
+		Run(func(mock.Arguments) { time.Sleep(50 * time.Millisecond) }).
+		Return([]canonical.ProductChange{}, nil)
+
+	rec := httptest.NewRecorder()
+	e := echo.New().NewContext(createRequest(http.MethodGet, "/product/events"), rec)
+
+	err := (&productChannel{service: serviceMock, sse: config.SSE{Retry: 3 * time.Second, Heartbeat: 5 * time.Millisecond}}).Events(e)
+
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(rec.Body.String(), ": heartbeat\n\n"))
+}
@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/middlewares"
+	"tech-challenge-product/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Webhook interface {
+	RegisterGroup(g *echo.Group, authorize middlewares.Authorizer)
+	Create(c echo.Context) error
+	List(c echo.Context) error
+	Get(c echo.Context) error
+	Remove(c echo.Context) error
+	Deliveries(c echo.Context) error
+}
+
+type webhookChannel struct {
+	service service.WebhookService
+}
+
+func NewWebhookChannel(service service.WebhookService) Webhook {
+	return &webhookChannel{
+		service: service,
+	}
+}
+
+func (w *webhookChannel) RegisterGroup(g *echo.Group, authorize middlewares.Authorizer) {
+	manage := authorize(rbac.PERMISSION_WEBHOOK_MANAGE)
+
+	g.POST("", w.Create, manage)
+	g.GET("", w.List, manage)
+	g.GET("/:id", w.Get, manage)
+	g.DELETE("/:id", w.Remove, manage)
+	g.GET("/:id/deliveries", w.Deliveries, manage)
+}
+
+func (w *webhookChannel) Create(c echo.Context) error {
+	var request WebhookRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, "Invalid request payload")
+	}
+
+	webhook, err := w.service.Create(c.Request().Context(), request.toCanonical())
+	if errors.Is(err, canonical.ErrorInvalidWebhook) {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	response := webhookToResponse(*webhook)
+	response.Secret = webhook.Secret
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (w *webhookChannel) List(c echo.Context) error {
+	webhooks, err := w.service.GetAll(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	response := []WebhookResponse{}
+	for _, webhook := range webhooks {
+		response = append(response, webhookToResponse(webhook))
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (w *webhookChannel) Get(c echo.Context) error {
+	webhook, err := w.service.GetByID(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, canonical.ErrorNotFound) {
+		return c.JSON(http.StatusNotFound, "Webhook not found")
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, webhookToResponse(*webhook))
+}
+
+func (w *webhookChannel) Remove(c echo.Context) error {
+	err := w.service.Remove(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, canonical.ErrorNotFound) {
+		return c.JSON(http.StatusNotFound, "Webhook not found")
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (w *webhookChannel) Deliveries(c echo.Context) error {
+	deliveries, err := w.service.Deliveries(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, canonical.ErrorNotFound) {
+		return c.JSON(http.StatusNotFound, "Webhook not found")
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, deliveriesToResponse(deliveries))
+}
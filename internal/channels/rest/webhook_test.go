@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookCreate(t *testing.T) {
+	createdAt := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		request interface{}
+		result  *canonical.Webhook
+		err     error
+	}
+	type Expected struct {
+		statusCode int
+		body       string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given valid webhook must return it with its secret": {
+			given: Given{
+				request: WebhookRequest{URL: "https://partner.example.com/hooks", Events: []string{"ProductPriceChanged"}},
+				result: &canonical.Webhook{
+					ID:        "webhook_valid_id",
+					URL:       "https://partner.example.com/hooks",
+					Events:    []canonical.EventType{canonical.EVENT_PRODUCT_PRICE_CHANGED},
+					Secret:    "s3cr3t",
+					CreatedAt: createdAt,
+				},
+			},
+			expected: Expected{
+				statusCode: http.StatusCreated,
+				body:       `{"id":"webhook_valid_id","url":"https://partner.example.com/hooks","events":["ProductPriceChanged"],"secret":"s3cr3t","created_at":"2024-03-10T12:00:00Z"}`,
+			},
+		},
+		"given invalid webhook must return bad request": {
+			given: Given{
+				request: WebhookRequest{URL: "/hooks"},
+				err:     fmt.Errorf("%w: url must be an absolute http or https URL", canonical.ErrorInvalidWebhook),
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+				body:       `"invalid webhook: url must be an absolute http or https URL"`,
+			},
+		},
+		"given invalid payload must return bad request": {
+			given: Given{
+				request: "url",
+			},
+			expected: Expected{
+				statusCode: http.StatusBadRequest,
+				body:       `"Invalid request payload"`,
+			},
+		},
+		"given error storing must return internal server error": {
+			given: Given{
+				request: WebhookRequest{URL: "https://partner.example.com/hooks"},
+				err:     errors.New("connection refused"),
+			},
+			expected: Expected{
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			serviceMock := &WebhookServiceMock{}
+			serviceMock.On("Create", mock.Anything, mock.Anything).Return(tc.given.result, tc.given.err)
+
+			rec := httptest.NewRecorder()
+			e := echo.New().NewContext(createJsonRequest(http.MethodPost, "/webhooks", tc.given.request), rec)
+
+			err := (&webhookChannel{serviceMock}).Create(e)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+			if tc.expected.body != "" {
+				assert.Equal(t, tc.expected.body, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+}
+
+func TestWebhookGet(t *testing.T) {
+	serviceMock := &WebhookServiceMock{}
+	serviceMock.On("GetByID", mock.Anything, "webhook_valid_id").Return(&canonical.Webhook{
+		ID:     "webhook_valid_id",
+		URL:    "https://partner.example.com/hooks",
+		Secret: "s3cr3t",
+	}, nil)
+	serviceMock.On("GetByID", mock.Anything, "webhook_invalid_id").Return(nil, canonical.ErrorNotFound)
+
+	for id, expected := range map[string]int{
+		"webhook_valid_id":   http.StatusOK,
+		"webhook_invalid_id": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(createRequest(http.MethodGet, "/webhooks/"+id), rec)
+		e.SetPath("/webhooks/:id")
+		e.SetParamNames("id")
+		e.SetParamValues(id)
+
+		err := (&webhookChannel{serviceMock}).Get(e)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+	}
+}
+
+func TestWebhookRemove(t *testing.T) {
+	serviceMock := &WebhookServiceMock{}
+	serviceMock.On("Remove", mock.Anything, "webhook_valid_id").Return(nil)
+	serviceMock.On("Remove", mock.Anything, "webhook_invalid_id").Return(canonical.ErrorNotFound)
+
+	for id, expected := range map[string]int{
+		"webhook_valid_id":   http.StatusNoContent,
+		"webhook_invalid_id": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(createRequest(http.MethodDelete, "/webhooks/"+id), rec)
+		e.SetPath("/webhooks/:id")
+		e.SetParamNames("id")
+		e.SetParamValues(id)
+
+		err := (&webhookChannel{serviceMock}).Remove(e)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rec.Code)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	timestamp := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	serviceMock := &WebhookServiceMock{}
+	serviceMock.On("Deliveries", mock.Anything, "webhook_valid_id").Return([]canonical.WebhookDelivery{{
+		ID:        "event_1:webhook_valid_id",
+		WebhookID: "webhook_valid_id",
+		Event:     canonical.Event{ID: "event_1", Type: canonical.EVENT_PRODUCT_CREATED},
+		Status:    canonical.DELIVERY_PENDING,
+		Attempts: []canonical.DeliveryAttempt{{
+			Timestamp:  timestamp,
+			StatusCode: http.StatusServiceUnavailable,
+			Error:      "receiver answered 503",
+			Duration:   120 * time.Millisecond,
+		}},
+		NextAttemptAt: timestamp.Add(5 * time.Second),
+		CreatedAt:     timestamp,
+	}}, nil)
+
+	rec := httptest.NewRecorder()
+	e := echo.New().NewContext(createRequest(http.MethodGet, "/webhooks/webhook_valid_id/deliveries"), rec)
+	e.SetPath("/webhooks/:id/deliveries")
+	e.SetParamNames("id")
+	e.SetParamValues("webhook_valid_id")
+
+	err := (&webhookChannel{serviceMock}).Deliveries(e)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `[{"id":"event_1:webhook_valid_id","event_id":"event_1","event_type":"ProductCreated","status":"pending","attempts":[{"timestamp":"2024-03-10T12:00:00Z","status_code":503,"error":"receiver answered 503","duration_ms":120}],"next_attempt_at":"2024-03-10T12:00:05Z","created_at":"2024-03-10T12:00:00Z"}]`, strings.TrimSpace(rec.Body.String()))
+}
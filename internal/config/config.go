@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 	"log"
+	"time"
 
 	"github.com/notnull-co/cfg"
 )
@@ -12,16 +13,217 @@ var (
 )
 
 type Config struct {
-	Token struct {
-		Key string `cfg:"key"`
-	} `cfg:"token"`
-	Server struct {
+	Token   Token   `cfg:"token"`
+	Auth    Auth    `cfg:"auth"`
+	Tenancy Tenancy `cfg:"tenancy"`
+	Server  struct {
 		Port string `cfg:"port"`
 		GRPC string `cfg:"grpc"`
+		// Admin is the port /metrics is served on, apart from the API.
+		Admin string `cfg:"admin" default:"9090"`
+		// ShutdownTimeout is how long requests in progress are given to
+		// finish on SIGTERM. Keep it below the termination grace period of
+		// the pod (30s by default).
+		ShutdownTimeout time.Duration `cfg:"shutdown_timeout" default:"25s"`
+		// TrustedProxies lists the networks (CIDR) of the proxies in front
+		// of the REST server. The client address is read from
+		// X-Forwarded-For only on requests coming through them.
+		TrustedProxies []string `cfg:"trusted_proxies"`
 	} `cfg:"server"`
-	DB struct {
-		ConnectionString string `cfg:"connection_string"`
-	} `cfg:"db"`
+	TLS struct {
+		REST TLS `cfg:"rest"`
+		GRPC TLS `cfg:"grpc"`
+	} `cfg:"tls"`
+	DB       DB       `cfg:"db"`
+	Events   Events   `cfg:"events"`
+	Webhooks Webhooks `cfg:"webhooks"`
+	SSE      SSE      `cfg:"sse"`
+	Health   struct {
+		// Timeout bounds each dependency check.
+		Timeout time.Duration `cfg:"timeout" default:"2s"`
+		// Interval is how often the gRPC health status is refreshed.
+		Interval time.Duration `cfg:"interval" default:"10s"`
+	} `cfg:"health"`
+	Logging   Logging   `cfg:"logging"`
+	Tracing   Tracing   `cfg:"tracing"`
+	RateLimit RateLimit `cfg:"rate_limit"`
+	Cache     struct {
+		// Control maps a route path (e.g. /api/product) to the
+		// Cache-Control value sent on its GET responses.
+		Control map[string]string `cfg:"control"`
+	} `cfg:"cache"`
+}
+
+// Token configures how bearer tokens are verified.
+type Token struct {
+	// Key is the HMAC secret; HS256 tokens are refused when it is empty.
+	Key string `cfg:"key"`
+	// PublicKeyFile is a PEM public key or certificate for RS256/ES256 tokens.
+	PublicKeyFile string `cfg:"public_key_file"`
+	// JWKSFile is a JWKS document; tokens with a kid header are checked
+	// against it and the file is reloaded when it changes.
+	JWKSFile   string        `cfg:"jwks_file"`
+	Algorithms []string      `cfg:"algorithms"`
+	Issuer     string        `cfg:"issuer"`
+	Audience   []string      `cfg:"audience"`
+	Leeway     time.Duration `cfg:"leeway" default:"30s"`
+}
+
+// Auth configures the permissions granted to callers.
+type Auth struct {
+	// PublicReads lets anonymous callers read the catalog.
+	PublicReads bool   `cfg:"public_reads"`
+	RolesClaim  string `cfg:"roles_claim" default:"roles"`
+	// Roles overrides the permissions granted to a role,
+	// e.g. kiosk: [product:read].
+	Roles map[string][]string `cfg:"roles"`
+	// GRPCAllowlist lists extra gRPC methods callable without a token,
+	// e.g. /grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo.
+	GRPCAllowlist []string `cfg:"grpc_allowlist"`
+	// Certificates maps the identity of gRPC client certificates verified
+	// by mutual TLS, a URI or DNS SAN or the subject common name, to what
+	// they are granted. Other certificates get no role.
+	Certificates map[string]Certificate `cfg:"certificates"`
+}
+
+// Certificate is what a client certificate is granted: its roles and the
+// store it is bound to, if any.
+type Certificate struct {
+	Roles []string `cfg:"roles"`
+	Store string   `cfg:"store"`
+}
+
+// Tenancy configures how requests are scoped to a store.
+type Tenancy struct {
+	// Claim holds the store of the caller in tokens.
+	Claim  string `cfg:"claim" default:"store_id"`
+	Header string `cfg:"header" default:"X-Store-ID"`
+	// AllStoresClaim is a boolean claim letting callers without a store
+	// pick any store with Header, as the admin role does. Other callers
+	// without a store only see the base catalog.
+	AllStoresClaim string `cfg:"all_stores_claim" default:"all_stores"`
+}
+
+// SSE configures the product event streams.
+type SSE struct {
+	// Heartbeat is how often a comment is sent on idle streams so proxies keep them open.
+	Heartbeat time.Duration `cfg:"heartbeat" default:"15s"`
+	// Retry is the reconnection delay suggested to clients.
+	Retry time.Duration `cfg:"retry" default:"3s"`
+}
+
+// Logging configures the global logger.
+type Logging struct {
+	// Level is the lowest level logged: debug, info, warn or error.
+	Level string `cfg:"level" default:"info"`
+	// Format is json, or text for humans.
+	Format string `cfg:"format" default:"json"`
+}
+
+// Tracing configures the span exporter.
+type Tracing struct {
+	// Exporter selects where spans are sent: none, stdout or otlp.
+	Exporter string `cfg:"exporter" default:"none"`
+	// Endpoint is the host:port of the OTLP gRPC collector. When empty,
+	// OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317 is used.
+	Endpoint    string `cfg:"endpoint"`
+	Insecure    bool   `cfg:"insecure"`
+	ServiceName string `cfg:"service_name" default:"product"`
+	// SampleRatio is the share of new traces recorded. Calls that are
+	// part of a trace follow the decision of their parent.
+	SampleRatio float64 `cfg:"sample_ratio" default:"1"`
+}
+
+type DB struct {
+	// Driver selects where products are stored: mongo, postgres or memory.
+	// memory keeps every collection in process and skips MongoDB. postgres
+	// also moves the audit log, outbox and price overrides, while webhooks
+	// and API keys stay in MongoDB.
+	Driver           string `cfg:"driver" default:"mongo"`
+	ConnectionString string `cfg:"connection_string"`
+	Database         string `cfg:"database" default:"product"`
+	// The pool, timeout, read preference and write concern settings are
+	// overridden by the options of the connection string.
+	MinPoolSize            uint64        `cfg:"min_pool_size"`
+	MaxPoolSize            uint64        `cfg:"max_pool_size" default:"100"`
+	ConnectTimeout         time.Duration `cfg:"connect_timeout" default:"10s"`
+	ServerSelectionTimeout time.Duration `cfg:"server_selection_timeout" default:"5s"`
+	SocketTimeout          time.Duration `cfg:"socket_timeout" default:"30s"`
+	// ReadPreference is primary, primaryPreferred, secondary,
+	// secondaryPreferred or nearest.
+	ReadPreference string `cfg:"read_preference" default:"primary"`
+	// WriteConcern is majority or the number of nodes acknowledging writes.
+	WriteConcern string `cfg:"write_concern" default:"majority"`
+	// OperationTimeout bounds every MongoDB and PostgreSQL repository call,
+	// unless the request ends sooner. Change streams are not bounded, only
+	// each read of the PostgreSQL changes.
+	OperationTimeout time.Duration `cfg:"operation_timeout" default:"5s"`
+	// SkipMigrations leaves the Mongo migrations to cmd/migrate instead of
+	// applying them at startup.
+	SkipMigrations bool     `cfg:"skip_migrations"`
+	Postgres       Postgres `cfg:"postgres"`
+}
+
+// Postgres configures the catalog store used by the postgres driver.
+type Postgres struct {
+	ConnectionString string `cfg:"connection_string"`
+	// The pool size overrides pool_min_conns and pool_max_conns of the
+	// connection string. Event streams share one more connection, outside
+	// of the pool.
+	MinConns int `cfg:"min_conns"`
+	MaxConns int `cfg:"max_conns" default:"20"`
+}
+
+// Events configures the outbox dispatcher.
+type Events struct {
+	// Publisher selects where outbox events are delivered: log or memory.
+	Publisher    string        `cfg:"publisher" default:"log"`
+	Interval     time.Duration `cfg:"interval" default:"1s"`
+	BatchSize    int           `cfg:"batch_size" default:"100"`
+	RetryBackoff time.Duration `cfg:"retry_backoff" default:"1s"`
+	MaxBackoff   time.Duration `cfg:"max_backoff" default:"5m"`
+}
+
+// Webhooks configures the delivery worker.
+type Webhooks struct {
+	Interval     time.Duration `cfg:"interval" default:"1s"`
+	BatchSize    int           `cfg:"batch_size" default:"100"`
+	Timeout      time.Duration `cfg:"timeout" default:"10s"`
+	RetryBackoff time.Duration `cfg:"retry_backoff" default:"5s"`
+	MaxBackoff   time.Duration `cfg:"max_backoff" default:"1h"`
+	MaxAttempts  int           `cfg:"max_attempts" default:"10"`
+}
+
+// TLS configures a listener. It serves plaintext while CertFile is empty.
+// With ClientCAFile set, client certificates signed by that CA are
+// verified, and required when RequireClientCert is set (mutual TLS).
+type TLS struct {
+	CertFile          string `cfg:"cert_file"`
+	KeyFile           string `cfg:"key_file"`
+	ClientCAFile      string `cfg:"client_ca_file"`
+	RequireClientCert bool   `cfg:"require_client_cert"`
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval time.Duration `cfg:"reload_interval" default:"30s"`
+}
+
+// RateLimit configures the token buckets kept per client. Default applies
+// to every REST route and gRPC method without an entry in Routes, keyed as
+// "GET /api/product" or "/ProductService/ListProducts".
+type RateLimit struct {
+	Default Limit            `cfg:"default"`
+	Routes  map[string]Limit `cfg:"routes"`
+	// Peer is the bucket of each peer address, taken before the caller is
+	// authenticated so requests with bad credentials are limited too.
+	Peer Limit `cfg:"peer"`
+	// IdleTimeout drops the bucket of a client idle for that long.
+	IdleTimeout time.Duration `cfg:"idle_timeout" default:"10m"`
+}
+
+// Limit lets a client make Rate requests per second, with bursts of up to
+// Burst requests. A zero Rate means no limit.
+type Limit struct {
+	Rate  float64 `cfg:"rate"`
+	Burst int     `cfg:"burst"`
 }
 
 func ParseFromFlags() {
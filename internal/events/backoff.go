@@ -0,0 +1,17 @@
+package events
+
+import "time"
+
+// Backoff returns the delay before the next attempt after the given number
+// of failed attempts: base doubled on every failure, capped at max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 0; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/repository"
+	"time"
+)
+
+// Dispatcher polls the outbox and hands pending events to a Publisher.
+// Events are marked as dispatched only after Publish succeeds, so a crash
+// between both steps leads to a redelivery, never to a lost event.
+type Dispatcher struct {
+	outbox       repository.OutboxRepository
+	publisher    Publisher
+	interval     time.Duration
+	batchSize    int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+}
+
+func NewDispatcher(outbox repository.OutboxRepository, publisher Publisher, settings config.Events) *Dispatcher {
+	return &Dispatcher{
+		outbox:       outbox,
+		publisher:    publisher,
+		interval:     settings.Interval,
+		batchSize:    settings.BatchSize,
+		retryBackoff: settings.RetryBackoff,
+		maxBackoff:   settings.MaxBackoff,
+	}
+}
+
+// Run dispatches pending events every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Dispatch(ctx); err != nil {
+			logging.From(ctx).Error().Err(err).Msg("an error occurred when dispatch outbox events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch delivers one batch of pending events.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	messages, err := d.outbox.Pending(ctx, time.Now(), d.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := d.publisher.Publish(ctx, message.Event); err != nil {
+			next := time.Now().Add(Backoff(d.retryBackoff, d.maxBackoff, message.Attempts))
+			logging.From(ctx).Warn().Err(err).
+				Str("event_id", message.ID).
+				Int("attempts", message.Attempts+1).
+				Time("next_attempt_at", next).
+				Msg("an error occurred when publish event")
+
+			if err := d.outbox.MarkFailed(ctx, message.ID, err.Error(), next); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := d.outbox.MarkDispatched(ctx, message.ID, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newDispatcher(outbox *OutboxRepositoryMock, publisher Publisher) *Dispatcher {
+	return &Dispatcher{
+		outbox:       outbox,
+		publisher:    publisher,
+		interval:     time.Millisecond,
+		batchSize:    10,
+		retryBackoff: time.Second,
+		maxBackoff:   time.Minute,
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	type Given struct {
+		messages   []canonical.OutboxMessage
+		pendingErr error
+		handlerErr error
+	}
+	type Expected struct {
+		err        bool
+		published  int
+		dispatched int
+		failed     int
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given pending events must publish and mark them dispatched": {
+			given: Given{
+				messages: []canonical.OutboxMessage{
+					{Event: canonical.Event{ID: "event_1", Type: canonical.EVENT_PRODUCT_CREATED}},
+					{Event: canonical.Event{ID: "event_2", Type: canonical.EVENT_PRODUCT_UPDATED}},
+				},
+			},
+			expected: Expected{
+				published:  2,
+				dispatched: 2,
+			},
+		},
+		"given publisher failure must schedule a retry": {
+			given: Given{
+				messages: []canonical.OutboxMessage{
+					{Event: canonical.Event{ID: "event_1", Type: canonical.EVENT_PRODUCT_CREATED}, Attempts: 1},
+				},
+				handlerErr: errors.New("broker unavailable"),
+			},
+			expected: Expected{
+				published: 1,
+				failed:    1,
+			},
+		},
+		"given outbox error must return it": {
+			given: Given{
+				pendingErr: errors.New("connection refused"),
+			},
+			expected: Expected{
+				err: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			outbox := &OutboxRepositoryMock{}
+			outbox.On("Pending", mock.Anything, mock.Anything, 10).Return(tc.given.messages, tc.given.pendingErr)
+			outbox.On("MarkDispatched", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			outbox.On("MarkFailed", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			publisher := NewMemoryPublisher()
+			publisher.Subscribe(func(ctx context.Context, event canonical.Event) error {
+				return tc.given.handlerErr
+			})
+
+			err := newDispatcher(outbox, publisher).Dispatch(context.Background())
+
+			assert.Equal(t, tc.expected.err, err != nil)
+			assert.Len(t, publisher.Published(), tc.expected.published)
+			outbox.AssertNumberOfCalls(t, "MarkDispatched", tc.expected.dispatched)
+			outbox.AssertNumberOfCalls(t, "MarkFailed", tc.expected.failed)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(time.Second, time.Minute, 0))
+	assert.Equal(t, 2*time.Second, Backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 8*time.Second, Backoff(time.Second, time.Minute, 3))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 20))
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	outbox := &OutboxRepositoryMock{}
+	outbox.On("Pending", mock.Anything, mock.Anything, 10).Return([]canonical.OutboxMessage{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newDispatcher(outbox, NewMemoryPublisher()).Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop after cancellation")
+	}
+	outbox.AssertCalled(t, "Pending", mock.Anything, mock.Anything, 10)
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+)
+
+type fanout []Publisher
+
+// Fanout publishes every event to all the given publishers. It fails when
+// any of them fails, so the event is retried for all of them.
+func Fanout(publishers ...Publisher) Publisher {
+	return fanout(publishers)
+}
+
+func (f fanout) Publish(ctx context.Context, event canonical.Event) error {
+	var errs []error
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/logging"
+)
+
+type logPublisher struct{}
+
+func NewLogPublisher() Publisher {
+	return &logPublisher{}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event canonical.Event) error {
+	logging.From(ctx).Info().
+		Str("event_id", event.ID).
+		Str("event_type", string(event.Type)).
+		Str("product_id", event.AggregateID).
+		Time("occurred_at", event.OccurredAt).
+		Msg("product event published")
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+)
+
+type Handler func(ctx context.Context, event canonical.Event) error
+
+// MemoryPublisher delivers events synchronously to in-process handlers and
+// keeps every published event, which makes it useful in tests.
+type MemoryPublisher struct {
+	mu        sync.RWMutex
+	handlers  []Handler
+	published []canonical.Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Subscribe(handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, handler)
+}
+
+// Publish hands the event to every handler and fails if any of them does,
+// so the dispatcher retries it.
+func (p *MemoryPublisher) Publish(ctx context.Context, event canonical.Event) error {
+	p.mu.Lock()
+	p.published = append(p.published, event)
+	handlers := append([]Handler(nil), p.handlers...)
+	p.mu.Unlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *MemoryPublisher) Published() []canonical.Event {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]canonical.Event(nil), p.published...)
+}
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OutboxRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OutboxRepositoryMock) Add(ctx context.Context, events ...canonical.Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func (m *OutboxRepositoryMock) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.OutboxMessage, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]canonical.OutboxMessage), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *OutboxRepositoryMock) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *OutboxRepositoryMock) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	args := m.Called(ctx, id, reason, nextAttempt)
+	return args.Error(0)
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+)
+
+// Publisher delivers domain events to their consumers. Publish must be safe
+// to call again for an event that was already delivered, since the
+// dispatcher only guarantees at-least-once delivery.
+type Publisher interface {
+	Publish(ctx context.Context, event canonical.Event) error
+}
+
+// NewPublisher builds the publisher selected by the events.publisher setting.
+func NewPublisher(settings config.Events) (Publisher, error) {
+	switch settings.Publisher {
+	case "", "log":
+		return NewLogPublisher(), nil
+	case "memory":
+		return NewMemoryPublisher(), nil
+	default:
+		return nil, fmt.Errorf("unknown events publisher %q", settings.Publisher)
+	}
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	STATUS_UP   Status = "up"
+	STATUS_DOWN Status = "down"
+)
+
+// Dependency is something the service cannot work without. Check must
+// return once ctx is done.
+type Dependency struct {
+	Name  string
+	Check func(context.Context) error
+}
+
+type Result struct {
+	Status  Status
+	Latency time.Duration
+	Error   string
+}
+
+// Report is the state of every dependency. The service is up only when all
+// of them are.
+type Report struct {
+	Status Status
+	Checks map[string]Result
+}
+
+type Checker interface {
+	Ready(context.Context) Report
+}
+
+type checker struct {
+	dependencies []Dependency
+	timeout      time.Duration
+}
+
+// New checks dependencies concurrently, giving each of them timeout to answer.
+func New(timeout time.Duration, dependencies ...Dependency) Checker {
+	return &checker{
+		dependencies: dependencies,
+		timeout:      timeout,
+	}
+}
+
+func (c *checker) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(c.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range c.dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			results[i] = check(ctx, dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	report := Report{Status: STATUS_UP, Checks: map[string]Result{}}
+	for i, dependency := range c.dependencies {
+		report.Checks[dependency.Name] = results[i]
+		if results[i].Status != STATUS_UP {
+			report.Status = STATUS_DOWN
+		}
+	}
+	return report
+}
+
+func check(ctx context.Context, dependency Dependency) Result {
+	start := time.Now()
+	err := dependency.Check(ctx)
+
+	result := Result{Status: STATUS_UP, Latency: time.Since(start)}
+	if err != nil {
+		result.Status = STATUS_DOWN
+		result.Error = err.Error()
+	}
+	return result
+}
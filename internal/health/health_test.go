@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	type Given struct {
+		dependencies []Dependency
+	}
+	type Expected struct {
+		status Status
+		checks map[string]Status
+		errors map[string]string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given every dependency up must be up": {
+			given: Given{dependencies: []Dependency{{Name: "mongo", Check: up}, {Name: "cache", Check: up}}},
+			expected: Expected{
+				status: STATUS_UP,
+				checks: map[string]Status{"mongo": STATUS_UP, "cache": STATUS_UP},
+			},
+		},
+		"given a dependency down must be down with its error": {
+			given: Given{dependencies: []Dependency{{Name: "mongo", Check: down}, {Name: "cache", Check: up}}},
+			expected: Expected{
+				status: STATUS_DOWN,
+				checks: map[string]Status{"mongo": STATUS_DOWN, "cache": STATUS_UP},
+				errors: map[string]string{"mongo": "connection refused"},
+			},
+		},
+		"given a dependency not answering must be down once it times out": {
+			given: Given{dependencies: []Dependency{{Name: "mongo", Check: hanging}}},
+			expected: Expected{
+				status: STATUS_DOWN,
+				checks: map[string]Status{"mongo": STATUS_DOWN},
+				errors: map[string]string{"mongo": context.DeadlineExceeded.Error()},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			report := New(20*time.Millisecond, tc.given.dependencies...).Ready(context.Background())
+
+			assert.Equal(t, tc.expected.status, report.Status)
+			for dependency, status := range tc.expected.checks {
+				assert.Equal(t, status, report.Checks[dependency].Status)
+				assert.Equal(t, tc.expected.errors[dependency], report.Checks[dependency].Error)
+			}
+		})
+	}
+}
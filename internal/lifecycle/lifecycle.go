@@ -0,0 +1,151 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"tech-challenge-product/internal/logging"
+	"time"
+)
+
+// Component is a long-running part of the service, such as a server.
+type Component struct {
+	Name string
+	// Run blocks until the component is stopped, or fails.
+	Run func() error
+	// Stop makes Run return, finishing the work in progress until ctx is
+	// done.
+	Stop func(ctx context.Context) error
+}
+
+// Closer releases a resource shared by the components, once they stopped.
+type Closer struct {
+	Name  string
+	Close func(ctx context.Context) error
+}
+
+// Manager runs components until the process is asked to stop, then shuts
+// them down within a deadline.
+type Manager struct {
+	servers []Component
+	workers []Component
+	closers []Closer
+	timeout time.Duration
+	signals []os.Signal
+}
+
+func New(timeout time.Duration) *Manager {
+	return &Manager{
+		timeout: timeout,
+		signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+}
+
+// AddServer adds a component taking requests. Servers are stopped first,
+// all at once.
+func (m *Manager) AddServer(component Component) {
+	m.servers = append(m.servers, component)
+}
+
+// Add adds a component working behind the servers, such as a Worker.
+func (m *Manager) Add(component Component) {
+	m.workers = append(m.workers, component)
+}
+
+func (m *Manager) OnShutdown(closer Closer) {
+	m.closers = append(m.closers, closer)
+}
+
+// Worker adapts a loop that runs until its context is cancelled, such as
+// the outbox dispatcher.
+func Worker(name string, run func(ctx context.Context)) Component {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	return Component{
+		Name: name,
+		Run: func() error {
+			defer close(done)
+			run(ctx)
+			return nil
+		},
+		Stop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	}
+}
+
+// Run starts every component and blocks until SIGINT or SIGTERM is
+// received, ctx is done or a component fails, then shuts them down (see
+// shutdown).
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, m.signals...)
+	defer stop()
+
+	components := append(append([]Component{}, m.servers...), m.workers...)
+	failed := make(chan error, len(components))
+	for _, component := range components {
+		go func(component Component) {
+			if err := component.Run(); err != nil {
+				failed <- fmt.Errorf("%s: %w", component.Name, err)
+			}
+		}(component)
+	}
+
+	var cause error
+	select {
+	case <-ctx.Done():
+		logging.From(ctx).Info().Msg("shutting down")
+	case cause = <-failed:
+		logging.From(ctx).Error().Err(cause).Msg("shutting down after a component failed")
+	}
+
+	return errors.Join(cause, m.shutdown())
+}
+
+// shutdown stops the servers together, so the requests in flight get the
+// whole timeout to finish. The workers behind them are stopped next, in
+// the reverse order they were added, and the closers run last, with the
+// time left.
+func (m *Manager) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	stopped := make(chan error, len(m.servers))
+	for _, server := range m.servers {
+		go func(server Component) {
+			if err := server.Stop(ctx); err != nil {
+				stopped <- fmt.Errorf("stop %s: %w", server.Name, err)
+				return
+			}
+			stopped <- nil
+		}(server)
+	}
+
+	var errs []error
+	for range m.servers {
+		errs = append(errs, <-stopped)
+	}
+	for i := len(m.workers) - 1; i >= 0; i-- {
+		worker := m.workers[i]
+		if err := worker.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", worker.Name, err))
+		}
+	}
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		closer := m.closers[i]
+		if err := closer.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", closer.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,224 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blocking is a component that runs until it is stopped, recording the
+// order components are stopped in.
+func blocking(name string, stops *[]string, mu *sync.Mutex, stopErr error) Component {
+	done := make(chan struct{})
+	return Component{
+		Name: name,
+		Run: func() error {
+			<-done
+			return nil
+		},
+		Stop: func(context.Context) error {
+			mu.Lock()
+			*stops = append(*stops, name)
+			mu.Unlock()
+			close(done)
+			return stopErr
+		},
+	}
+}
+
+func TestManager_Run(t *testing.T) {
+	type Given struct {
+		failure error
+		stopErr error
+	}
+	type Expected struct {
+		err   []string
+		stops []string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given shutdown request must stop servers, then workers, then close": {
+			given: Given{},
+			expected: Expected{
+				stops: []string{"rest server", "worker", "closer"},
+			},
+		},
+		"given failing component must stop the others and report it": {
+			given: Given{failure: errors.New("address already in use")},
+			expected: Expected{
+				err:   []string{"grpc server: address already in use"},
+				stops: []string{"rest server", "worker", "closer"},
+			},
+		},
+		"given component failing to stop must still stop the others": {
+			given: Given{stopErr: errors.New("connections still open")},
+			expected: Expected{
+				err:   []string{"stop rest server: connections still open"},
+				stops: []string{"rest server", "worker", "closer"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var mu sync.Mutex
+			var stops []string
+
+			manager := New(time.Second)
+			manager.Add(blocking("worker", &stops, &mu, nil))
+			if tc.given.failure != nil {
+				manager.AddServer(Component{
+					Name: "grpc server",
+					Run:  func() error { return tc.given.failure },
+					Stop: func(context.Context) error { return nil },
+				})
+			}
+			manager.AddServer(blocking("rest server", &stops, &mu, tc.given.stopErr))
+			manager.OnShutdown(Closer{Name: "mongo", Close: func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				stops = append(stops, "closer")
+				return nil
+			}})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.given.failure == nil {
+				cancel()
+			}
+			defer cancel()
+
+			err := manager.Run(ctx)
+
+			for _, message := range tc.expected.err {
+				assert.ErrorContains(t, err, message)
+			}
+			if len(tc.expected.err) == 0 {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tc.expected.stops, stops)
+		})
+	}
+}
+
+func TestManager_ShutdownDrains(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})}
+
+	var mu sync.Mutex
+	var stops []string
+	manager := New(time.Second)
+	manager.Add(blocking("worker", &stops, &mu, nil))
+	manager.AddServer(Component{
+		Name: "rest server",
+		Run: func() error {
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Stop: server.Shutdown,
+	})
+	manager.AddServer(blocking("grpc server", &stops, &mu, nil))
+	var closeErr error
+	manager.OnShutdown(Closer{Name: "mongo", Close: func(ctx context.Context) error {
+		closeErr = ctx.Err()
+		return nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- manager.Run(ctx) }()
+
+	status := make(chan int, 1)
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+		response.Body.Close()
+		status <- response.StatusCode
+	}()
+	<-started
+	cancel()
+
+	assert.Nil(t, <-result)
+	assert.Equal(t, http.StatusNoContent, <-status)
+	assert.Equal(t, []string{"grpc server", "worker"}, stops)
+	assert.Nil(t, closeErr)
+}
+
+func TestManager_ShutdownStuckServer(t *testing.T) {
+	var mu sync.Mutex
+	var stops []string
+
+	manager := New(50 * time.Millisecond)
+	manager.Add(blocking("worker", &stops, &mu, nil))
+	stuck := make(chan struct{})
+	manager.AddServer(Component{
+		Name: "rest server",
+		Run:  func() error { <-stuck; return nil },
+		Stop: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stuck)
+			return ctx.Err()
+		},
+	})
+	closed := false
+	manager.OnShutdown(Closer{Name: "mongo", Close: func(ctx context.Context) error {
+		closed = true
+		return nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := manager.Run(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "stop rest server")
+	assert.Equal(t, []string{"worker"}, stops)
+	assert.True(t, closed)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWorker(t *testing.T) {
+	cancelled := make(chan struct{})
+	worker := Worker("dispatcher", func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	go worker.Run()
+	err := worker.Stop(context.Background())
+
+	assert.Nil(t, err)
+	<-cancelled
+
+	release := make(chan struct{})
+	defer close(release)
+	stuck := Worker("stuck", func(ctx context.Context) {
+		<-release
+	})
+	go stuck.Run()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, stuck.Stop(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"tech-challenge-product/internal/config"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader carries the request ID on REST. gRPC uses the
+	// lowercase form as metadata key.
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+func init() {
+	zerolog.DefaultContextLogger = &log.Logger
+}
+
+// Setup configures the global logger from the logging settings. It is also
+// the logger returned by From for contexts without a request.
+func Setup(settings config.Logging) error {
+	return setup(settings, os.Stderr)
+}
+
+func setup(settings config.Logging, out io.Writer) error {
+	level, err := zerolog.ParseLevel(settings.Level)
+	if err != nil {
+		return fmt.Errorf("unknown logging level %q", settings.Level)
+	}
+
+	switch settings.Format {
+	case "", "json":
+	case "text":
+		out = zerolog.ConsoleWriter{Out: out, NoColor: true, TimeFormat: time.RFC3339}
+	default:
+		return fmt.Errorf("unknown logging format %q", settings.Format)
+	}
+
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+	log.Logger = zerolog.New(out).Level(level).With().Timestamp().Logger()
+	zerolog.DefaultContextLogger = &log.Logger
+
+	return nil
+}
+
+// From returns the logger of the request ctx belongs to, which tags every
+// entry with its request and trace IDs.
+func From(ctx context.Context) *zerolog.Logger {
+	return zerolog.Ctx(ctx)
+}
+
+// WithRequestID scopes ctx to a request, tagging the entries logged with it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	logger := From(ctx).With().Str("request_id", id)
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		logger = logger.Str("trace_id", span.TraceID().String())
+	}
+
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return logger.Logger().WithContext(ctx)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ResolveRequestID keeps the request ID sent by the caller, so a request
+// can be followed across services, or generates one when it is missing or
+// unsafe to log.
+func ResolveRequestID(given string) string {
+	if validRequestID(given) {
+		return given
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(raw)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
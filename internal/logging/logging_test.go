@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"tech-challenge-product/internal/config"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	type Given struct {
+		level  string
+		format string
+	}
+	type Expected struct {
+		err    assert.ErrorAssertionFunc
+		output string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given json format must log json above the level": {
+			given:    Given{level: "warn", format: "json"},
+			expected: Expected{err: assert.NoError, output: `"message":"kept"`},
+		},
+		"given text format must log plain lines": {
+			given:    Given{level: "info", format: "text"},
+			expected: Expected{err: assert.NoError, output: "WRN kept"},
+		},
+		"given unknown level must fail": {
+			given:    Given{level: "verbose", format: "json"},
+			expected: Expected{err: assert.Error},
+		},
+		"given unknown format must fail": {
+			given:    Given{level: "info", format: "xml"},
+			expected: Expected{err: assert.Error},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := setup(config.Logging{Level: tc.given.level, Format: tc.given.format}, &out)
+
+			tc.expected.err(t, err)
+			if err == nil {
+				log.Debug().Msg("dropped")
+				log.Warn().Msg("kept")
+				assert.Contains(t, out.String(), tc.expected.output)
+				assert.NotContains(t, out.String(), "dropped")
+			}
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, setup(config.Logging{Level: "info", Format: "json"}, &out))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+
+	ctx = WithRequestID(ctx, "req-1")
+	From(ctx).Info().Msg("handled")
+
+	var entry map[string]interface{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "req-1", RequestID(ctx))
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+	assert.Equal(t, "", RequestID(context.Background()))
+}
+
+func TestResolveRequestID(t *testing.T) {
+	tests := map[string]struct {
+		given    string
+		expected func(t *testing.T, id string)
+	}{
+		"given request id must keep it": {
+			given: "a1b2-c3",
+			expected: func(t *testing.T, id string) {
+				assert.Equal(t, "a1b2-c3", id)
+			},
+		},
+		"given no request id must generate one": {
+			given: "",
+			expected: func(t *testing.T, id string) {
+				assert.Len(t, id, 32)
+			},
+		},
+		"given request id with line breaks must replace it": {
+			given: "forged\nentry",
+			expected: func(t *testing.T, id string) {
+				assert.Len(t, id, 32)
+			},
+		},
+		"given too long request id must replace it": {
+			given: strings.Repeat("a", maxRequestIDLength+1),
+			expected: func(t *testing.T, id string) {
+				assert.Len(t, id, 32)
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.expected(t, ResolveRequestID(tc.given))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/logging"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	catalogTimeout = 5 * time.Second
+)
+
+var activeProductsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "catalog", "active_products"),
+	"Active products per store and category. The base catalog has an empty store.",
+	[]string{"store", "category"}, nil,
+)
+
+// CatalogStats counts the active products of the catalog.
+type CatalogStats interface {
+	ActiveByCategory(context.Context) ([]canonical.CategoryCount, error)
+}
+
+// catalogCollector counts the products when scraped, so the gauges are
+// never stale.
+type catalogCollector struct {
+	stats CatalogStats
+}
+
+// RegisterCatalog adds the business gauges computed by stats to registerer.
+func RegisterCatalog(registerer prometheus.Registerer, stats CatalogStats) error {
+	return registerer.Register(&catalogCollector{stats: stats})
+}
+
+func (c *catalogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeProductsDesc
+}
+
+func (c *catalogCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), catalogTimeout)
+	defer cancel()
+
+	counts, err := c.stats.ActiveByCategory(ctx)
+	if err != nil {
+		// leave the gauges out rather than failing the whole scrape
+		logging.From(ctx).Error().Err(err).Msg("could not count active products")
+		return
+	}
+
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(activeProductsDesc, prometheus.GaugeValue, float64(count.Count), count.StoreID, count.Category)
+	}
+}
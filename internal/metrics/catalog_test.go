@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogCollector(t *testing.T) {
+	type Given struct {
+		counts []canonical.CategoryCount
+		err    error
+	}
+	type Expected struct {
+		exposition string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given counts must expose one gauge per store and category": {
+			given: Given{
+				counts: []canonical.CategoryCount{
+					{StoreID: "", Category: "drink", Count: 3},
+					{StoreID: "store_1", Category: "dessert", Count: 1},
+				},
+			},
+			expected: Expected{
+				exposition: `
+# HELP product_catalog_active_products Active products per store and category. The base catalog has an empty store.
+# TYPE product_catalog_active_products gauge
+product_catalog_active_products{category="dessert",store="store_1"} 1
+product_catalog_active_products{category="drink",store=""} 3
+`,
+			},
+		},
+		"given stats error must expose nothing": {
+			given: Given{
+				err: errors.New("connection refused"),
+			},
+			expected: Expected{
+				exposition: "",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			stats := &CatalogStatsMock{}
+			stats.On("ActiveByCategory").Return(tc.given.counts, tc.given.err)
+
+			err := testutil.CollectAndCompare(&catalogCollector{stats: stats}, strings.NewReader(tc.expected.exposition))
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestRegisterCatalog(t *testing.T) {
+	first := &CatalogStatsMock{}
+	first.On("ActiveByCategory").Return([]canonical.CategoryCount{{Category: "drink", Count: 3}}, nil)
+	second := &CatalogStatsMock{}
+	second.On("ActiveByCategory").Return([]canonical.CategoryCount{{Category: "drink", Count: 7}}, nil)
+
+	firstRegistry := NewRegistry()
+	assert.Nil(t, RegisterCatalog(firstRegistry, first))
+	secondRegistry := NewRegistry()
+	assert.Nil(t, RegisterCatalog(secondRegistry, second))
+
+	rec := httptest.NewRecorder()
+	Handler(secondRegistry).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `product_catalog_active_products{category="drink",store=""} 7`)
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+	assert.Equal(t, 1, testutil.CollectAndCount(firstRegistry, "product_catalog_active_products"))
+}
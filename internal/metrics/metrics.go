@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "product"
+)
+
+// Recorder records the REST, gRPC and MongoDB command metrics of one App.
+type Recorder struct {
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	grpcRequests  *prometheus.CounterVec
+	grpcDuration  *prometheus.HistogramVec
+	mongoDuration *prometheus.HistogramVec
+}
+
+// NewRegistry holds the metrics of one App, starting with the Go runtime
+// and process ones.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return registry
+}
+
+// NewRecorder registers the request and MongoDB collectors on registerer.
+func NewRecorder(registerer prometheus.Registerer) *Recorder {
+	factory := promauto.With(registerer)
+
+	return &Recorder{
+		httpRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "REST requests handled, by route and status.",
+		}, []string{"method", "route", "status"}),
+
+		httpDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle REST requests, by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		grpcRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "gRPC calls handled, by method and status code.",
+		}, []string{"method", "code"}),
+
+		grpcDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "Time taken to handle gRPC calls, by method and status code. Streams are measured until they end.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+
+		mongoDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mongo_operation_duration_seconds",
+			Help:      "Time taken by MongoDB commands, by command, collection and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"command", "collection", "outcome"}),
+	}
+}
+
+// Handler serves the metrics of registry in the Prometheus text format.
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.InstrumentMetricHandler(registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+}
+
+// ObserveHTTP records a REST request. route is the matched route pattern
+// (e.g. /api/product/:id) so paths with IDs do not create new series.
+func (r *Recorder) ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	r.httpRequests.With(labels).Inc()
+	r.httpDuration.With(labels).Observe(elapsed.Seconds())
+}
+
+func (r *Recorder) ObserveGRPC(method, code string, elapsed time.Duration) {
+	labels := prometheus.Labels{"method": method, "code": code}
+	r.grpcRequests.With(labels).Inc()
+	r.grpcDuration.With(labels).Observe(elapsed.Seconds())
+}
+
+func (r *Recorder) ObserveMongo(command, collection string, failed bool, elapsed time.Duration) {
+	outcome := "success"
+	if failed {
+		outcome = "failure"
+	}
+	r.mongoDuration.With(prometheus.Labels{"command": command, "collection": collection, "outcome": outcome}).Observe(elapsed.Seconds())
+}
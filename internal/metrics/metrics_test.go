@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRecorder(t *testing.T) {
+	firstRegistry := NewRegistry()
+	first := NewRecorder(firstRegistry)
+	secondRegistry := NewRegistry()
+	second := NewRecorder(secondRegistry)
+
+	first.ObserveHTTP("GET", "/api/product", 200, time.Millisecond)
+	first.ObserveGRPC("/ProductService/GetProduct", "OK", time.Millisecond)
+	first.ObserveMongo("find", "product", false, time.Millisecond)
+	second.ObserveHTTP("GET", "/api/product", 200, time.Millisecond)
+	second.ObserveHTTP("GET", "/api/product", 200, time.Millisecond)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(first.httpRequests))
+	assert.Equal(t, 2.0, testutil.ToFloat64(second.httpRequests))
+	assert.Equal(t, 1, testutil.CollectAndCount(firstRegistry, "product_grpc_requests_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(firstRegistry, "product_mongo_operation_duration_seconds"))
+	assert.Equal(t, 0, testutil.CollectAndCount(secondRegistry, "product_mongo_operation_duration_seconds"))
+}
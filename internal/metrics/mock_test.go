@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CatalogStatsMock struct {
+	mock.Mock
+}
+
+func (m *CatalogStatsMock) ActiveByCategory(ctx context.Context) ([]canonical.CategoryCount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]canonical.CategoryCount), args.Error(1)
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Server serves GET /metrics on the admin port, apart from the API, so the
+// metrics are only reachable where that port is exposed.
+type Server struct {
+	server *http.Server
+}
+
+// NewServer serves the metrics of registry on port.
+func NewServer(port string, registry *prometheus.Registry) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(registry))
+
+	return &Server{
+		server: &http.Server{
+			Addr:              net.JoinHostPort("", port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// Serve listens on the admin port until Stop is called.
+func (s *Server) Serve() error {
+	return serving(s.server.ListenAndServe())
+}
+
+// Stop stops accepting scrapes and waits for the ones in progress until ctx
+// is done.
+func (s *Server) Stop(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
+		return err
+	}
+	return nil
+}
+
+// serving hides the error returned once the server was stopped.
+func serving(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
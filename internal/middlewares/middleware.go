@@ -1,36 +1,225 @@
 package middlewares
 
 import (
-	"context"
+	"errors"
 	"net/http"
+	"strings"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/auth/rbac"
 	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/metrics"
+	"tech-challenge-product/internal/ratelimit"
+	"tech-challenge-product/internal/tenant"
+	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 )
 
+// Logger scopes every request to a request ID, taken from the
+// X-Request-ID header or generated, and echoes it back. The access log is
+// written once the handler returns, with the status, latency and size of
+// the response.
 func Logger(fx echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
-		log := logrus.WithContext(context.Background())
+		start := time.Now()
 		request := ctx.Request()
-		log.WithFields(logrus.Fields{
-			"Host":   request.Host,
-			"URI":    request.RequestURI,
-			"Method": request.Method,
-		}).Info()
 
-		return fx(ctx)
+		id := logging.ResolveRequestID(request.Header.Get(logging.RequestIDHeader))
+		ctx.Response().Header().Set(logging.RequestIDHeader, id)
+		ctx.SetRequest(request.WithContext(logging.WithRequestID(request.Context(), id)))
+
+		err := fx(ctx)
+
+		status := responseStatus(ctx, err)
+		entry := logging.From(ctx.Request().Context()).Info()
+		if status >= http.StatusInternalServerError {
+			entry = logging.From(ctx.Request().Context()).Error().Err(err)
+		}
+		entry.
+			Str("method", request.Method).
+			Str("uri", request.RequestURI).
+			Str("route", ctx.Path()).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Int64("bytes", ctx.Response().Size).
+			Str("remote_ip", ctx.RealIP()).
+			Str("user_agent", request.UserAgent()).
+			Msg("request handled")
+
+		return err
 	}
 }
 
-func Authorization(fx echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		if err := token.ValidateToken(ctx.Request()); err != nil {
-			ctx.Response().Header().Set("Content-Type", "application/json")
-			ctx.Response().WriteHeader(http.StatusUnauthorized)
+// Metrics records the status and latency of every request under its route
+// pattern with recorder (see metrics.Recorder.ObserveHTTP).
+func Metrics(recorder *metrics.Recorder) echo.MiddlewareFunc {
+	return func(fx echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			start := time.Now()
+
+			err := fx(ctx)
+
+			route := ctx.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			recorder.ObserveHTTP(ctx.Request().Method, route, responseStatus(ctx, err), time.Since(start))
+
 			return err
 		}
+	}
+}
+
+// responseStatus is the status the request is answered with. Errors are
+// written by the error handler after the middlewares, so their status is
+// read from the error.
+func responseStatus(ctx echo.Context, err error) int {
+	if err == nil {
+		return ctx.Response().Status
+	}
+
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.Code
+	}
+	return http.StatusInternalServerError
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Authorizer returns the middleware requiring permission.
+type Authorizer func(permission rbac.Permission) echo.MiddlewareFunc
+
+// Then runs next once the request was authorized.
+func (a Authorizer) Then(next echo.MiddlewareFunc) Authorizer {
+	return func(permission rbac.Permission) echo.MiddlewareFunc {
+		authorize := a(permission)
+		return func(fx echo.HandlerFunc) echo.HandlerFunc {
+			return authorize(next(fx))
+		}
+	}
+}
+
+// After runs first before the authorization.
+func (a Authorizer) After(first echo.MiddlewareFunc) Authorizer {
+	return func(permission rbac.Permission) echo.MiddlewareFunc {
+		authorize := a(permission)
+		return func(fx echo.HandlerFunc) echo.HandlerFunc {
+			return first(authorize(fx))
+		}
+	}
+}
+
+// Authorize only lets the request through when the caller, identified by a
+// bearer token or an X-API-Key header, is granted permission (see
+// principal.Authorize). It answers 401 when the credentials are missing or
+// invalid and 403 when they lack the permission. The request is then scoped
+// to the store of the caller (see tenant.Resolve).
+func Authorize(principals *principal.Authorizer, stores tenant.Resolver) Authorizer {
+	return func(permission rbac.Permission) echo.MiddlewareFunc {
+		return authorize(principals, stores, permission)
+	}
+}
+
+func authorize(principals *principal.Authorizer, stores tenant.Resolver, permission rbac.Permission) echo.MiddlewareFunc {
+	return func(fx echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+
+			claims, err := principals.Authorize(request.Context(), principal.Credentials{
+				Token:  token.BearerToken(request.Header.Get(echo.HeaderAuthorization)),
+				APIKey: request.Header.Get(principal.APIKeyHeader),
+			}, permission)
+			if errors.Is(err, rbac.ErrorForbidden) {
+				return ctx.JSON(http.StatusForbidden, errorResponse{Message: "missing permission " + string(permission)})
+			}
+			if principal.Unauthenticated(err) {
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return ctx.JSON(http.StatusUnauthorized, errorResponse{Message: "missing or invalid token"})
+			}
+			if err != nil {
+				logging.From(request.Context()).Error().Err(err).Msg("could not authenticate request")
+				return ctx.JSON(http.StatusInternalServerError, errorResponse{Message: "could not authenticate request"})
+			}
+
+			header := stores.Header()
+			store, err := stores.Resolve(claims, request.Header.Get(header))
+			if err != nil {
+				return ctx.JSON(http.StatusForbidden, errorResponse{Message: err.Error()})
+			}
+			// responses depend on the store, so shared caches must not mix them
+			ctx.Response().Header().Add(echo.HeaderVary, header)
+
+			requestCtx := tenant.WithStore(request.Context(), store)
+			if claims != nil {
+				requestCtx = token.WithClaims(requestCtx, claims)
+			}
+			ctx.SetRequest(request.WithContext(requestCtx))
+
+			return fx(ctx)
+		}
+	}
+}
+
+// RateLimit answers 429 with a Retry-After header once the client has used
+// up the requests allowed on the matched route (see ratelimit.Limiter). It
+// runs after Authorize, so the client is the authenticated caller, or the
+// peer address for anonymous requests (see ratelimit.Client).
+func RateLimit(limiter *ratelimit.Limiter) echo.MiddlewareFunc {
+	return func(fx echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
 
-		return fx(ctx)
+			route := request.Method + " " + strings.TrimSuffix(ctx.Path(), "/")
+			client := ratelimit.Client(token.Claims(request.Context()), ctx.RealIP())
+
+			if allowed, wait := limiter.Allow(route, client); !allowed {
+				return tooManyRequests(ctx, wait)
+			}
+
+			return fx(ctx)
+		}
+	}
+}
+
+// PeerRateLimit answers 429 like RateLimit once the peer address has used
+// up its own bucket. It runs before Authorize, so requests with missing or
+// invalid credentials are limited before they are looked up.
+func PeerRateLimit(limiter *ratelimit.Limiter) echo.MiddlewareFunc {
+	return func(fx echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if allowed, wait := limiter.AllowPeer(ctx.RealIP()); !allowed {
+				return tooManyRequests(ctx, wait)
+			}
+
+			return fx(ctx)
+		}
+	}
+}
+
+func tooManyRequests(ctx echo.Context, wait time.Duration) error {
+	ctx.Response().Header().Set(echo.HeaderRetryAfter, ratelimit.RetryAfter(wait))
+	return ctx.JSON(http.StatusTooManyRequests, errorResponse{Message: "too many requests"})
+}
+
+// CacheControl sets the Cache-Control header configured for the matched
+// route on GET and HEAD responses.
+func CacheControl(routes map[string]string) echo.MiddlewareFunc {
+	return func(fx echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			method := ctx.Request().Method
+			if method != http.MethodGet && method != http.MethodHead {
+				return fx(ctx)
+			}
+
+			if value, ok := routes[strings.TrimSuffix(ctx.Path(), "/")]; ok {
+				ctx.Response().Header().Set(echo.HeaderCacheControl, value)
+			}
+
+			return fx(ctx)
+		}
 	}
 }
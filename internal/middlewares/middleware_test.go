@@ -0,0 +1,546 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tech-challenge-product/internal/auth/principal"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/metrics"
+	"tech-challenge-product/internal/ratelimit"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCacheControl(t *testing.T) {
+	routes := map[string]string{
+		"/api/product": "public, max-age=30",
+	}
+
+	type Given struct {
+		method string
+		path   string
+	}
+	type Expected struct {
+		cacheControl string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given configured route must set cache control": {
+			given: Given{
+				method: http.MethodGet,
+				path:   "/api/product/",
+			},
+			expected: Expected{
+				cacheControl: "public, max-age=30",
+			},
+		},
+		"given write method must not set cache control": {
+			given: Given{
+				method: http.MethodPost,
+				path:   "/api/product/",
+			},
+			expected: Expected{
+				cacheControl: "",
+			},
+		},
+		"given route without configuration must not set cache control": {
+			given: Given{
+				method: http.MethodGet,
+				path:   "/api/healthz",
+			},
+			expected: Expected{
+				cacheControl: "",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		router := echo.New()
+		router.Use(CacheControl(routes))
+		router.Add(tc.given.method, tc.given.path, func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tc.given.method, tc.given.path, nil))
+
+		assert.Equal(t, tc.expected.cacheControl, rec.Header().Get(echo.HeaderCacheControl))
+	}
+}
+
+func signedToken(key string, claims jwt.MapClaims) string {
+	signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	return "Bearer " + signed
+}
+
+// authorizer builds Authorize as the servers do, from settings.
+func authorizer(settings config.Config, keys principal.APIKeys) Authorizer {
+	policy := rbac.New(settings.Auth, token.New(settings.Token))
+	return Authorize(principal.New(policy, keys, settings.Tenancy), tenant.New(settings.Tenancy, policy))
+}
+
+func TestAuthorize(t *testing.T) {
+	settings := config.Config{}
+	settings.Token.Key = "test-key"
+	settings.Auth.RolesClaim = "roles"
+	settings.Auth.PublicReads = true
+	authorize := authorizer(settings, nil)
+
+	type Given struct {
+		permission    rbac.Permission
+		authorization string
+	}
+	type Expected struct {
+		statusCode int
+		body       string
+		subject    string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given missing token on write must return unauthorized": {
+			given: Given{
+				permission: rbac.PERMISSION_PRODUCT_WRITE,
+			},
+			expected: Expected{
+				statusCode: http.StatusUnauthorized,
+				body:       `{"message":"missing or invalid token"}`,
+			},
+		},
+		"given token signed with another key must return unauthorized": {
+			given: Given{
+				permission:    rbac.PERMISSION_PRODUCT_WRITE,
+				authorization: signedToken("other-key", jwt.MapClaims{"sub": "admin", "roles": []string{"admin"}}),
+			},
+			expected: Expected{
+				statusCode: http.StatusUnauthorized,
+				body:       `{"message":"missing or invalid token"}`,
+			},
+		},
+		"given kiosk token on write must return forbidden": {
+			given: Given{
+				permission:    rbac.PERMISSION_PRODUCT_WRITE,
+				authorization: signedToken("test-key", jwt.MapClaims{"sub": "kiosk-1", "roles": []string{"kiosk"}}),
+			},
+			expected: Expected{
+				statusCode: http.StatusForbidden,
+				body:       `{"message":"missing permission product:write"}`,
+			},
+		},
+		"given manager token on write must reach handler with subject": {
+			given: Given{
+				permission:    rbac.PERMISSION_PRODUCT_WRITE,
+				authorization: signedToken("test-key", jwt.MapClaims{"sub": "maria", "roles": []string{"manager"}}),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				subject:    "maria",
+			},
+		},
+		"given public read without token must reach handler": {
+			given: Given{
+				permission: rbac.PERMISSION_PRODUCT_READ,
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+			},
+		},
+		"given public read with invalid token must return unauthorized": {
+			given: Given{
+				permission:    rbac.PERMISSION_PRODUCT_READ,
+				authorization: "Bearer not-a-jwt",
+			},
+			expected: Expected{
+				statusCode: http.StatusUnauthorized,
+				body:       `{"message":"missing or invalid token"}`,
+			},
+		},
+		"given public read with token without roles must reach handler": {
+			given: Given{
+				permission:    rbac.PERMISSION_PRODUCT_READ,
+				authorization: signedToken("test-key", jwt.MapClaims{"sub": "guest"}),
+			},
+			expected: Expected{
+				statusCode: http.StatusOK,
+				subject:    "guest",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var subject string
+			router := echo.New()
+			router.POST("/api/product/", func(c echo.Context) error {
+				subject = token.Subject(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, authorize(tc.given.permission))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/product/", nil)
+			if tc.given.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.given.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+			assert.Equal(t, tc.expected.subject, subject)
+			if tc.expected.body != "" {
+				assert.Equal(t, tc.expected.body, strings.TrimSpace(rec.Body.String()))
+			}
+			if tc.expected.statusCode == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+		})
+	}
+}
+
+func TestAuthorizeStore(t *testing.T) {
+	settings := config.Config{}
+	settings.Token.Key = "test-key"
+	settings.Auth.RolesClaim = "roles"
+	settings.Tenancy.Claim = "store_id"
+	settings.Tenancy.Header = "X-Store-ID"
+	authorize := authorizer(settings, nil)
+
+	type Given struct {
+		claims jwt.MapClaims
+		header string
+	}
+	type Expected struct {
+		statusCode int
+		store      string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given token bound to a store must scope the request to it": {
+			given: Given{
+				claims: jwt.MapClaims{"sub": "maria", "roles": []string{"manager"}, "store_id": "store_1"},
+			},
+			expected: Expected{statusCode: http.StatusOK, store: "store_1"},
+		},
+		"given token bound to a store asking for another must return forbidden": {
+			given: Given{
+				claims: jwt.MapClaims{"sub": "maria", "roles": []string{"manager"}, "store_id": "store_1"},
+				header: "store_2",
+			},
+			expected: Expected{statusCode: http.StatusForbidden},
+		},
+		"given token without store must use the header": {
+			given: Given{
+				claims: jwt.MapClaims{"sub": "admin", "roles": []string{"admin"}},
+				header: "store_2",
+			},
+			expected: Expected{statusCode: http.StatusOK, store: "store_2"},
+		},
+		"given manager token without store asking for one must return forbidden": {
+			given: Given{
+				claims: jwt.MapClaims{"sub": "maria", "roles": []string{"manager"}},
+				header: "store_2",
+			},
+			expected: Expected{statusCode: http.StatusForbidden},
+		},
+		"given token without store nor header must use the base catalog": {
+			given: Given{
+				claims: jwt.MapClaims{"sub": "admin", "roles": []string{"admin"}},
+			},
+			expected: Expected{statusCode: http.StatusOK, store: ""},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var store string
+			router := echo.New()
+			router.POST("/api/product/", func(c echo.Context) error {
+				store = tenant.Store(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, authorize(rbac.PERMISSION_PRODUCT_WRITE))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/product/", nil)
+			req.Header.Set(echo.HeaderAuthorization, signedToken("test-key", tc.given.claims))
+			if tc.given.header != "" {
+				req.Header.Set("X-Store-ID", tc.given.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+			assert.Equal(t, tc.expected.store, store)
+			if tc.expected.statusCode == http.StatusOK {
+				assert.Equal(t, "X-Store-ID", rec.Header().Get(echo.HeaderVary))
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.New(config.RateLimit{
+		Default: config.Limit{Rate: 0.5, Burst: 2},
+		Routes: map[string]config.Limit{
+			"GET /api/healthz": {},
+		},
+	})
+
+	// stands for Authorize, taking the subject of the caller from the
+	// Authorization header
+	var authorize Authorizer = func(rbac.Permission) echo.MiddlewareFunc {
+		return func(fx echo.HandlerFunc) echo.HandlerFunc {
+			return func(ctx echo.Context) error {
+				if subject := ctx.Request().Header.Get(echo.HeaderAuthorization); subject != "" {
+					ctx.SetRequest(ctx.Request().WithContext(token.WithClaims(ctx.Request().Context(), jwt.MapClaims{"sub": subject})))
+				}
+				return fx(ctx)
+			}
+		}
+	}
+	limited := authorize.Then(RateLimit(limiter))
+
+	router := echo.New()
+	router.IPExtractor = echo.ExtractIPDirect()
+	router.GET("/api/product/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limited(rbac.PERMISSION_PRODUCT_READ))
+	router.GET("/api/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limited(rbac.PERMISSION_PRODUCT_READ))
+
+	request := func(path, ip, forwardedFor, subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":41234"
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderAuthorization, subject)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("/api/product/", "10.0.0.1", "203.0.113.1", "").Code)
+	assert.Equal(t, http.StatusOK, request("/api/product/", "10.0.0.1", "203.0.113.2", "").Code)
+
+	rec := request("/api/product/", "10.0.0.1", "203.0.113.3", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, `{"message":"too many requests"}`, strings.TrimSpace(rec.Body.String()))
+
+	assert.Equal(t, http.StatusOK, request("/api/product/", "10.0.0.2", "", "").Code)
+	assert.Equal(t, http.StatusOK, request("/api/product/", "10.0.0.1", "", "kiosk-1").Code)
+	assert.Equal(t, http.StatusOK, request("/api/product/", "10.0.0.1", "", "kiosk-1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/product/", "10.0.0.3", "", "kiosk-1").Code)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, request("/api/healthz", "10.0.0.1", "", "").Code)
+	}
+}
+
+func TestPeerRateLimit(t *testing.T) {
+	limiter := ratelimit.New(config.RateLimit{Peer: config.Limit{Rate: 0.5, Burst: 2}})
+
+	// stands for Authorize refusing made up credentials
+	var authorize Authorizer = func(rbac.Permission) echo.MiddlewareFunc {
+		return func(echo.HandlerFunc) echo.HandlerFunc {
+			return func(ctx echo.Context) error {
+				return ctx.NoContent(http.StatusUnauthorized)
+			}
+		}
+	}
+	limited := authorize.After(PeerRateLimit(limiter))
+
+	router := echo.New()
+	router.IPExtractor = echo.ExtractIPDirect()
+	router.GET("/api/product/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limited(rbac.PERMISSION_PRODUCT_READ))
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/product/", nil)
+		req.RemoteAddr = ip + ":41234"
+		req.Header.Set(principal.APIKeyHeader, "made-up-"+canonical.NewUUID())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1").Code)
+
+	rec := request("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(echo.HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.2").Code)
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	router := echo.New()
+	router.Use(Metrics(metrics.NewRecorder(registry)))
+	router.GET("/metrics", echo.WrapHandler(metrics.Handler(registry)))
+	router.GET("/api/product/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	request("/api/product/1")
+	request("/api/product/2")
+	request("/api/product/missing")
+
+	body := request("/metrics").Body.String()
+
+	assert.Contains(t, body, `product_http_requests_total{method="GET",route="/api/product/:id",status="200"} 2`)
+	assert.Contains(t, body, `product_http_requests_total{method="GET",route="/api/product/:id",status="404"} 1`)
+	assert.Contains(t, body, `product_http_request_duration_seconds_count{method="GET",route="/api/product/:id",status="200"} 2`)
+}
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	log.Logger = zerolog.New(&out)
+
+	var handled string
+	router := echo.New()
+	router.Use(Logger)
+	router.GET("/api/product/:id", func(c echo.Context) error {
+		handled = logging.RequestID(c.Request().Context())
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.String(http.StatusOK, "found")
+	})
+
+	type Given struct {
+		path      string
+		requestID string
+	}
+	type Expected struct {
+		status int
+		bytes  float64
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given request id must keep it and log the response": {
+			given:    Given{path: "/api/product/1", requestID: "req-1"},
+			expected: Expected{status: http.StatusOK, bytes: 5},
+		},
+		"given no request id must generate one": {
+			given:    Given{path: "/api/product/1"},
+			expected: Expected{status: http.StatusOK, bytes: 5},
+		},
+		"given handler error must log its status": {
+			given:    Given{path: "/api/product/missing", requestID: "req-2"},
+			expected: Expected{status: http.StatusNotFound},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			out.Reset()
+			req := httptest.NewRequest(http.MethodGet, tc.given.path, nil)
+			if tc.given.requestID != "" {
+				req.Header.Set(logging.RequestIDHeader, tc.given.requestID)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(logging.RequestIDHeader)
+			assert.NotEmpty(t, id)
+			if tc.given.requestID != "" {
+				assert.Equal(t, tc.given.requestID, id)
+			}
+			assert.Equal(t, id, handled)
+
+			var entry map[string]interface{}
+			assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+			assert.Equal(t, id, entry["request_id"])
+			assert.Equal(t, "/api/product/:id", entry["route"])
+			assert.Equal(t, float64(tc.expected.status), entry["status"])
+			assert.Equal(t, tc.expected.bytes, entry["bytes"])
+			assert.Contains(t, entry, "latency")
+		})
+	}
+}
+
+func TestAuthorizeAPIKey(t *testing.T) {
+	apiKeys := &APIKeysMock{}
+	apiKeys.On("Authenticate", mock.Anything, "pk_writer").Return(&canonical.APIKey{ID: "writer", Scopes: []string{"product:write"}}, nil)
+	apiKeys.On("Authenticate", mock.Anything, "pk_revoked").Return(nil, canonical.ErrorRejectedAPIKey)
+
+	type Given struct {
+		apiKey    string
+		installed bool
+	}
+	type Expected struct {
+		statusCode int
+		subject    string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given api key with the scope must reach handler with the key as subject": {
+			given:    Given{apiKey: "pk_writer", installed: true},
+			expected: Expected{statusCode: http.StatusOK, subject: "api_key:writer"},
+		},
+		"given rejected api key must return unauthorized": {
+			given:    Given{apiKey: "pk_revoked", installed: true},
+			expected: Expected{statusCode: http.StatusUnauthorized},
+		},
+		"given router without api keys must fail": {
+			given:    Given{apiKey: "pk_writer"},
+			expected: Expected{statusCode: http.StatusInternalServerError},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var keys principal.APIKeys
+			if tc.given.installed {
+				keys = apiKeys
+			}
+
+			var subject string
+			router := echo.New()
+			router.POST("/api/product/", func(c echo.Context) error {
+				subject = token.Subject(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, authorizer(config.Config{}, keys)(rbac.PERMISSION_PRODUCT_WRITE))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/product/", nil)
+			req.Header.Set("X-API-Key", tc.given.apiKey)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expected.statusCode, rec.Code)
+			assert.Equal(t, tc.expected.subject, subject)
+		})
+	}
+}
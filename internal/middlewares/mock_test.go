@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeysMock struct {
+	mock.Mock
+}
+
+func (m *APIKeysMock) Authenticate(ctx context.Context, key string) (*canonical.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*canonical.APIKey), args.Error(1)
+}
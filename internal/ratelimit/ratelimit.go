@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"tech-challenge-product/internal/config"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Limiter keeps a token bucket per route and client.
+type Limiter struct {
+	settings config.RateLimit
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New limits the clients with the buckets configured in settings.
+func New(settings config.RateLimit) *Limiter {
+	return &Limiter{
+		settings: settings,
+		now:      time.Now,
+		buckets:  map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from the bucket of client on route. When the bucket
+// is empty it returns false and how long until a token is available.
+func (l *Limiter) Allow(route, client string) (bool, time.Duration) {
+	return l.take(route+" "+client, l.limit(route))
+}
+
+// AllowPeer takes a token from the bucket of the peer address ip, shared by
+// every route, like Allow.
+func (l *Limiter) AllowPeer(ip string) (bool, time.Duration) {
+	return l.take("peer "+ip, l.settings.Peer)
+}
+
+func (l *Limiter) take(key string, limit config.Limit) (bool, time.Duration) {
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = math.Max(1, math.Ceil(limit.Rate))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) limit(route string) config.Limit {
+	if limit, ok := l.settings.Routes[route]; ok {
+		return limit
+	}
+	return l.settings.Default
+}
+
+// sweep drops the buckets of clients idle for longer than IdleTimeout. A
+// dropped bucket would have refilled by then anyway.
+func (l *Limiter) sweep(now time.Time) {
+	idle := l.settings.IdleTimeout
+	if idle <= 0 || now.Sub(l.lastSweep) < idle {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Client identifies the caller of a request once it was authenticated: by
+// the subject of its claims, which API keys and client certificates carry
+// too, or else by the address of the peer. Credentials are never used as is,
+// so a caller cannot get a fresh bucket by sending made up ones.
+func Client(claims jwt.MapClaims, ip string) string {
+	if subject, _ := claims["sub"].(string); subject != "" {
+		return "sub:" + subject
+	}
+	return "ip:" + ip
+}
+
+// RetryAfter formats wait as the whole seconds of a Retry-After header.
+func RetryAfter(wait time.Duration) string {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
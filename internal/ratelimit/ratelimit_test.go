@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"tech-challenge-product/internal/config"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		route    string
+		requests []time.Duration
+	}
+	type Expected struct {
+		allowed []bool
+		wait    time.Duration
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given requests within the burst must allow them": {
+			given: Given{
+				route:    "GET /api/product",
+				requests: []time.Duration{0, 0, 0},
+			},
+			expected: Expected{allowed: []bool{true, true, true}},
+		},
+		"given requests over the burst must refuse them until refilled": {
+			given: Given{
+				route:    "GET /api/product",
+				requests: []time.Duration{0, 0, 0, 0, 500 * time.Millisecond},
+			},
+			expected: Expected{allowed: []bool{true, true, true, false, true}, wait: 500 * time.Millisecond},
+		},
+		"given route with its own limit must use it": {
+			given: Given{
+				route:    "POST /api/product",
+				requests: []time.Duration{0, 0},
+			},
+			expected: Expected{allowed: []bool{true, false}, wait: 10 * time.Second},
+		},
+		"given route without limit must allow everything": {
+			given: Given{
+				route:    "/grpc.health.v1.Health/Check",
+				requests: []time.Duration{0, 0, 0, 0, 0},
+			},
+			expected: Expected{allowed: []bool{true, true, true, true, true}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			now := start
+			limiter := New(config.RateLimit{
+				Default: config.Limit{Rate: 2, Burst: 3},
+				Routes: map[string]config.Limit{
+					"POST /api/product":            {Rate: 0.1, Burst: 1},
+					"/grpc.health.v1.Health/Check": {},
+				},
+			})
+			limiter.now = func() time.Time { return now }
+
+			var wait time.Duration
+			for i, elapsed := range tc.given.requests {
+				now = now.Add(elapsed)
+
+				allowed, retry := limiter.Allow(tc.given.route, "sub:kiosk-1")
+				if !allowed {
+					wait = retry
+				}
+				assert.Equal(t, tc.expected.allowed[i], allowed, "request %d", i)
+			}
+			assert.Equal(t, tc.expected.wait, wait)
+		})
+	}
+}
+
+func TestLimiter_ClientsAreIndependent(t *testing.T) {
+	limiter := New(config.RateLimit{Default: config.Limit{Rate: 1, Burst: 1}})
+
+	allowed, _ := limiter.Allow("GET /api/product", "ip:10.0.0.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("GET /api/product", "ip:10.0.0.1")
+	assert.False(t, allowed)
+	allowed, _ = limiter.Allow("GET /api/product", "ip:10.0.0.2")
+	assert.True(t, allowed)
+}
+
+func TestLimiter_AllowPeer(t *testing.T) {
+	limiter := New(config.RateLimit{
+		Default: config.Limit{Rate: 1, Burst: 5},
+		Peer:    config.Limit{Rate: 1, Burst: 2},
+	})
+
+	allowed, _ := limiter.AllowPeer("10.0.0.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.AllowPeer("10.0.0.1")
+	assert.True(t, allowed)
+	allowed, wait := limiter.AllowPeer("10.0.0.1")
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, wait.Round(time.Second))
+
+	// the peer bucket is apart from the route buckets
+	allowed, _ = limiter.Allow("GET /api/product", "ip:10.0.0.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.AllowPeer("10.0.0.2")
+	assert.True(t, allowed)
+}
+
+func TestLimiter_DropsIdleBuckets(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	limiter := New(config.RateLimit{Default: config.Limit{Rate: 1, Burst: 1}, IdleTimeout: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("GET /api/product", "ip:10.0.0.1")
+	assert.Len(t, limiter.buckets, 1)
+
+	now = now.Add(2 * time.Minute)
+	limiter.Allow("GET /api/product", "ip:10.0.0.2")
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "GET /api/product ip:10.0.0.2")
+}
+
+func TestClient(t *testing.T) {
+	assert.Equal(t, "sub:kiosk-1", Client(jwt.MapClaims{"sub": "kiosk-1"}, "10.0.0.1"))
+	assert.Equal(t, "sub:api_key:key_1", Client(jwt.MapClaims{"sub": "api_key:key_1"}, "10.0.0.1"))
+	assert.Equal(t, "ip:10.0.0.1", Client(jwt.MapClaims{"name": "kiosk"}, "10.0.0.1"))
+	assert.Equal(t, "ip:10.0.0.1", Client(nil, "10.0.0.1"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, "1", RetryAfter(0))
+	assert.Equal(t, "1", RetryAfter(200*time.Millisecond))
+	assert.Equal(t, "3", RetryAfter(2100*time.Millisecond))
+}
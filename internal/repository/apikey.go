@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	apiKeyCollection = "api_key"
+)
+
+// APIKeyRepository lists and revokes the keys of the store ctx is scoped
+// to. GetByHash looks keys up across stores to authenticate callers.
+type APIKeyRepository interface {
+	Create(context.Context, canonical.APIKey) error
+	GetAll(context.Context) ([]canonical.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*canonical.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewAPIKeyRepo(db *mongo.Database, timeout time.Duration) APIKeyRepository {
+	return &apiKeyRepository{
+		collection: db.Collection(apiKeyCollection),
+		timeout:    timeout,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key canonical.APIKey) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.D{{Key: "store_id", Value: ownStore(ctx)}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.APIKey
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*canonical.APIKey, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	var key canonical.APIKey
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "hash", Value: hash}}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, canonical.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Revoke marks the key as revoked; revoking it again keeps the first date.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}, {Key: "store_id", Value: ownStore(ctx)}, {Key: "revoked_at", Value: nil}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.D{{Key: "_id", Value: id}, {Key: "store_id", Value: ownStore(ctx)}})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return canonical.ErrorNotFound
+		}
+	}
+	return nil
+}
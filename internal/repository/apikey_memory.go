@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"time"
+)
+
+type memoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys []canonical.APIKey
+}
+
+func (r *memoryAPIKeyRepository) Create(ctx context.Context, key canonical.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []canonical.APIKey
+	for _, key := range r.keys {
+		if ownedBy(ctx, key.StoreID) {
+			results = append(results, key)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	return results, nil
+}
+
+func (r *memoryAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*canonical.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, canonical.ErrorNotFound
+}
+
+// Revoke marks the key as revoked; revoking it again keeps the first date.
+func (r *memoryAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.ID != id || !ownedBy(ctx, key.StoreID) {
+			continue
+		}
+		if key.RevokedAt == nil {
+			r.keys[i].RevokedAt = &at
+		}
+		return nil
+	}
+	return canonical.ErrorNotFound
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditCollection = "audit"
+)
+
+type AuditRepository interface {
+	Create(context.Context, canonical.AuditEntry) error
+	GetByEntity(ctx context.Context, entityID string) ([]canonical.AuditEntry, error)
+	Find(context.Context, canonical.AuditFilter) ([]canonical.AuditEntry, error)
+}
+
+type auditRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewAuditRepo(db *mongo.Database, timeout time.Duration) AuditRepository {
+	return &auditRepository{
+		collection: db.Collection(auditCollection),
+		timeout:    timeout,
+	}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry canonical.AuditEntry) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *auditRepository) GetByEntity(ctx context.Context, entityID string) ([]canonical.AuditEntry, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, bson.D{{Key: "entity_id", Value: entityID}})
+}
+
+func (r *auditRepository) Find(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	query := bson.D{}
+	if filter.Actor != "" {
+		query = append(query, bson.E{Key: "actor", Value: filter.Actor})
+	}
+
+	timestamp := bson.D{}
+	if !filter.From.IsZero() {
+		timestamp = append(timestamp, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		timestamp = append(timestamp, bson.E{Key: "$lte", Value: filter.To})
+	}
+	if len(timestamp) > 0 {
+		query = append(query, bson.E{Key: "timestamp", Value: timestamp})
+	}
+
+	return r.find(ctx, query)
+}
+
+// find only returns the entries of the store ctx is scoped to.
+func (r *auditRepository) find(ctx context.Context, query bson.D) ([]canonical.AuditEntry, error) {
+	query = append(query, bson.E{Key: "store_id", Value: ownStore(ctx)})
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.AuditEntry
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
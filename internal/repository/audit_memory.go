@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+)
+
+type memoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []canonical.AuditEntry
+}
+
+func (r *memoryAuditRepository) Create(ctx context.Context, entry canonical.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *memoryAuditRepository) GetByEntity(ctx context.Context, entityID string) ([]canonical.AuditEntry, error) {
+	return r.find(ctx, func(entry canonical.AuditEntry) bool {
+		return entry.EntityID == entityID
+	}), nil
+}
+
+func (r *memoryAuditRepository) Find(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	return r.find(ctx, func(entry canonical.AuditEntry) bool {
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			return false
+		}
+		if !filter.From.IsZero() && entry.Timestamp.Before(filter.From) {
+			return false
+		}
+		return filter.To.IsZero() || !entry.Timestamp.After(filter.To)
+	}), nil
+}
+
+// find returns the matching entries of the store ctx is scoped to, newest
+// first.
+func (r *memoryAuditRepository) find(ctx context.Context, match func(canonical.AuditEntry) bool) []canonical.AuditEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []canonical.AuditEntry
+	for _, entry := range r.entries {
+		if ownedBy(ctx, entry.StoreID) && match(entry) {
+			results = append(results, entry)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+	return results
+}
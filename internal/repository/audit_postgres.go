@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const auditColumns = `id, entity_id, store_id, action, actor, logged_at, changes`
+
+type postgresAuditRepository struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+func NewPostgresAuditRepo(pool *pgxpool.Pool, timeout time.Duration) AuditRepository {
+	return &postgresAuditRepository{
+		pool:    pool,
+		timeout: timeout,
+	}
+}
+
+func (r *postgresAuditRepository) Create(ctx context.Context, entry canonical.AuditEntry) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := postgresConn(ctx, r.pool).Exec(ctx, `INSERT INTO audit_entries (`+auditColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.ID, entry.EntityID, entry.StoreID, entry.Action, entry.Actor, entry.Timestamp, entry.Changes)
+	return err
+}
+
+func (r *postgresAuditRepository) GetByEntity(ctx context.Context, entityID string) ([]canonical.AuditEntry, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, []string{"entity_id = $2"}, entityID)
+}
+
+func (r *postgresAuditRepository) Find(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)+1), 1))
+	}
+
+	if filter.Actor != "" {
+		where("actor = ?", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		where("logged_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("logged_at <= ?", filter.To)
+	}
+
+	return r.find(ctx, conditions, args...)
+}
+
+// find only returns the entries of the store ctx is scoped to, newest
+// first. conditions number their arguments from $2.
+func (r *postgresAuditRepository) find(ctx context.Context, conditions []string, args ...interface{}) ([]canonical.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_entries
+		WHERE ` + strings.Join(append([]string{"store_id = $1"}, conditions...), " AND ") + `
+		ORDER BY logged_at DESC`
+
+	rows, _ := postgresConn(ctx, r.pool).Query(ctx, query, append([]interface{}{tenant.Store(ctx)}, args...)...)
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (canonical.AuditEntry, error) {
+		var entry canonical.AuditEntry
+		err := row.Scan(&entry.ID, &entry.EntityID, &entry.StoreID, &entry.Action, &entry.Actor, &entry.Timestamp, &entry.Changes)
+		entry.Timestamp = entry.Timestamp.UTC()
+		return entry, err
+	})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAuditRepository_Create(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := auditRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := repo.Create(context.Background(), canonical.AuditEntry{
+			ID:       "audit_valid_id",
+			EntityID: "product_valid_id",
+			Action:   canonical.AUDIT_UPDATE,
+			Actor:    "admin",
+			Changes:  []canonical.FieldChange{{Field: "price", Before: 10.0, After: 12.5}},
+		})
+
+		assert.Nil(t, err)
+	})
+}
+
+func TestAuditRepository_Find(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	type Given struct {
+		filter canonical.AuditFilter
+		store  string
+	}
+	type Expected struct {
+		filter bson.D
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given actor and date range must filter by both": {
+			given: Given{
+				filter: canonical.AuditFilter{Actor: "admin", From: from, To: to},
+			},
+			expected: Expected{
+				filter: bson.D{
+					{Key: "actor", Value: "admin"},
+					{Key: "timestamp", Value: bson.D{
+						{Key: "$gte", Value: primitive.NewDateTimeFromTime(from)},
+						{Key: "$lte", Value: primitive.NewDateTimeFromTime(to)},
+					}},
+					{Key: "store_id", Value: bson.D{{Key: "$in", Value: bson.A{"", nil}}}},
+				},
+			},
+		},
+		"given empty filter must return every base catalog entry": {
+			given: Given{
+				filter: canonical.AuditFilter{},
+			},
+			expected: Expected{
+				filter: bson.D{{Key: "store_id", Value: bson.D{{Key: "$in", Value: bson.A{"", nil}}}}},
+			},
+		},
+		"given store must only return its entries": {
+			given: Given{
+				filter: canonical.AuditFilter{},
+				store:  "store_1",
+			},
+			expected: Expected{
+				filter: bson.D{{Key: "store_id", Value: "store_1"}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+		db.Run(name, func(mt *mtest.T) {
+			repo := auditRepository{
+				collection: mt.DB.Collection("fake-collection"),
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.audit", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "audit_valid_id"},
+				{Key: "entity_id", Value: "product_valid_id"},
+				{Key: "action", Value: "update"},
+				{Key: "actor", Value: "admin"},
+			}))
+
+			entries, err := repo.Find(tenant.WithStore(context.Background(), tc.given.store), tc.given.filter)
+
+			assert.Nil(t, err)
+			assert.Len(t, entries, 1)
+			assert.Equal(t, canonical.AUDIT_UPDATE, entries[0].Action)
+
+			var filter bson.D
+			assert.Nil(t, mt.GetStartedEvent().Command.Lookup("filter").Unmarshal(&filter))
+			assert.Equal(t, tc.expected.filter, filter)
+		})
+	}
+}
@@ -0,0 +1,526 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoURIEnv and postgresURIEnv point the conformance suite to database
+// servers. The repositories using them are only checked when they are set.
+const (
+	mongoURIEnv    = "MONGODB_URI"
+	postgresURIEnv = "POSTGRES_URI"
+)
+
+func TestMemoryProductRepository(t *testing.T) {
+	testProductRepository(t, func(t *testing.T) ProductRepository {
+		return NewMemoryProductRepo()
+	})
+}
+
+func TestMongoProductRepository(t *testing.T) {
+	uri := os.Getenv(mongoURIEnv)
+	if uri == "" {
+		t.Skip(mongoURIEnv + " is not set")
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	testProductRepository(t, func(t *testing.T) ProductRepository {
+		db := client.Database("conformance_" + canonical.NewUUID())
+		t.Cleanup(func() { db.Drop(context.Background()) })
+		return NewProductRepo(db, 5*time.Second)
+	})
+}
+
+func TestPostgresProductRepository(t *testing.T) {
+	uri := os.Getenv(postgresURIEnv)
+	if uri == "" {
+		t.Skip(postgresURIEnv + " is not set")
+	}
+
+	testProductRepository(t, func(t *testing.T) ProductRepository {
+		return NewPostgresProductRepo(newPostgresSchema(t, uri), 5*time.Second)
+	})
+}
+
+func TestMemoryRepositories(t *testing.T) {
+	testProductWatcher(t, func(t *testing.T) Repositories {
+		return NewMemoryRepositories()
+	})
+}
+
+func TestMongoRepositories(t *testing.T) {
+	uri := os.Getenv(mongoURIEnv)
+	if uri == "" {
+		t.Skip(mongoURIEnv + " is not set")
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	newRepos := func(t *testing.T) Repositories {
+		db := client.Database("conformance_" + canonical.NewUUID())
+		t.Cleanup(func() { db.Drop(context.Background()) })
+		// transactions can not create collections on older servers
+		for _, collection := range []string{productCollection, auditCollection, outboxCollection, priceOverrideCollection} {
+			require.NoError(t, db.CreateCollection(context.Background(), collection))
+		}
+		return NewMongoRepositories(client, db, 5*time.Second)
+	}
+	testProductWatcher(t, newRepos)
+	testTransactor(t, newRepos)
+}
+
+func TestPostgresRepositories(t *testing.T) {
+	uri := os.Getenv(postgresURIEnv)
+	if uri == "" {
+		t.Skip(postgresURIEnv + " is not set")
+	}
+
+	newRepos := func(t *testing.T) Repositories {
+		return NewPostgresRepositories(newPostgresSchema(t, uri), 5*time.Second)
+	}
+	testProductWatcher(t, newRepos)
+	testTransactor(t, newRepos)
+
+	t.Run("Watch must not hold a pool connection while waiting", func(t *testing.T) {
+		pool := newPostgresSchema(t, uri)
+		repos := NewPostgresRepositories(pool, 5*time.Second)
+		token := watchToken(t, repos)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		watches := int(pool.Config().MaxConns) + 1
+		results := make(chan error, watches)
+		for i := 0; i < watches; i++ {
+			go func() {
+				results <- repos.Watcher.Watch(ctx, canonical.ChangeFilter{IDs: []string{"soda"}}, token, func(canonical.ProductChange) error {
+					return errWatchDone
+				})
+			}()
+		}
+		// let every watch start waiting before the change they wait for
+		time.Sleep(500 * time.Millisecond)
+
+		_, err := repos.Products.Create(ctx, &canonical.Product{ID: "soda"})
+		require.NoError(t, err)
+		for i := 0; i < watches; i++ {
+			assert.ErrorIs(t, <-results, errWatchDone)
+		}
+	})
+}
+
+// newPostgresSchema connects to uri with a migrated schema of its own,
+// dropped once t is done.
+func newPostgresSchema(t *testing.T, uri string) *pgxpool.Pool {
+	ctx := context.Background()
+	schema := "conformance_" + strings.ReplaceAll(canonical.NewUUID(), "-", "_")
+
+	admin, err := pgx.Connect(ctx, uri)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close(ctx) })
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE") })
+
+	settings, err := pgxpool.ParseConfig(uri)
+	require.NoError(t, err)
+	settings.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, settings)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	require.NoError(t, migratePostgres(ctx, pool))
+	// applying them again must be a no-op
+	require.NoError(t, migratePostgres(ctx, pool))
+	return pool
+}
+
+// testProductRepository checks the behaviour every ProductRepository must
+// share. newRepo must return an empty repository.
+func testProductRepository(t *testing.T, newRepo func(t *testing.T) ProductRepository) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fixtures := []canonical.Product{
+		{ID: "base-soda", Name: "Soda", Price: 5, Category: "drinks", CreatedAt: created, UpdatedAt: created},
+		{ID: "base-burger", Name: "Burger", Price: 20, Category: "food", CreatedAt: created, UpdatedAt: created.Add(time.Hour)},
+		{ID: "base-juice", Name: "Juice", Price: 7, Category: "drinks", Status: canonical.STATUS_INACTIVE, CreatedAt: created, UpdatedAt: created.Add(2 * time.Hour)},
+		{ID: "store-1-tea", StoreID: "store-1", Name: "Tea", Price: 4, Category: "drinks", CreatedAt: created, UpdatedAt: created.Add(3 * time.Hour)},
+		{ID: "store-2-water", StoreID: "store-2", Name: "Water", Price: 2, Category: "drinks", CreatedAt: created, UpdatedAt: created},
+	}
+
+	seeded := func(t *testing.T) ProductRepository {
+		repo := newRepo(t)
+		for _, product := range fixtures {
+			product := product
+			ctx := tenant.WithStore(context.Background(), product.StoreID)
+			_, err := repo.Create(ctx, &product)
+			require.NoError(t, err)
+		}
+		return repo
+	}
+
+	base := context.Background()
+	store1 := tenant.WithStore(base, "store-1")
+
+	tests := map[string]func(t *testing.T, repo ProductRepository){
+		"GetAll must return the active products visible from the store": func(t *testing.T, repo ProductRepository) {
+			products, err := repo.GetAll(base)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{"base-soda", "base-burger"}, ids(products))
+
+			products, err = repo.GetAll(store1)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{"base-soda", "base-burger", "store-1-tea"}, ids(products))
+		},
+		"GetByID must return the product with every field": func(t *testing.T, repo ProductRepository) {
+			product, err := repo.GetByID(store1, "store-1-tea")
+			assert.NoError(t, err)
+			assert.Equal(t, &fixtures[3], product)
+		},
+		"GetByID must return inactive products": func(t *testing.T, repo ProductRepository) {
+			product, err := repo.GetByID(base, "base-juice")
+			assert.NoError(t, err)
+			assert.Equal(t, canonical.STATUS_INACTIVE, product.Status)
+		},
+		"GetByID must fail with not found for unknown or other store products": func(t *testing.T, repo ProductRepository) {
+			_, err := repo.GetByID(base, "unknown")
+			assert.ErrorIs(t, err, canonical.ErrorNotFound)
+
+			_, err = repo.GetByID(store1, "store-2-water")
+			assert.ErrorIs(t, err, canonical.ErrorNotFound)
+		},
+		"GetByCategory must return the active products of the category": func(t *testing.T, repo ProductRepository) {
+			products, err := repo.GetByCategory(store1, "drinks")
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{"base-soda", "store-1-tea"}, ids(products))
+
+			products, err = repo.GetByCategory(base, "unknown")
+			assert.NoError(t, err)
+			assert.Empty(t, products)
+		},
+		"GetProductsWithId must return the visible products of any status": func(t *testing.T, repo ProductRepository) {
+			products, err := repo.GetProductsWithId(store1, []string{"base-juice", "store-1-tea", "store-2-water", "unknown"})
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{"base-juice", "store-1-tea"}, ids(products))
+
+			products, err = repo.GetProductsWithId(base, nil)
+			assert.NoError(t, err)
+			assert.Empty(t, products)
+		},
+		"Find must filter by category and sort with the id as tie breaker": func(t *testing.T, repo ProductRepository) {
+			products, err := repo.Find(store1, canonical.ProductFilter{Category: "drinks", SortBy: "price", Descending: true})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"base-soda", "store-1-tea"}, ids(products))
+
+			products, err = repo.Find(store1, canonical.ProductFilter{SortBy: "created_at"})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"base-burger", "base-soda", "store-1-tea"}, ids(products))
+		},
+		"Find updated since must include inactive products": func(t *testing.T, repo ProductRepository) {
+			products, err := repo.Find(base, canonical.ProductFilter{UpdatedSince: created.Add(time.Hour), SortBy: "updated_at"})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"base-burger", "base-juice"}, ids(products))
+		},
+		"Create must fail for an existing id": func(t *testing.T, repo ProductRepository) {
+			_, err := repo.Create(base, &canonical.Product{ID: "base-soda"})
+			assert.Error(t, err)
+		},
+		"Update must replace the product and increment its version": func(t *testing.T, repo ProductRepository) {
+			updated := fixtures[3]
+			updated.Name = "Green tea"
+			updated.Status = canonical.STATUS_INACTIVE
+			updated.UpdatedAt = created.Add(4 * time.Hour)
+			updated.CreatedBy = "ignored"
+
+			assert.NoError(t, repo.Update(store1, "store-1-tea", updated))
+
+			product, err := repo.GetByID(store1, "store-1-tea")
+			assert.NoError(t, err)
+			assert.Equal(t, "Green tea", product.Name)
+			assert.Equal(t, canonical.STATUS_INACTIVE, product.Status)
+			assert.Equal(t, updated.UpdatedAt, product.UpdatedAt)
+			assert.Equal(t, int64(1), product.Version)
+			assert.Equal(t, "", product.CreatedBy)
+			assert.Equal(t, "store-1", product.StoreID)
+		},
+		"removed product must leave the listings but stay readable": func(t *testing.T, repo ProductRepository) {
+			removed := fixtures[0]
+			removed.Status = canonical.STATUS_INACTIVE
+			assert.NoError(t, repo.Update(base, "base-soda", removed))
+
+			products, err := repo.GetAll(base)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"base-burger"}, ids(products))
+
+			products, err = repo.GetByCategory(base, "drinks")
+			assert.NoError(t, err)
+			assert.Empty(t, products)
+
+			product, err := repo.GetByID(base, "base-soda")
+			assert.NoError(t, err)
+			assert.Equal(t, canonical.STATUS_INACTIVE, product.Status)
+		},
+		"Update must fail with version mismatch for a stale version": func(t *testing.T, repo ProductRepository) {
+			stale := fixtures[0]
+			stale.Version = 3
+
+			assert.ErrorIs(t, repo.Update(base, "base-soda", stale), canonical.ErrorVersionMismatch)
+		},
+		"Touch must increment the version and move updated_at of a visible product": func(t *testing.T, repo ProductRepository) {
+			touched := created.Add(5 * time.Hour)
+			assert.NoError(t, repo.Touch(store1, "base-soda", touched))
+
+			product, err := repo.GetByID(base, "base-soda")
+			assert.NoError(t, err)
+			assert.Equal(t, "Soda", product.Name)
+			assert.Equal(t, touched, product.UpdatedAt)
+			assert.Equal(t, int64(1), product.Version)
+
+			products, err := repo.Find(base, canonical.ProductFilter{UpdatedSince: touched})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"base-soda"}, ids(products))
+		},
+		"Touch must fail with not found for other store products": func(t *testing.T, repo ProductRepository) {
+			assert.ErrorIs(t, repo.Touch(store1, "store-2-water", created), canonical.ErrorNotFound)
+			assert.ErrorIs(t, repo.Touch(base, "unknown", created), canonical.ErrorNotFound)
+		},
+		"Update must fail with not found outside the own store": func(t *testing.T, repo ProductRepository) {
+			assert.ErrorIs(t, repo.Update(store1, "base-soda", fixtures[0]), canonical.ErrorNotFound)
+			assert.ErrorIs(t, repo.Update(store1, "store-2-water", fixtures[4]), canonical.ErrorNotFound)
+			assert.ErrorIs(t, repo.Update(base, "unknown", canonical.Product{}), canonical.ErrorNotFound)
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, seeded(t))
+		})
+	}
+}
+
+func ids(products []canonical.Product) []string {
+	result := []string{}
+	for _, product := range products {
+		result = append(result, product.ID)
+	}
+	return result
+}
+
+// errWatchDone stops the watches of the conformance suite once they saw
+// the changes they wait for.
+var errWatchDone = errors.New("done watching")
+
+// testProductWatcher checks the changes every ProductWatcher reports for
+// the writes of the ProductRepository it is built with. newRepos must
+// return empty repositories.
+func testProductWatcher(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store1 := tenant.WithStore(context.Background(), "store-1")
+
+	tests := map[string]func(t *testing.T, repos Repositories){
+		"Watch must report the matching changes after the resume token": func(t *testing.T, repos Repositories) {
+			token := watchToken(t, repos)
+
+			for _, product := range []canonical.Product{
+				{ID: "tea", StoreID: "store-1", Category: "drinks"},
+				{ID: "water", StoreID: "store-2", Category: "drinks"},
+				{ID: "burger", Category: "food"},
+				{ID: "soda", Category: "drinks"},
+			} {
+				product := product
+				product.CreatedAt, product.UpdatedAt = created, created
+				_, err := repos.Products.Create(tenant.WithStore(context.Background(), product.StoreID), &product)
+				require.NoError(t, err)
+			}
+			soda, err := repos.Products.GetByID(context.Background(), "soda")
+			require.NoError(t, err)
+			soda.Status = canonical.STATUS_INACTIVE
+			require.NoError(t, repos.Products.Update(context.Background(), "soda", *soda))
+
+			filter := canonical.ChangeFilter{Category: "drinks", IDs: []string{"tea", "water", "burger", "soda"}}
+			changes := watchChanges(t, repos.Watcher, store1, filter, token, 3)
+			assert.Equal(t, []string{"ProductCreated tea", "ProductCreated soda", "ProductRemoved soda"}, describeChanges(changes))
+			assert.Equal(t, "store-1", changes[0].Product.StoreID)
+			assert.Equal(t, created, changes[0].OccurredAt)
+
+			resumed := watchChanges(t, repos.Watcher, store1, filter, changes[1].Token, 1)
+			assert.Equal(t, []string{"ProductRemoved soda"}, describeChanges(resumed))
+		},
+		"Watch must report touched products as updated": func(t *testing.T, repos Repositories) {
+			_, err := repos.Products.Create(context.Background(), &canonical.Product{ID: "soda", CreatedAt: created, UpdatedAt: created})
+			require.NoError(t, err)
+			token := watchToken(t, repos)
+
+			touched := created.Add(time.Hour)
+			require.NoError(t, repos.Products.Touch(store1, "soda", touched))
+
+			changes := watchChanges(t, repos.Watcher, store1, canonical.ChangeFilter{IDs: []string{"soda"}}, token, 1)
+			assert.Equal(t, []string{"ProductUpdated soda"}, describeChanges(changes))
+			assert.Equal(t, int64(1), changes[0].Product.Version)
+			assert.Equal(t, touched, changes[0].OccurredAt)
+		},
+		"Watch must stop without error once ctx is done": func(t *testing.T, repos Repositories) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := repos.Watcher.Watch(ctx, canonical.ChangeFilter{}, "", func(canonical.ProductChange) error {
+				return nil
+			})
+
+			assert.NoError(t, err)
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepos(t))
+		})
+	}
+}
+
+// testTransactor checks that the product, audit, outbox and price override
+// writes made through the Transactor commit or roll back together.
+// newRepos must return empty repositories.
+func testTransactor(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store1 := tenant.WithStore(context.Background(), "store-1")
+
+	write := func(ctx context.Context, repos Repositories) error {
+		product := canonical.Product{ID: "tea", StoreID: "store-1", Name: "Tea", CreatedAt: created, UpdatedAt: created}
+		if _, err := repos.Products.Create(ctx, &product); err != nil {
+			return err
+		}
+		// reads inside the transaction see its writes
+		if _, err := repos.Products.GetByID(ctx, "tea"); err != nil {
+			return err
+		}
+		entry := canonical.AuditEntry{ID: "entry", EntityID: "tea", StoreID: "store-1", Action: canonical.AUDIT_CREATE, Timestamp: created}
+		if err := repos.Audit.Create(ctx, entry); err != nil {
+			return err
+		}
+		if err := repos.Outbox.Add(ctx, canonical.NewEvent(canonical.EVENT_PRODUCT_CREATED, product, nil)); err != nil {
+			return err
+		}
+		return repos.Prices.Set(ctx, canonical.PriceOverride{ProductID: "soda", Price: 4, UpdatedAt: created})
+	}
+	stored := func(t *testing.T, repos Repositories) []string {
+		var found []string
+		if _, err := repos.Products.GetByID(store1, "tea"); err == nil {
+			found = append(found, "product")
+		}
+		entries, err := repos.Audit.GetByEntity(store1, "tea")
+		require.NoError(t, err)
+		if len(entries) > 0 {
+			found = append(found, "audit")
+		}
+		messages, err := repos.Outbox.Pending(store1, created, 10)
+		require.NoError(t, err)
+		if len(messages) > 0 {
+			found = append(found, "outbox")
+		}
+		if _, err := repos.Prices.Get(store1, "soda"); err == nil {
+			found = append(found, "price override")
+		}
+		return found
+	}
+
+	tests := map[string]func(t *testing.T, repos Repositories){
+		"WithTransaction must commit every write once fn succeeds": func(t *testing.T, repos Repositories) {
+			err := repos.Transactor.WithTransaction(store1, func(ctx context.Context) error {
+				return write(ctx, repos)
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"product", "audit", "outbox", "price override"}, stored(t, repos))
+		},
+		"WithTransaction must roll back every write when fn fails": func(t *testing.T, repos Repositories) {
+			failure := errors.New("failure")
+
+			err := repos.Transactor.WithTransaction(store1, func(ctx context.Context) error {
+				require.NoError(t, write(ctx, repos))
+				return failure
+			})
+
+			assert.ErrorIs(t, err, failure)
+			assert.Empty(t, stored(t, repos))
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepos(t))
+		})
+	}
+}
+
+// watchToken starts watching from now, and creates probe products until
+// the watcher reports one. It returns the token of that change, so the
+// changes written afterwards are reported when resuming from it.
+func watchToken(t *testing.T, repos Repositories) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tokens := make(chan string, 1)
+	go repos.Watcher.Watch(ctx, canonical.ChangeFilter{}, "", func(change canonical.ProductChange) error {
+		tokens <- change.Token
+		return errWatchDone
+	})
+
+	for i := 0; ; i++ {
+		_, err := repos.Products.Create(context.Background(), &canonical.Product{ID: fmt.Sprintf("probe-%d", i)})
+		require.NoError(t, err)
+
+		select {
+		case token := <-tokens:
+			return token
+		case <-ctx.Done():
+			require.FailNow(t, "the watcher reported no change")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// watchChanges resumes watching after token until count changes were
+// reported.
+func watchChanges(t *testing.T, watcher ProductWatcher, ctx context.Context, filter canonical.ChangeFilter, token string, count int) []canonical.ProductChange {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var changes []canonical.ProductChange
+	err := watcher.Watch(ctx, filter, token, func(change canonical.ProductChange) error {
+		changes = append(changes, change)
+		if len(changes) == count {
+			return errWatchDone
+		}
+		return nil
+	})
+
+	require.ErrorIs(t, err, errWatchDone)
+	return changes
+}
+
+func describeChanges(changes []canonical.ProductChange) []string {
+	result := []string{}
+	for _, change := range changes {
+		result = append(result, fmt.Sprintf("%s %s", change.Type, change.ProductID))
+	}
+	return result
+}
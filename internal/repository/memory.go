@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"time"
+)
+
+// memoryProductRepository keeps products in process, with the same
+// semantics as the Mongo repository. Products are returned in the order
+// they were created unless a sort is requested. Every write is recorded in
+// changes for the memory watcher.
+type memoryProductRepository struct {
+	mu       sync.RWMutex
+	products map[string]canonical.Product
+	order    []string
+	changes  *memoryChangeLog
+}
+
+func NewMemoryProductRepo() ProductRepository {
+	return newMemoryProductRepo()
+}
+
+func newMemoryProductRepo() *memoryProductRepository {
+	return &memoryProductRepository{
+		products: map[string]canonical.Product{},
+		changes:  newMemoryChangeLog(),
+	}
+}
+
+func (r *memoryProductRepository) GetAll(ctx context.Context) ([]canonical.Product, error) {
+	return r.filter(func(product canonical.Product) bool {
+		return product.Status == canonical.STATUS_ACTIVE && visibleFrom(ctx, product)
+	}), nil
+}
+
+func (r *memoryProductRepository) GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error) {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	return r.filter(func(product canonical.Product) bool {
+		return wanted[product.ID] && visibleFrom(ctx, product)
+	}), nil
+}
+
+func (r *memoryProductRepository) Create(ctx context.Context, product *canonical.Product) (*canonical.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[product.ID]; ok {
+		return nil, fmt.Errorf("product %q already exists", product.ID)
+	}
+
+	r.products[product.ID] = stored(*product)
+	r.order = append(r.order, product.ID)
+	r.changes.append(canonical.EVENT_PRODUCT_CREATED, r.products[product.ID])
+	return product, nil
+}
+
+// Update replaces the product only if its stored version still matches
+// product.Version, incrementing the version in the same operation. Only
+// products of the store ctx is scoped to can be updated.
+func (r *memoryProductRepository) Update(ctx context.Context, id string, product canonical.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.products[id]
+	if !ok || current.StoreID != tenant.Store(ctx) {
+		return canonical.ErrorNotFound
+	}
+	if current.Version != product.Version {
+		return canonical.ErrorVersionMismatch
+	}
+
+	// the identity, ownership and creation fields are never replaced
+	updated := stored(product)
+	updated.ID = current.ID
+	updated.StoreID = current.StoreID
+	updated.Version = current.Version + 1
+	updated.CreatedAt = current.CreatedAt
+	updated.CreatedBy = current.CreatedBy
+
+	r.products[id] = updated
+
+	// products are removed by deactivating them
+	eventType := canonical.EVENT_PRODUCT_UPDATED
+	if updated.Status == canonical.STATUS_INACTIVE && current.Status != canonical.STATUS_INACTIVE {
+		eventType = canonical.EVENT_PRODUCT_REMOVED
+	}
+	r.changes.append(eventType, updated)
+	return nil
+}
+
+func (r *memoryProductRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || !visibleFrom(ctx, product) {
+		return canonical.ErrorNotFound
+	}
+
+	product.UpdatedAt = at
+	product.Version++
+	r.products[id] = stored(product)
+
+	r.changes.append(canonical.EVENT_PRODUCT_UPDATED, r.products[id])
+	return nil
+}
+
+func (r *memoryProductRepository) GetByID(ctx context.Context, id string) (*canonical.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	product, ok := r.products[id]
+	if !ok || !visibleFrom(ctx, product) {
+		return nil, canonical.ErrorNotFound
+	}
+	return &product, nil
+}
+
+func (r *memoryProductRepository) GetByCategory(ctx context.Context, category string) ([]canonical.Product, error) {
+	return r.filter(func(product canonical.Product) bool {
+		return product.Category == category &&
+			product.Status == canonical.STATUS_ACTIVE &&
+			visibleFrom(ctx, product)
+	}), nil
+}
+
+func (r *memoryProductRepository) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	products := r.filter(func(product canonical.Product) bool {
+		if !visibleFrom(ctx, product) {
+			return false
+		}
+		if filter.Category != "" && product.Category != filter.Category {
+			return false
+		}
+		if filter.UpdatedSince.IsZero() {
+			return product.Status == canonical.STATUS_ACTIVE
+		}
+		return !product.UpdatedAt.Before(filter.UpdatedSince)
+	})
+
+	if filter.SortBy != "" {
+		sort.SliceStable(products, func(i, j int) bool {
+			if c := compareBy(filter.SortBy, products[i], products[j]); c != 0 {
+				return (c < 0) != filter.Descending
+			}
+			return products[i].ID < products[j].ID
+		})
+	}
+
+	return products, nil
+}
+
+func (r *memoryProductRepository) filter(match func(canonical.Product) bool) []canonical.Product {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var products []canonical.Product
+	for _, id := range r.order {
+		if product := r.products[id]; match(product) {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// visibleFrom reports whether product is readable from the store ctx is
+// scoped to: its own products and the base catalog.
+func visibleFrom(ctx context.Context, product canonical.Product) bool {
+	return product.StoreID == "" || product.StoreID == tenant.Store(ctx)
+}
+
+// ownedBy reports whether a record of store is writable from the store ctx
+// is scoped to.
+func ownedBy(ctx context.Context, store string) bool {
+	return store == tenant.Store(ctx)
+}
+
+// stored rounds the timestamps of product the way Mongo stores them, so
+// both repositories return the same values.
+func stored(product canonical.Product) canonical.Product {
+	product.CreatedAt = product.CreatedAt.Truncate(time.Millisecond).UTC()
+	product.UpdatedAt = product.UpdatedAt.Truncate(time.Millisecond).UTC()
+	return product
+}
+
+// compareBy compares a and b on one of the canonical product sort keys.
+func compareBy(key string, a, b canonical.Product) int {
+	switch key {
+	case "name":
+		return cmp.Compare(a.Name, b.Name)
+	case "price":
+		return cmp.Compare(a.Price, b.Price)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryProductRepository_Concurrent(t *testing.T) {
+	repo := NewMemoryProductRepo()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("product-%d", i)
+			_, err := repo.Create(ctx, &canonical.Product{ID: id})
+			assert.NoError(t, err)
+			assert.NoError(t, repo.Update(ctx, id, canonical.Product{Name: "updated"}))
+			_, err = repo.GetAll(ctx)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	products, err := repo.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, products, 50)
+}
+
+func TestMemoryRepositories_ScopedToStore(t *testing.T) {
+	repos := NewMemoryRepositories()
+	base := context.Background()
+	store1 := tenant.WithStore(base, "store-1")
+	store2 := tenant.WithStore(base, "store-2")
+
+	require.NoError(t, repos.Webhooks.Create(store1, canonical.Webhook{ID: "hook-1", StoreID: "store-1"}))
+	require.NoError(t, repos.APIKeys.Create(store1, canonical.APIKey{ID: "key-1", StoreID: "store-1", Hash: "hash-1"}))
+	require.NoError(t, repos.Audit.Create(store1, canonical.AuditEntry{ID: "entry-1", EntityID: "tea", StoreID: "store-1"}))
+	require.NoError(t, repos.Prices.Set(store1, canonical.PriceOverride{ProductID: "soda", Price: 4}))
+
+	webhooks, err := repos.Webhooks.GetAll(store2)
+	assert.NoError(t, err)
+	assert.Empty(t, webhooks)
+	assert.ErrorIs(t, repos.Webhooks.Remove(store2, "hook-1"), canonical.ErrorNotFound)
+	webhooks, err = repos.Webhooks.GetByEvent(base, canonical.EVENT_PRODUCT_CREATED, "store-1")
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+
+	keys, err := repos.APIKeys.GetAll(base)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.ErrorIs(t, repos.APIKeys.Revoke(store2, "key-1", time.Now()), canonical.ErrorNotFound)
+	key, err := repos.APIKeys.GetByHash(base, "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", key.ID)
+
+	entries, err := repos.Audit.GetByEntity(store2, "tea")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = repos.Prices.Get(store2, "soda")
+	assert.ErrorIs(t, err, canonical.ErrorNotFound)
+	assert.ErrorIs(t, repos.Prices.Set(base, canonical.PriceOverride{ProductID: "soda"}), canonical.ErrorStoreRequired)
+	overrides, err := repos.Prices.GetByProducts(store1, []string{"soda", "tea"})
+	assert.NoError(t, err)
+	assert.Equal(t, []canonical.PriceOverride{{ID: "store-1:soda", StoreID: "store-1", ProductID: "soda", Price: 4}}, overrides)
+}
+
+func TestMemoryOutboxRepository(t *testing.T) {
+	repo := newMemoryOutboxRepo()
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Add(ctx,
+		canonical.Event{ID: "second", OccurredAt: now.Add(-time.Minute)},
+		canonical.Event{ID: "first", OccurredAt: now.Add(-time.Hour)},
+		canonical.Event{ID: "later", OccurredAt: now.Add(time.Hour)},
+	))
+
+	pending, err := repo.Pending(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, []string{pending[0].ID, pending[1].ID})
+
+	require.NoError(t, repo.MarkDispatched(ctx, "first", now))
+	require.NoError(t, repo.MarkFailed(ctx, "second", "unreachable", now.Add(time.Minute)))
+
+	pending, err = repo.Pending(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	pending, err = repo.Pending(ctx, now.Add(time.Minute), 1)
+	assert.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "second", pending[0].ID)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "unreachable", pending[0].LastError)
+}
+
+func TestMemoryDeliveryRepository_CreateSkipsExisting(t *testing.T) {
+	repo := newMemoryDeliveryRepo()
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	delivery := canonical.WebhookDelivery{ID: "delivery-1", WebhookID: "hook-1", Status: canonical.DELIVERY_PENDING, NextAttemptAt: now, CreatedAt: now}
+	require.NoError(t, repo.Create(ctx, delivery))
+	require.NoError(t, repo.RecordAttempt(ctx, "delivery-1", canonical.DeliveryAttempt{StatusCode: 500}, canonical.DELIVERY_PENDING, now.Add(time.Minute)))
+	require.NoError(t, repo.Create(ctx, delivery))
+
+	deliveries, err := repo.GetByWebhook(ctx, "hook-1")
+	assert.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Len(t, deliveries[0].Attempts, 1)
+
+	pending, err := repo.Pending(ctx, now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMemoryStatsRepository_ActiveByCategory(t *testing.T) {
+	repos := NewMemoryRepositories()
+	for _, product := range []canonical.Product{
+		{ID: "soda", Category: "drinks"},
+		{ID: "juice", Category: "drinks"},
+		{ID: "burger", Category: "food", Status: canonical.STATUS_INACTIVE},
+		{ID: "tea", StoreID: "store-1", Category: "drinks"},
+	} {
+		product := product
+		_, err := repos.Products.Create(tenant.WithStore(context.Background(), product.StoreID), &product)
+		require.NoError(t, err)
+	}
+
+	counts, err := repos.Stats.ActiveByCategory(context.Background())
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []canonical.CategoryCount{
+		{Category: "drinks", Count: 2},
+		{StoreID: "store-1", Category: "drinks", Count: 1},
+	}, counts)
+}
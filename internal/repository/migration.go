@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"tech-challenge-product/internal/logging"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationCollection = "migration"
+
+	// skippedReportLimit caps the documents a data migration reports as
+	// skipped.
+	skippedReportLimit = 100
+)
+
+// Migration changes the database from the previous version to Version. Up
+// must be safe to run again, since instances starting together may both
+// apply it before either records it.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// AppliedMigration records a migration applied to the database.
+type AppliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+type Migrator interface {
+	// Pending lists the migrations not applied yet, in version order.
+	Pending(ctx context.Context) ([]Migration, error)
+	// Migrate applies the pending migrations in version order and returns
+	// them. It stops at the first failing one.
+	Migrate(ctx context.Context) ([]Migration, error)
+}
+
+type migrator struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+}
+
+func NewMigrator(db *mongo.Database) Migrator {
+	return newMigrator(db, migrations)
+}
+
+func newMigrator(db *mongo.Database, migrations []Migration) *migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &migrator{
+		db:         db,
+		collection: db.Collection(migrationCollection),
+		migrations: sorted,
+	}
+}
+
+func (m *migrator) Pending(ctx context.Context) ([]Migration, error) {
+	cursor, err := m.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []AppliedMigration
+	if err = cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+
+	done := map[int]bool{}
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *migrator) Migrate(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range pending {
+		logging.From(ctx).Info().
+			Int("version", migration.Version).
+			Str("description", migration.Description).
+			Msg("applying migration")
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		record := AppliedMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		_, err := m.collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: record.Version}}, record, options.Replace().SetUpsert(true))
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// migrations are the database versions, oldest first. Never change one
+// that was released, add a new version instead.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "index products by category, status, store and text",
+		Up: createIndexes(productCollection,
+			mongo.IndexModel{Keys: bson.D{{Key: "category", Value: 1}, {Key: "status", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "store_id", Value: 1}, {Key: "status", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "updated_at", Value: 1}}},
+			mongo.IndexModel{Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}}},
+		),
+	},
+	{
+		Version:     2,
+		Description: "index the lookups of api keys, price overrides, audit, outbox and deliveries",
+		Up: migrateAll(
+			createIndexes(apiKeyCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			),
+			createIndexes(priceOverrideCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "store_id", Value: 1}, {Key: "product_id", Value: 1}}},
+			),
+			createIndexes(auditCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "entity_id", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "store_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+			),
+			createIndexes(outboxCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "dispatched_at", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+			),
+			createIndexes(deliveryCollection,
+				mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+				mongo.IndexModel{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			),
+		),
+	},
+	{
+		Version:     3,
+		Description: "store product and override prices as doubles rounded to cents",
+		Up: migrateAll(
+			roundPrices(productCollection),
+			roundPrices(priceOverrideCollection),
+		),
+	},
+}
+
+func createIndexes(collection string, indexes ...mongo.IndexModel) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
+
+// roundPrices converts the prices written as strings or integers by older
+// clients to doubles, dropping the float noise below a cent. Prices that
+// cannot be converted are left as they are and reported, so one malformed
+// document does not keep the service from starting.
+func roundPrices(collection string) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(ctx,
+			bson.D{{Key: "price", Value: bson.D{{Key: "$exists", Value: true}, {Key: "$ne", Value: nil}}}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "price", Value: bson.D{{Key: "$let", Value: bson.D{
+				{Key: "vars", Value: bson.D{{Key: "converted", Value: bson.D{{Key: "$convert", Value: bson.D{
+					{Key: "input", Value: "$price"},
+					{Key: "to", Value: "double"},
+					{Key: "onError", Value: nil},
+					{Key: "onNull", Value: nil},
+				}}}}}},
+				{Key: "in", Value: bson.D{{Key: "$cond", Value: bson.A{
+					bson.D{{Key: "$eq", Value: bson.A{"$$converted", nil}}},
+					"$price",
+					bson.D{{Key: "$round", Value: bson.A{"$$converted", 2}}},
+				}}}},
+			}}}}}}}},
+		)
+		if err != nil {
+			return err
+		}
+
+		cursor, err := db.Collection(collection).Find(ctx,
+			bson.D{{Key: "price", Value: bson.D{{Key: "$exists", Value: true}, {Key: "$not", Value: bson.D{{Key: "$type", Value: "number"}}}}}},
+			options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}).SetLimit(skippedReportLimit))
+		if err != nil {
+			return err
+		}
+		var skipped []struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err = cursor.All(ctx, &skipped); err != nil {
+			return err
+		}
+
+		for _, document := range skipped {
+			logging.From(ctx).Warn().
+				Str("collection", collection).
+				Interface("id", document.ID).
+				Msg("price could not be converted, fix it by hand")
+		}
+		return nil
+	}
+}
+
+func migrateAll(steps ...func(context.Context, *mongo.Database) error) func(context.Context, *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, step := range steps {
+			if err := step(ctx, db); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
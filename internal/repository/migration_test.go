@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMigrator_Migrate(t *testing.T) {
+	type Given struct {
+		applied  []bson.D
+		failing  int
+		recorded int
+	}
+	type Expected struct {
+		ran     []int
+		applied []int
+		err     assert.ErrorAssertionFunc
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given empty database must apply every migration in order": {
+			given:    Given{recorded: 3},
+			expected: Expected{ran: []int{1, 2, 3}, applied: []int{1, 2, 3}, err: assert.NoError},
+		},
+		"given applied versions must only apply the pending ones": {
+			given: Given{
+				applied:  []bson.D{{{Key: "_id", Value: 1}}, {{Key: "_id", Value: 2}}},
+				recorded: 1,
+			},
+			expected: Expected{ran: []int{3}, applied: []int{3}, err: assert.NoError},
+		},
+		"given failing migration must stop without recording it": {
+			given:    Given{failing: 2, recorded: 1},
+			expected: Expected{ran: []int{1, 2}, applied: []int{1}, err: assert.Error},
+		},
+	}
+
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for name, tc := range tests {
+		db.Run(name, func(mt *mtest.T) {
+			var ran []int
+			step := func(version int) Migration {
+				return Migration{
+					Version:     version,
+					Description: "step",
+					Up: func(ctx context.Context, db *mongo.Database) error {
+						ran = append(ran, version)
+						if version == tc.given.failing {
+							return errors.New("failed")
+						}
+						return nil
+					},
+				}
+			}
+			// declared out of order, they must still run by version
+			m := newMigrator(mt.DB, []Migration{step(3), step(1), step(2)})
+
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.migration", mtest.FirstBatch, tc.given.applied...))
+			for i := 0; i < tc.given.recorded; i++ {
+				mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+			}
+
+			applied, err := m.Migrate(context.Background())
+
+			tc.expected.err(t, err)
+			assert.Equal(t, tc.expected.ran, ran)
+			var versions []int
+			for _, migration := range applied {
+				versions = append(versions, migration.Version)
+			}
+			assert.Equal(t, tc.expected.applied, versions)
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	seen := map[int]bool{}
+	for i, migration := range migrations {
+		assert.False(t, seen[migration.Version], "version %d is declared twice", migration.Version)
+		seen[migration.Version] = true
+		if i > 0 {
+			assert.Greater(t, migration.Version, migrations[i-1].Version, "versions must be declared in order")
+		}
+		assert.NotEmpty(t, migration.Description)
+		assert.NotNil(t, migration.Up)
+	}
+}
+
+func TestMigrations_Up(t *testing.T) {
+	type Given struct {
+		version   int
+		responses func() []bson.D
+	}
+	type Expected struct {
+		commands []string
+		check    func(t *testing.T, started []bson.Raw)
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given product indexes migration must index category, status, store and text": {
+			given: Given{
+				version:   1,
+				responses: func() []bson.D { return []bson.D{mtest.CreateSuccessResponse()} },
+			},
+			expected: Expected{
+				commands: []string{"createIndexes:product"},
+				check: func(t *testing.T, started []bson.Raw) {
+					indexes := started[0].Lookup("indexes").String()
+					assert.Contains(t, indexes, `{"category": {"$numberInt":"1"},"status": {"$numberInt":"1"}}`)
+					assert.Contains(t, indexes, `{"store_id": {"$numberInt":"1"},"status": {"$numberInt":"1"}}`)
+					assert.Contains(t, indexes, `{"name": "text","description": "text"}`)
+				},
+			},
+		},
+		"given lookup indexes migration must index every looked up collection": {
+			given: Given{
+				version: 2,
+				responses: func() []bson.D {
+					return []bson.D{
+						mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(),
+						mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(),
+					}
+				},
+			},
+			expected: Expected{
+				commands: []string{
+					"createIndexes:api_key", "createIndexes:price_override", "createIndexes:audit",
+					"createIndexes:outbox", "createIndexes:webhook_delivery",
+				},
+				check: func(t *testing.T, started []bson.Raw) {
+					assert.Contains(t, started[0].Lookup("indexes").String(), `"unique": true`)
+				},
+			},
+		},
+		"given price migration must convert without failing on malformed prices": {
+			given: Given{
+				version: 3,
+				responses: func() []bson.D {
+					return []bson.D{
+						mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+						mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch, bson.D{{Key: "_id", Value: "malformed"}}),
+						mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+						mtest.CreateCursorResponse(0, "product.price_override", mtest.FirstBatch),
+					}
+				},
+			},
+			expected: Expected{
+				commands: []string{"update:product", "find:product", "update:price_override", "find:price_override"},
+				check: func(t *testing.T, started []bson.Raw) {
+					update := started[0].Lookup("updates").String()
+					assert.Contains(t, update, `"$convert"`)
+					assert.Contains(t, update, `"onError": null`)
+					assert.Contains(t, update, `"$round"`)
+				},
+			},
+		},
+	}
+
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for name, tc := range tests {
+		db.Run(name, func(mt *mtest.T) {
+			var migration Migration
+			for _, m := range migrations {
+				if m.Version == tc.given.version {
+					migration = m
+				}
+			}
+			mt.AddMockResponses(tc.given.responses()...)
+
+			err := migration.Up(context.Background(), mt.DB)
+
+			assert.NoError(t, err)
+			var commands []string
+			var started []bson.Raw
+			for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+				collection, _ := event.Command.Lookup(event.CommandName).StringValueOK()
+				commands = append(commands, event.CommandName+":"+collection)
+				started = append(started, event.Command)
+			}
+			assert.Equal(t, tc.expected.commands, commands)
+			tc.expected.check(t, started)
+		})
+	}
+}
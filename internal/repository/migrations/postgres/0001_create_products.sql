@@ -0,0 +1,21 @@
+-- Products of the base catalog have an empty store_id. Names use the C
+-- collation so sorting by name matches MongoDB, which compares bytes.
+CREATE TABLE products (
+    id          text PRIMARY KEY,
+    store_id    text NOT NULL DEFAULT '',
+    name        text COLLATE "C" NOT NULL DEFAULT '',
+    description text NOT NULL DEFAULT '',
+    price       double precision NOT NULL DEFAULT 0,
+    category    text NOT NULL DEFAULT '',
+    status      integer NOT NULL DEFAULT 0,
+    image_path  text NOT NULL DEFAULT '',
+    version     bigint NOT NULL DEFAULT 0,
+    created_at  timestamptz NOT NULL,
+    updated_at  timestamptz NOT NULL,
+    created_by  text NOT NULL DEFAULT '',
+    updated_by  text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX products_category_status ON products (category, status);
+CREATE INDEX products_store_id ON products (store_id);
+CREATE INDEX products_updated_at ON products (updated_at);
@@ -0,0 +1,41 @@
+-- The tables written in the same transaction as the products. Field
+-- changes, and the product carried by an event, are stored as JSON.
+CREATE TABLE audit_entries (
+    id        text PRIMARY KEY,
+    entity_id text NOT NULL,
+    store_id  text NOT NULL DEFAULT '',
+    action    text NOT NULL,
+    actor     text NOT NULL DEFAULT '',
+    logged_at timestamptz NOT NULL,
+    changes   jsonb
+);
+
+CREATE INDEX audit_entries_entity ON audit_entries (store_id, entity_id, logged_at DESC);
+CREATE INDEX audit_entries_logged_at ON audit_entries (store_id, logged_at DESC);
+
+CREATE TABLE outbox (
+    id              text PRIMARY KEY,
+    type            text NOT NULL,
+    aggregate_id    text NOT NULL,
+    store_id        text NOT NULL DEFAULT '',
+    occurred_at     timestamptz NOT NULL,
+    product         jsonb NOT NULL,
+    changes         jsonb,
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    dispatched_at   timestamptz,
+    last_error      text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX outbox_pending ON outbox (next_attempt_at) WHERE dispatched_at IS NULL;
+
+CREATE TABLE price_overrides (
+    id         text PRIMARY KEY,
+    store_id   text NOT NULL,
+    product_id text NOT NULL,
+    price      double precision NOT NULL,
+    updated_at timestamptz NOT NULL,
+    updated_by text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX price_overrides_store_product ON price_overrides (store_id, product_id);
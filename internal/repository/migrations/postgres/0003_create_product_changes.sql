@@ -0,0 +1,36 @@
+-- Every product write is numbered in product_changes, which the product
+-- watcher follows. The writers take the same transaction lock before
+-- numbering their change, so changes commit in change_id order and a
+-- watcher never skips one committed late. Changes are kept 7 days.
+CREATE TABLE product_changes (
+    change_id   bigserial PRIMARY KEY,
+    product_id  text NOT NULL,
+    operation   text NOT NULL,
+    removed     boolean NOT NULL,
+    occurred_at timestamptz NOT NULL,
+    recorded_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX product_changes_recorded_at ON product_changes (recorded_at);
+
+CREATE FUNCTION record_product_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(4802);
+
+    DELETE FROM product_changes WHERE recorded_at < now() - interval '7 days';
+    INSERT INTO product_changes (product_id, operation, removed, occurred_at)
+    VALUES (
+        NEW.id,
+        TG_OP,
+        -- products are removed by deactivating them
+        CASE WHEN TG_OP = 'UPDATE' THEN OLD.status = 0 AND NEW.status <> 0 ELSE false END,
+        NEW.updated_at
+    );
+    PERFORM pg_notify('product_changes', '');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_record_change
+    AFTER INSERT OR UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION record_product_change();
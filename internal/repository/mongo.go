@@ -3,25 +3,122 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/metrics"
+	"time"
 
-	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 var (
-	cfg           = &config.Cfg
-	ErrorNotFound = errors.New("entity not found")
-	database      = "product"
+	ErrorNotFound   = errors.New("entity not found")
+	defaultDatabase = "product"
 )
 
-func NewMongo() *mongo.Database {
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.DB.ConnectionString))
+// NewMongo connects to MongoDB and pings it with the configured read
+// preference, so a server that cannot be reached fails the startup instead
+// of the first requests. Every repository must share the same client so
+// they can take part in the same transaction. The commands are recorded
+// with recorder.
+func NewMongo(ctx context.Context, settings config.DB, recorder *metrics.Recorder) (*mongo.Client, error) {
+	clientOptions, err := mongoOptions(settings, recorder)
 	if err != nil {
-		log.Fatal().Err(err).Msg("an error occurred when try to connect to mongo")
+		return nil, err
 	}
 
-	return client.Database(database)
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	ctx, cancel := withDeadline(ctx, settings.ServerSelectionTimeout+settings.ConnectTimeout)
+	defer cancel()
+	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("mongo: cannot reach %v: %w", clientOptions.Hosts, err)
+	}
+
+	return client, nil
+}
+
+// Database is the service database on client.
+func Database(client *mongo.Client, settings config.DB) *mongo.Database {
+	if settings.Database == "" {
+		return client.Database(defaultDatabase)
+	}
+	return client.Database(settings.Database)
+}
+
+func mongoOptions(settings config.DB, recorder *metrics.Recorder) (*options.ClientOptions, error) {
+	clientOptions := options.Client().
+		SetMonitor(chainMonitors(commandMonitor(recorder), otelmongo.NewMonitor())).
+		SetMinPoolSize(settings.MinPoolSize)
+
+	// zero keeps the driver defaults, the driver would read it as unbounded
+	if settings.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(settings.MaxPoolSize)
+	}
+	if settings.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(settings.ConnectTimeout)
+	}
+	if settings.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(settings.ServerSelectionTimeout)
+	}
+	if settings.SocketTimeout > 0 {
+		clientOptions.SetSocketTimeout(settings.SocketTimeout)
+	}
+
+	if settings.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(settings.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("mongo: invalid read preference %q", settings.ReadPreference)
+		}
+		preference, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetReadPreference(preference)
+	}
+
+	if settings.WriteConcern != "" {
+		concern, err := writeConcern(settings.WriteConcern)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetWriteConcern(concern)
+	}
+
+	// options given in the connection string take precedence
+	clientOptions.ApplyURI(settings.ConnectionString)
+
+	return clientOptions, clientOptions.Validate()
+}
+
+// writeConcern parses majority or the number of nodes that must
+// acknowledge a write.
+func writeConcern(value string) (*writeconcern.WriteConcern, error) {
+	if value == "majority" {
+		return writeconcern.Majority(), nil
+	}
+	nodes, err := strconv.Atoi(value)
+	if err != nil || nodes < 0 {
+		return nil, fmt.Errorf("mongo: invalid write concern %q, want majority or a number of nodes", value)
+	}
+	return &writeconcern.WriteConcern{W: nodes}, nil
+}
+
+// withDeadline bounds one repository operation by timeout, unless ctx ends
+// sooner. A zero timeout leaves ctx as it is.
+func withDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/metrics"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestMongoOptions(t *testing.T) {
+	type Given struct {
+		settings config.DB
+	}
+	type Expected struct {
+		err            assert.ErrorAssertionFunc
+		maxPoolSize    uint64
+		socketTimeout  time.Duration
+		readPreference readpref.Mode
+		writeConcern   interface{}
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given settings must configure the client": {
+			given: Given{settings: config.DB{
+				ConnectionString: "mongodb://localhost:27017",
+				MaxPoolSize:      20,
+				SocketTimeout:    time.Second,
+				ReadPreference:   "secondaryPreferred",
+				WriteConcern:     "2",
+			}},
+			expected: Expected{err: assert.NoError, maxPoolSize: 20, socketTimeout: time.Second, readPreference: readpref.SecondaryPreferredMode, writeConcern: 2},
+		},
+		"given connection string options must override the settings": {
+			given: Given{settings: config.DB{
+				ConnectionString: "mongodb://localhost:27017/?maxPoolSize=5&readPreference=nearest&w=majority",
+				MaxPoolSize:      20,
+				ReadPreference:   "primary",
+				WriteConcern:     "1",
+			}},
+			expected: Expected{err: assert.NoError, maxPoolSize: 5, readPreference: readpref.NearestMode, writeConcern: "majority"},
+		},
+		"given invalid read preference must fail": {
+			given:    Given{settings: config.DB{ConnectionString: "mongodb://localhost:27017", ReadPreference: "fastest"}},
+			expected: Expected{err: assert.Error},
+		},
+		"given invalid write concern must fail": {
+			given:    Given{settings: config.DB{ConnectionString: "mongodb://localhost:27017", WriteConcern: "all"}},
+			expected: Expected{err: assert.Error},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			clientOptions, err := mongoOptions(tc.given.settings, metrics.NewRecorder(metrics.NewRegistry()))
+
+			tc.expected.err(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.expected.maxPoolSize, *clientOptions.MaxPoolSize)
+			if tc.expected.socketTimeout > 0 {
+				assert.Equal(t, tc.expected.socketTimeout, *clientOptions.SocketTimeout)
+			}
+			assert.Equal(t, tc.expected.readPreference, clientOptions.ReadPreference.Mode())
+			assert.Equal(t, tc.expected.writeConcern, clientOptions.WriteConcern.W)
+		})
+	}
+}
+
+func TestWriteConcern(t *testing.T) {
+	concern, err := writeConcern("majority")
+	assert.NoError(t, err)
+	assert.Equal(t, writeconcern.Majority(), concern)
+
+	concern, err = writeConcern("0")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, concern.W)
+
+	_, err = writeConcern("-1")
+	assert.Error(t, err)
+}
+
+func TestWithDeadline(t *testing.T) {
+	ctx, cancel := withDeadline(context.Background(), 0)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok, "zero timeout must not set a deadline")
+
+	ctx, cancel = withDeadline(context.Background(), time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	sooner, cancelSooner := context.WithTimeout(context.Background(), time.Second)
+	defer cancelSooner()
+	ctx, cancel = withDeadline(sooner, time.Minute)
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, time.Second, "a sooner deadline must be kept")
+}
+
+func TestProductRepository_OperationTimeout(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("given expired operation timeout must fail", func(mt *mtest.T) {
+		repo := productRepository{collection: mt.DB.Collection("fake-collection"), timeout: time.Nanosecond}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch, bson.D{{Key: "_id", Value: "1"}}))
+
+		_, err := repo.GetAll(context.Background())
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
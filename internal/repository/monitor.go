@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"tech-challenge-product/internal/metrics"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// commandMonitor records the latency of every command sent to MongoDB with
+// recorder. The collection is only known when the command starts, so it is
+// kept by request ID until the command finishes.
+func commandMonitor(recorder *metrics.Recorder) *event.CommandMonitor {
+	var collections sync.Map
+
+	finished := func(e event.CommandFinishedEvent, failed bool) {
+		collection, _ := collections.LoadAndDelete(e.RequestID)
+		name, _ := collection.(string)
+		recorder.ObserveMongo(e.CommandName, name, failed, e.Duration)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			collections.Store(e.RequestID, commandCollection(e))
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.CommandFinishedEvent, false)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.CommandFinishedEvent, true)
+		},
+	}
+}
+
+// commandCollection reads the collection a command works on: the value of
+// the command itself (e.g. {find: "product"}) or, for getMore, its
+// collection field.
+func commandCollection(e *event.CommandStartedEvent) string {
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		return collection
+	}
+	if collection, ok := e.Command.Lookup("collection").StringValueOK(); ok {
+		return collection
+	}
+	return ""
+}
+
+// chainMonitors hands every command event to each of monitors in order, as
+// the driver only accepts one monitor.
+func chainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Started != nil {
+					monitor.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, monitor := range monitors {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestCommandCollection(t *testing.T) {
+	started := func(name string, command bson.D) *event.CommandStartedEvent {
+		raw, _ := bson.Marshal(command)
+		return &event.CommandStartedEvent{CommandName: name, Command: raw}
+	}
+
+	assert.Equal(t, "product", commandCollection(started("find", bson.D{{Key: "find", Value: "product"}})))
+	assert.Equal(t, "product", commandCollection(started("getMore", bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "product"}})))
+	assert.Equal(t, "", commandCollection(started("commitTransaction", bson.D{{Key: "commitTransaction", Value: 1}})))
+}
+
+func TestChainMonitors(t *testing.T) {
+	var calls []string
+	monitor := func(name string) *event.CommandMonitor {
+		return &event.CommandMonitor{
+			Started: func(context.Context, *event.CommandStartedEvent) {
+				calls = append(calls, name+" started")
+			},
+			Succeeded: func(context.Context, *event.CommandSucceededEvent) {
+				calls = append(calls, name+" succeeded")
+			},
+		}
+	}
+
+	chained := chainMonitors(monitor("metrics"), monitor("tracing"))
+	chained.Started(context.Background(), &event.CommandStartedEvent{})
+	chained.Succeeded(context.Background(), &event.CommandSucceededEvent{})
+	chained.Failed(context.Background(), &event.CommandFailedEvent{})
+
+	assert.Equal(t, []string{"metrics started", "tracing started", "metrics succeeded", "tracing succeeded"}, calls)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	outboxCollection = "outbox"
+)
+
+type OutboxRepository interface {
+	Add(ctx context.Context, events ...canonical.Event) error
+	Pending(ctx context.Context, now time.Time, limit int) ([]canonical.OutboxMessage, error)
+	MarkDispatched(ctx context.Context, id string, at time.Time) error
+	MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error
+}
+
+type outboxRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewOutboxRepo(db *mongo.Database, timeout time.Duration) OutboxRepository {
+	return &outboxRepository{
+		collection: db.Collection(outboxCollection),
+		timeout:    timeout,
+	}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, events ...canonical.Event) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		documents = append(documents, canonical.OutboxMessage{
+			Event:         event,
+			NextAttemptAt: event.OccurredAt,
+		})
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return err
+}
+
+// Pending returns undelivered messages due for an attempt, oldest first.
+func (r *outboxRepository) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.OutboxMessage, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "dispatched_at", Value: nil},
+		{Key: "next_attempt_at", Value: bson.M{"$lte": now}},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.OutboxMessage
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"dispatched_at": at},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"last_error": reason, "next_attempt_at": nextAttempt},
+		"$inc": bson.M{"attempts": 1},
+	})
+	return err
+}
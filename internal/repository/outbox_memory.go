@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"time"
+)
+
+type memoryOutboxRepository struct {
+	mu       sync.Mutex
+	messages map[string]*canonical.OutboxMessage
+	order    []string
+}
+
+func newMemoryOutboxRepo() *memoryOutboxRepository {
+	return &memoryOutboxRepository{
+		messages: map[string]*canonical.OutboxMessage{},
+	}
+}
+
+func (r *memoryOutboxRepository) Add(ctx context.Context, events ...canonical.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range events {
+		r.messages[event.ID] = &canonical.OutboxMessage{
+			Event:         event,
+			NextAttemptAt: event.OccurredAt,
+		}
+		r.order = append(r.order, event.ID)
+	}
+	return nil
+}
+
+// Pending returns undelivered messages due for an attempt, oldest first.
+func (r *memoryOutboxRepository) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var results []canonical.OutboxMessage
+	for _, id := range r.order {
+		message := r.messages[id]
+		if message.DispatchedAt == nil && !message.NextAttemptAt.After(now) {
+			results = append(results, *message)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].OccurredAt.Before(results[j].OccurredAt)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (r *memoryOutboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if message, ok := r.messages[id]; ok {
+		message.DispatchedAt = &at
+		message.Attempts++
+	}
+	return nil
+}
+
+func (r *memoryOutboxRepository) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if message, ok := r.messages[id]; ok {
+		message.LastError = reason
+		message.NextAttemptAt = nextAttempt
+		message.Attempts++
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const outboxColumns = `id, type, aggregate_id, store_id, occurred_at, product, changes,
+	attempts, next_attempt_at, dispatched_at, last_error`
+
+type postgresOutboxRepository struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+func NewPostgresOutboxRepo(pool *pgxpool.Pool, timeout time.Duration) OutboxRepository {
+	return &postgresOutboxRepository{
+		pool:    pool,
+		timeout: timeout,
+	}
+}
+
+func (r *postgresOutboxRepository) Add(ctx context.Context, events ...canonical.Event) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		batch.Queue(`INSERT INTO outbox (id, type, aggregate_id, store_id, occurred_at, product, changes, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $5)`,
+			event.ID, event.Type, event.AggregateID, event.StoreID, event.OccurredAt, event.Product, event.Changes)
+	}
+	return postgresConn(ctx, r.pool).SendBatch(ctx, batch).Close()
+}
+
+// Pending returns undelivered messages due for an attempt, oldest first.
+func (r *postgresOutboxRepository) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.OutboxMessage, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	rows, _ := postgresConn(ctx, r.pool).Query(ctx, `SELECT `+outboxColumns+` FROM outbox
+		WHERE dispatched_at IS NULL AND next_attempt_at <= $1
+		ORDER BY occurred_at
+		LIMIT $2`,
+		now, limit)
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (canonical.OutboxMessage, error) {
+		var message canonical.OutboxMessage
+		err := row.Scan(
+			&message.ID, &message.Type, &message.AggregateID, &message.StoreID, &message.OccurredAt,
+			&message.Product, &message.Changes,
+			&message.Attempts, &message.NextAttemptAt, &message.DispatchedAt, &message.LastError,
+		)
+		message.OccurredAt = message.OccurredAt.UTC()
+		message.NextAttemptAt = message.NextAttemptAt.UTC()
+		return message, err
+	})
+}
+
+func (r *postgresOutboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := postgresConn(ctx, r.pool).Exec(ctx,
+		"UPDATE outbox SET dispatched_at = $1, attempts = attempts + 1 WHERE id = $2", at, id)
+	return err
+}
+
+func (r *postgresOutboxRepository) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := postgresConn(ctx, r.pool).Exec(ctx,
+		"UPDATE outbox SET last_error = $1, next_attempt_at = $2, attempts = attempts + 1 WHERE id = $3", reason, nextAttempt, id)
+	return err
+}
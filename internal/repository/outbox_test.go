@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestOutboxRepository_Add(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := outboxRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := repo.Add(context.Background(), canonical.Event{
+			ID:          "event_valid_id",
+			Type:        canonical.EVENT_PRODUCT_CREATED,
+			AggregateID: "product_valid_id",
+		})
+
+		assert.Nil(t, err)
+
+		err = repo.Add(context.Background())
+
+		assert.Nil(t, err)
+	})
+}
+
+func TestOutboxRepository_Pending(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := outboxRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.outbox", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "event_valid_id"},
+			{Key: "type", Value: string(canonical.EVENT_PRODUCT_UPDATED)},
+			{Key: "aggregate_id", Value: "product_valid_id"},
+			{Key: "attempts", Value: 2},
+		}))
+
+		messages, err := repo.Pending(context.Background(), now, 10)
+
+		assert.Nil(t, err)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, "event_valid_id", messages[0].ID)
+		assert.Equal(t, 2, messages[0].Attempts)
+	})
+}
+
+func TestOutboxRepository_Mark(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := outboxRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		assert.Nil(t, repo.MarkDispatched(context.Background(), "event_valid_id", time.Now()))
+		assert.Nil(t, repo.MarkFailed(context.Background(), "event_valid_id", "timeout", time.Now()))
+	})
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"tech-challenge-product/internal/config"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresMigrationLock keeps instances starting together from applying the
+// same migrations.
+const postgresMigrationLock = 4801
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// NewPostgres connects to PostgreSQL and applies the pending migrations.
+func NewPostgres(ctx context.Context, settings config.Postgres) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(settings.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	if settings.MinConns > 0 {
+		poolConfig.MinConns = int32(settings.MinConns)
+	}
+	if settings.MaxConns > 0 {
+		poolConfig.MaxConns = int32(settings.MaxConns)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migratePostgres(ctx, pool); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("postgres migrations: %w", err)
+	}
+	return pool, nil
+}
+
+// migratePostgres applies, in name order, the migrations not recorded in
+// schema_migrations yet. They are applied in one transaction, so a failed
+// migration leaves the schema untouched.
+func migratePostgres(ctx context.Context, pool *pgxpool.Pool) error {
+	names, err := fs.Glob(postgresMigrations, "migrations/postgres/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", postgresMigrationLock); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    text PRIMARY KEY,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`)
+		if err != nil {
+			return err
+		}
+
+		rows, _ := tx.Query(ctx, "SELECT version FROM schema_migrations")
+		applied, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+		done := map[string]bool{}
+		for _, version := range applied {
+			done[version] = true
+		}
+
+		for _, name := range names {
+			version := strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".sql")
+			if done[version] {
+				continue
+			}
+
+			script, err := postgresMigrations.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			if _, err = tx.Exec(ctx, string(script)); err != nil {
+				return fmt.Errorf("%s: %w", version, err)
+			}
+			if _, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
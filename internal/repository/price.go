@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	priceOverrideCollection = "price_override"
+)
+
+// PriceOverrideRepository stores the prices a store sets on base catalog
+// products. Every operation works on the store ctx is scoped to.
+type PriceOverrideRepository interface {
+	Set(ctx context.Context, override canonical.PriceOverride) error
+	Get(ctx context.Context, productID string) (*canonical.PriceOverride, error)
+	GetByProducts(ctx context.Context, productIDs []string) ([]canonical.PriceOverride, error)
+	Remove(ctx context.Context, productID string) error
+}
+
+type priceOverrideRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewPriceOverrideRepo(db *mongo.Database, timeout time.Duration) PriceOverrideRepository {
+	return &priceOverrideRepository{
+		collection: db.Collection(priceOverrideCollection),
+		timeout:    timeout,
+	}
+}
+
+func (r *priceOverrideRepository) Set(ctx context.Context, override canonical.PriceOverride) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	override.StoreID = tenant.Store(ctx)
+	if override.StoreID == "" {
+		return canonical.ErrorStoreRequired
+	}
+	override.ID = canonical.PriceOverrideID(override.StoreID, override.ProductID)
+
+	_, err := r.collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: override.ID}}, override, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *priceOverrideRepository) Get(ctx context.Context, productID string) (*canonical.PriceOverride, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	var override canonical.PriceOverride
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: canonical.PriceOverrideID(tenant.Store(ctx), productID)}}).Decode(&override)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, canonical.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &override, nil
+}
+
+func (r *priceOverrideRepository) GetByProducts(ctx context.Context, productIDs []string) ([]canonical.PriceOverride, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	store := tenant.Store(ctx)
+	if store == "" || len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.D{
+		{Key: "store_id", Value: store},
+		{Key: "product_id", Value: bson.M{"$in": productIDs}},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.PriceOverride
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *priceOverrideRepository) Remove(ctx context.Context, productID string) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: canonical.PriceOverrideID(tenant.Store(ctx), productID)}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return canonical.ErrorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+)
+
+type memoryPriceOverrideRepository struct {
+	mu        sync.RWMutex
+	overrides map[string]canonical.PriceOverride
+}
+
+func newMemoryPriceOverrideRepo() *memoryPriceOverrideRepository {
+	return &memoryPriceOverrideRepository{
+		overrides: map[string]canonical.PriceOverride{},
+	}
+}
+
+func (r *memoryPriceOverrideRepository) Set(ctx context.Context, override canonical.PriceOverride) error {
+	override.StoreID = tenant.Store(ctx)
+	if override.StoreID == "" {
+		return canonical.ErrorStoreRequired
+	}
+	override.ID = canonical.PriceOverrideID(override.StoreID, override.ProductID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.overrides[override.ID] = override
+	return nil
+}
+
+func (r *memoryPriceOverrideRepository) Get(ctx context.Context, productID string) (*canonical.PriceOverride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	override, ok := r.overrides[canonical.PriceOverrideID(tenant.Store(ctx), productID)]
+	if !ok {
+		return nil, canonical.ErrorNotFound
+	}
+	return &override, nil
+}
+
+func (r *memoryPriceOverrideRepository) GetByProducts(ctx context.Context, productIDs []string) ([]canonical.PriceOverride, error) {
+	store := tenant.Store(ctx)
+	if store == "" || len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []canonical.PriceOverride
+	for _, productID := range productIDs {
+		if override, ok := r.overrides[canonical.PriceOverrideID(store, productID)]; ok {
+			results = append(results, override)
+		}
+	}
+	return results, nil
+}
+
+func (r *memoryPriceOverrideRepository) Remove(ctx context.Context, productID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := canonical.PriceOverrideID(tenant.Store(ctx), productID)
+	if _, ok := r.overrides[id]; !ok {
+		return canonical.ErrorNotFound
+	}
+	delete(r.overrides, id)
+	return nil
+}
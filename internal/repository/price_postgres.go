@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const priceOverrideColumns = `id, store_id, product_id, price, updated_at, updated_by`
+
+type postgresPriceOverrideRepository struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+func NewPostgresPriceOverrideRepo(pool *pgxpool.Pool, timeout time.Duration) PriceOverrideRepository {
+	return &postgresPriceOverrideRepository{
+		pool:    pool,
+		timeout: timeout,
+	}
+}
+
+func (r *postgresPriceOverrideRepository) Set(ctx context.Context, override canonical.PriceOverride) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	override.StoreID = tenant.Store(ctx)
+	if override.StoreID == "" {
+		return canonical.ErrorStoreRequired
+	}
+	override.ID = canonical.PriceOverrideID(override.StoreID, override.ProductID)
+
+	_, err := postgresConn(ctx, r.pool).Exec(ctx, `INSERT INTO price_overrides (`+priceOverrideColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			price = EXCLUDED.price, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by`,
+		override.ID, override.StoreID, override.ProductID, override.Price, override.UpdatedAt, override.UpdatedBy)
+	return err
+}
+
+func (r *postgresPriceOverrideRepository) Get(ctx context.Context, productID string) (*canonical.PriceOverride, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	rows, _ := postgresConn(ctx, r.pool).Query(ctx, `SELECT `+priceOverrideColumns+` FROM price_overrides
+		WHERE id = $1`,
+		canonical.PriceOverrideID(tenant.Store(ctx), productID))
+
+	override, err := pgx.CollectOneRow(rows, scanPriceOverride)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, canonical.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *postgresPriceOverrideRepository) GetByProducts(ctx context.Context, productIDs []string) ([]canonical.PriceOverride, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	store := tenant.Store(ctx)
+	if store == "" || len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, _ := postgresConn(ctx, r.pool).Query(ctx, `SELECT `+priceOverrideColumns+` FROM price_overrides
+		WHERE store_id = $1 AND product_id = ANY($2)`,
+		store, productIDs)
+	return pgx.CollectRows(rows, scanPriceOverride)
+}
+
+func (r *postgresPriceOverrideRepository) Remove(ctx context.Context, productID string) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	result, err := postgresConn(ctx, r.pool).Exec(ctx, "DELETE FROM price_overrides WHERE id = $1",
+		canonical.PriceOverrideID(tenant.Store(ctx), productID))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return canonical.ErrorNotFound
+	}
+	return nil
+}
+
+func scanPriceOverride(row pgx.CollectableRow) (canonical.PriceOverride, error) {
+	var override canonical.PriceOverride
+	err := row.Scan(&override.ID, &override.StoreID, &override.ProductID, &override.Price, &override.UpdatedAt, &override.UpdatedBy)
+	override.UpdatedAt = override.UpdatedAt.UTC()
+	return override, err
+}
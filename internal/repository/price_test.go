@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestPriceOverrideRepository_Set(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	db.Run("given store must upsert the override of the store", func(mt *mtest.T) {
+		repo := priceOverrideRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+		err := repo.Set(tenant.WithStore(context.Background(), "store_1"), canonical.PriceOverride{
+			StoreID:   "store_2",
+			ProductID: "product_valid_id",
+			Price:     12.5,
+		})
+
+		assert.Nil(t, err)
+
+		command := mt.GetStartedEvent().Command
+		update := command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, "store_1:product_valid_id", update.Lookup("q", "_id").StringValue())
+		assert.Equal(t, "store_1", update.Lookup("u", "store_id").StringValue())
+		assert.True(t, update.Lookup("upsert").Boolean())
+	})
+
+	db.Run("given no store must be refused", func(mt *mtest.T) {
+		repo := priceOverrideRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+
+		err := repo.Set(context.Background(), canonical.PriceOverride{ProductID: "product_valid_id", Price: 12.5})
+
+		assert.ErrorIs(t, err, canonical.ErrorStoreRequired)
+	})
+}
+
+func TestPriceOverrideRepository_GetByProducts(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	db.Run("given store must only read its overrides", func(mt *mtest.T) {
+		repo := priceOverrideRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.price_override", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "store_1:product_valid_id"},
+			{Key: "store_id", Value: "store_1"},
+			{Key: "product_id", Value: "product_valid_id"},
+			{Key: "price", Value: 12.5},
+		}))
+
+		overrides, err := repo.GetByProducts(tenant.WithStore(context.Background(), "store_1"), []string{"product_valid_id"})
+
+		assert.Nil(t, err)
+		assert.Len(t, overrides, 1)
+		assert.Equal(t, 12.5, overrides[0].Price)
+
+		var filter bson.D
+		assert.Nil(t, mt.GetStartedEvent().Command.Lookup("filter").Unmarshal(&filter))
+		assert.Equal(t, "store_1", filter.Map()["store_id"])
+	})
+
+	db.Run("given base catalog must not query", func(mt *mtest.T) {
+		repo := priceOverrideRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+
+		overrides, err := repo.GetByProducts(context.Background(), []string{"product_valid_id"})
+
+		assert.Nil(t, err)
+		assert.Empty(t, overrides)
+		assert.Nil(t, mt.GetStartedEvent())
+	})
+}
+
+func TestPriceOverrideRepository_Remove(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	db.Run("given missing override must return not found", func(mt *mtest.T) {
+		repo := priceOverrideRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+
+		err := repo.Remove(tenant.WithStore(context.Background(), "store_1"), "product_valid_id")
+
+		assert.ErrorIs(t, err, canonical.ErrorNotFound)
+	})
+}
@@ -2,63 +2,74 @@ package repository
 
 import (
 	"context"
-	"sync"
+	"errors"
 	"tech-challenge-product/internal/canonical"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
 	productCollection = "product"
 )
 
-var (
-	once     sync.Once
-	instance productRepository
-)
-
+// ProductRepository stores the catalog. Listings only return active
+// products, except batch lookups and incremental listings. GetByID fails
+// with canonical.ErrorNotFound when the product is not visible.
 type ProductRepository interface {
 	GetAll(context.Context) ([]canonical.Product, error)
 	Create(ctx context.Context, product *canonical.Product) (*canonical.Product, error)
 	Update(context.Context, string, canonical.Product) error
 	GetByID(context.Context, string) (*canonical.Product, error)
 	GetByCategory(context.Context, string) ([]canonical.Product, error)
+	Find(context.Context, canonical.ProductFilter) ([]canonical.Product, error)
 	GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error)
+	// Touch increments the version of a product visible from ctx and sets
+	// its updated_at to at, leaving every other field as it is, so a change
+	// kept outside the product, such as a store price override, reaches
+	// watchers and updated_since listings.
+	Touch(ctx context.Context, id string, at time.Time) error
 }
 
 type productRepository struct {
 	collection *mongo.Collection
+	timeout    time.Duration
 }
 
-func NewProductRepo() ProductRepository {
-	once.Do(func() {
-		instance = productRepository{
-			collection: NewMongo().Collection(productCollection),
-		}
-	})
-
-	return &instance
+func NewProductRepo(db *mongo.Database, timeout time.Duration) ProductRepository {
+	return &productRepository{
+		collection: db.Collection(productCollection),
+		timeout:    timeout,
+	}
 }
 
 func (r *productRepository) GetAll(ctx context.Context) ([]canonical.Product, error) {
-	filter := bson.D{{Key: "status", Value: 0}}
-	cursor, err := r.collection.Find(context.TODO(), filter)
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.D{{Key: "status", Value: 0}, {Key: "store_id", Value: visibleStores(ctx)}}
+	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 	var results []canonical.Product
-	if err = cursor.All(context.TODO(), &results); err != nil {
+	if err = cursor.All(ctx, &results); err != nil {
 		return nil, err
 	}
 	return results, nil
 }
 
 func (r *productRepository) GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
 	filter := bson.M{
 		"_id": bson.M{
 			"$in": ids,
 		},
+		"store_id": visibleStores(ctx),
 	}
 
 	cursor, err := r.collection.Find(ctx, filter)
@@ -77,6 +88,9 @@ func (r *productRepository) GetProductsWithId(ctx context.Context, ids []string)
 }
 
 func (r *productRepository) Create(ctx context.Context, product *canonical.Product) (*canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
 	_, err := r.collection.InsertOne(ctx, product)
 	if err != nil {
 		return nil, err
@@ -84,22 +98,97 @@ func (r *productRepository) Create(ctx context.Context, product *canonical.Produ
 	return product, nil
 }
 
+// Update replaces the product only if its stored version still matches
+// product.Version, incrementing the version in the same operation. Only
+// products of the store ctx is scoped to can be updated.
 func (r *productRepository) Update(ctx context.Context, id string, product canonical.Product) error {
-	filter := bson.M{"_id": id}
-	fields := bson.M{"$set": product}
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "version": product.Version, "store_id": ownStore(ctx)}
+	if product.Version == 0 {
+		// documents written before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	set, err := updatableFields(product)
+	if err != nil {
+		return err
+	}
+
+	fields := bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, fields)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id, "store_id": ownStore(ctx)})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return canonical.ErrorNotFound
+		}
+		return canonical.ErrorVersionMismatch
+	}
+
+	return nil
+}
+
+func (r *productRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	fields := bson.M{
+		"$set": bson.M{"updated_at": at},
+		"$inc": bson.M{"version": 1},
+	}
 
-	_, err := r.collection.UpdateOne(ctx, filter, fields)
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "store_id": visibleStores(ctx)}, fields)
 	if err != nil {
 		return err
 	}
+	if result.MatchedCount == 0 {
+		return canonical.ErrorNotFound
+	}
 	return nil
 }
 
+func updatableFields(product canonical.Product) (bson.M, error) {
+	raw, err := bson.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields bson.M
+	if err = bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "_id")
+	delete(fields, "store_id")
+	delete(fields, "version")
+	delete(fields, "created_at")
+	delete(fields, "created_by")
+
+	return fields, nil
+}
+
 func (r *productRepository) GetByID(ctx context.Context, id string) (*canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
 
 	var roduct canonical.Product
 
-	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&roduct)
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}, {Key: "store_id", Value: visibleStores(ctx)}}).Decode(&roduct)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, canonical.ErrorNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -108,20 +197,59 @@ func (r *productRepository) GetByID(ctx context.Context, id string) (*canonical.
 }
 
 func (r *productRepository) GetByCategory(ctx context.Context, category string) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
 	filter := bson.D{
 		{Key: "$and",
 			Value: bson.A{
 				bson.D{{Key: "category", Value: category}},
 				bson.D{{Key: "status", Value: 0}},
+				bson.D{{Key: "store_id", Value: visibleStores(ctx)}},
 			},
 		},
 	}
-	cursor, err := r.collection.Find(context.TODO(), filter)
+	cursor, err := r.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 	var results []canonical.Product
-	if err = cursor.All(context.TODO(), &results); err != nil {
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *productRepository) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	query := bson.D{{Key: "store_id", Value: visibleStores(ctx)}}
+	if filter.Category != "" {
+		query = append(query, bson.E{Key: "category", Value: filter.Category})
+	}
+	if filter.UpdatedSince.IsZero() {
+		query = append(query, bson.E{Key: "status", Value: canonical.STATUS_ACTIVE})
+	} else {
+		query = append(query, bson.E{Key: "updated_at", Value: bson.M{"$gte": filter.UpdatedSince}})
+	}
+
+	findOptions := options.Find()
+	if filter.SortBy != "" {
+		direction := 1
+		if filter.Descending {
+			direction = -1
+		}
+		findOptions.SetSort(bson.D{{Key: filter.SortBy, Value: direction}, {Key: "_id", Value: 1}})
+	}
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.Product
+	if err = cursor.All(ctx, &results); err != nil {
 		return nil, err
 	}
 	return results, nil
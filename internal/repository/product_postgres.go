@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const productColumns = `id, store_id, name, description, price, category, status,
+	image_path, version, created_at, updated_at, created_by, updated_by`
+
+// productSortColumns maps the canonical sort keys to columns. Only these
+// are ever written into a query.
+var productSortColumns = map[string]string{
+	"name":       "name",
+	"price":      "price",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+type postgresProductRepository struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+func NewPostgresProductRepo(pool *pgxpool.Pool, timeout time.Duration) ProductRepository {
+	return &postgresProductRepository{
+		pool:    pool,
+		timeout: timeout,
+	}
+}
+
+func (r *postgresProductRepository) GetAll(ctx context.Context) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.query(ctx, `SELECT `+productColumns+` FROM products
+		WHERE status = $1 AND store_id IN ('', $2)`,
+		canonical.STATUS_ACTIVE, tenant.Store(ctx))
+}
+
+func (r *postgresProductRepository) GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.query(ctx, `SELECT `+productColumns+` FROM products
+		WHERE id = ANY($1) AND store_id IN ('', $2)`,
+		ids, tenant.Store(ctx))
+}
+
+func (r *postgresProductRepository) Create(ctx context.Context, product *canonical.Product) (*canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := postgresConn(ctx, r.pool).Exec(ctx, `INSERT INTO products (`+productColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		product.ID, product.StoreID, product.Name, product.Description, product.Price,
+		product.Category, product.Status, product.ImagePath, product.Version,
+		product.CreatedAt, product.UpdatedAt, product.CreatedBy, product.UpdatedBy)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// Update replaces the product only if its stored version still matches
+// product.Version, incrementing the version in the same statement. Only
+// products of the store ctx is scoped to can be updated.
+func (r *postgresProductRepository) Update(ctx context.Context, id string, product canonical.Product) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	result, err := postgresConn(ctx, r.pool).Exec(ctx, `UPDATE products SET
+			name = $1, description = $2, price = $3, category = $4, status = $5,
+			image_path = $6, updated_at = $7, updated_by = $8, version = version + 1
+		WHERE id = $9 AND store_id = $10 AND version = $11`,
+		product.Name, product.Description, product.Price, product.Category, product.Status,
+		product.ImagePath, product.UpdatedAt, product.UpdatedBy,
+		id, tenant.Store(ctx), product.Version)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		var exists bool
+		err = postgresConn(ctx, r.pool).QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND store_id = $2)",
+			id, tenant.Store(ctx)).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return canonical.ErrorNotFound
+		}
+		return canonical.ErrorVersionMismatch
+	}
+
+	return nil
+}
+
+func (r *postgresProductRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	result, err := postgresConn(ctx, r.pool).Exec(ctx, `UPDATE products SET
+			updated_at = $1, version = version + 1
+		WHERE id = $2 AND store_id IN ('', $3)`,
+		at, id, tenant.Store(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return canonical.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *postgresProductRepository) GetByID(ctx context.Context, id string) (*canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	rows, _ := postgresConn(ctx, r.pool).Query(ctx, `SELECT `+productColumns+` FROM products
+		WHERE id = $1 AND store_id IN ('', $2)`,
+		id, tenant.Store(ctx))
+
+	product, err := pgx.CollectOneRow(rows, scanProduct)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, canonical.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *postgresProductRepository) GetByCategory(ctx context.Context, category string) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.query(ctx, `SELECT `+productColumns+` FROM products
+		WHERE category = $1 AND status = $2 AND store_id IN ('', $3)`,
+		category, canonical.STATUS_ACTIVE, tenant.Store(ctx))
+}
+
+func (r *postgresProductRepository) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	conditions := []string{"store_id IN ('', $1)"}
+	args := []interface{}{tenant.Store(ctx)}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if filter.Category != "" {
+		where("category = ?", filter.Category)
+	}
+	if filter.UpdatedSince.IsZero() {
+		where("status = ?", canonical.STATUS_ACTIVE)
+	} else {
+		where("updated_at >= ?", filter.UpdatedSince)
+	}
+
+	query := `SELECT ` + productColumns + ` FROM products WHERE ` + strings.Join(conditions, " AND ")
+	if column, ok := productSortColumns[filter.SortBy]; ok {
+		direction := "ASC"
+		if filter.Descending {
+			direction = "DESC"
+		}
+		query += " ORDER BY " + column + " " + direction + ", id ASC"
+	}
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *postgresProductRepository) query(ctx context.Context, query string, args ...interface{}) ([]canonical.Product, error) {
+	rows, _ := postgresConn(ctx, r.pool).Query(ctx, query, args...)
+	return pgx.CollectRows(rows, scanProduct)
+}
+
+func scanProduct(row pgx.CollectableRow) (canonical.Product, error) {
+	var product canonical.Product
+	err := row.Scan(
+		&product.ID, &product.StoreID, &product.Name, &product.Description, &product.Price,
+		&product.Category, &product.Status, &product.ImagePath, &product.Version,
+		&product.CreatedAt, &product.UpdatedAt, &product.CreatedBy, &product.UpdatedBy,
+	)
+	// timestamptz is read in the local time zone, Mongo returns UTC
+	product.CreatedAt = product.CreatedAt.UTC()
+	product.UpdatedAt = product.UpdatedAt.UTC()
+	return product, err
+}
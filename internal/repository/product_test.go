@@ -3,21 +3,17 @@ package repository
 import (
 	"context"
 	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/undefinedlabs/go-mpatch"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 )
 
 func TestProductRepository_GetByID(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-
 	type Given struct {
 		mtestFunc func(mt *mtest.T)
 	}
@@ -31,7 +27,7 @@ func TestProductRepository_GetByID(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(
 						mtest.CreateCursorResponse(1,
@@ -59,12 +55,12 @@ func TestProductRepository_GetByID(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch))
 					product, err := repo.GetByID(context.Background(), "asd")
 					assert.NotNil(t, err)
-					assert.Equal(t, err.Error(), "mongo: no documents in result")
+					assert.ErrorIs(t, err, canonical.ErrorNotFound)
 					assert.Nil(t, product)
 				},
 			},
@@ -78,11 +74,6 @@ func TestProductRepository_GetByID(t *testing.T) {
 }
 
 func TestProductRepository_GetAll(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-
 	type Given struct {
 		mtestFunc func(mt *mtest.T)
 	}
@@ -96,7 +87,7 @@ func TestProductRepository_GetAll(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 
 					first := mtest.CreateCursorResponse(1, "product.product", mtest.FirstBatch, bson.D{
@@ -133,7 +124,7 @@ func TestProductRepository_GetAll(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Message: "mongo: no documents in result"}))
 					product, err := repo.GetAll(context.Background())
@@ -152,11 +143,6 @@ func TestProductRepository_GetAll(t *testing.T) {
 }
 
 func TestProductRepository_GetByCategory(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-
 	type Given struct {
 		mtestFunc func(mt *mtest.T)
 	}
@@ -170,7 +156,7 @@ func TestProductRepository_GetByCategory(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					first := mtest.CreateCursorResponse(1, "product.product", mtest.FirstBatch, bson.D{
 						{Key: "_id", Value: "product_valid_id"},
@@ -206,7 +192,7 @@ func TestProductRepository_GetByCategory(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 
 					mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Message: "mongo: no documents in result"}))
@@ -226,11 +212,6 @@ func TestProductRepository_GetByCategory(t *testing.T) {
 }
 
 func TestCreate(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-
 	type Given struct {
 		mtestFunc func(mt *mtest.T)
 	}
@@ -244,7 +225,7 @@ func TestCreate(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(mtest.CreateSuccessResponse())
 
@@ -270,7 +251,7 @@ func TestCreate(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(
 						bson.D{
@@ -305,11 +286,6 @@ func TestCreate(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-
 	type Given struct {
 		mtestFunc func(mt *mtest.T)
 	}
@@ -323,10 +299,12 @@ func TestUpdate(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(bson.D{
 						{Key: "ok", Value: 1},
+						{Key: "n", Value: 1},
+						{Key: "nModified", Value: 1},
 						{Key: "value", Value: bson.D{
 							{Key: "_id", Value: "product_valid_id"},
 							{Key: "name", Value: "product_valid_name"},
@@ -355,11 +333,61 @@ func TestUpdate(t *testing.T) {
 				},
 			},
 		},
+		"given stale version must return version mismatch": {
+			given: Given{
+				mtestFunc: func(mt *mtest.T) {
+					repo := productRepository{
+						collection: mt.DB.Collection("fake-collection"),
+					}
+					mt.AddMockResponses(
+						bson.D{
+							{Key: "ok", Value: 1},
+							{Key: "n", Value: 0},
+							{Key: "nModified", Value: 0},
+						},
+						mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch, bson.D{
+							{Key: "n", Value: 1},
+						}),
+					)
+
+					product := canonical.Product{
+						ID:      "product_valid_id",
+						Name:    "product_valid_name",
+						Version: 2,
+					}
+
+					err := repo.Update(context.Background(), "product_valid_id", product)
+
+					assert.ErrorIs(t, err, canonical.ErrorVersionMismatch)
+				},
+			},
+		},
+		"given unknown product must return not found": {
+			given: Given{
+				mtestFunc: func(mt *mtest.T) {
+					repo := productRepository{
+						collection: mt.DB.Collection("fake-collection"),
+					}
+					mt.AddMockResponses(
+						bson.D{
+							{Key: "ok", Value: 1},
+							{Key: "n", Value: 0},
+							{Key: "nModified", Value: 0},
+						},
+						mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch),
+					)
+
+					err := repo.Update(context.Background(), "product_invalid_id", canonical.Product{})
+
+					assert.ErrorIs(t, err, canonical.ErrorNotFound)
+				},
+			},
+		},
 		"given error saving must return error": {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					mt.AddMockResponses(
 						bson.D{
@@ -405,7 +433,7 @@ func TestGetProductsWithId(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 					first := mtest.CreateCursorResponse(1, "product.product", mtest.FirstBatch, bson.D{
 						{Key: "_id", Value: "123"},
@@ -446,7 +474,7 @@ func TestGetProductsWithId(t *testing.T) {
 			given: Given{
 				mtestFunc: func(mt *mtest.T) {
 					repo := productRepository{
-						mt.DB.Collection("fake-collection"),
+						collection: mt.DB.Collection("fake-collection"),
 					}
 
 					mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Message: "mongo: no documents in result"}))
@@ -466,3 +494,76 @@ func TestGetProductsWithId(t *testing.T) {
 		db.Run("", tc.given.mtestFunc)
 	}
 }
+
+func TestProductRepository_Find(t *testing.T) {
+	since := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	baseCatalog := bson.D{{Key: "$in", Value: bson.A{"", nil}}}
+
+	type Given struct {
+		filter canonical.ProductFilter
+		store  string
+	}
+	type Expected struct {
+		filter bson.D
+		sort   bson.D
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given category only must return active products": {
+			given: Given{
+				filter: canonical.ProductFilter{Category: "drinks"},
+			},
+			expected: Expected{
+				filter: bson.D{{Key: "store_id", Value: baseCatalog}, {Key: "category", Value: "drinks"}, {Key: "status", Value: int32(0)}},
+			},
+		},
+		"given store must return its products and the base catalog": {
+			given: Given{
+				filter: canonical.ProductFilter{Category: "drinks"},
+				store:  "store_1",
+			},
+			expected: Expected{
+				filter: bson.D{{Key: "store_id", Value: bson.D{{Key: "$in", Value: bson.A{"", nil, "store_1"}}}}, {Key: "category", Value: "drinks"}, {Key: "status", Value: int32(0)}},
+			},
+		},
+		"given updated_since and sort must include every status and sort": {
+			given: Given{
+				filter: canonical.ProductFilter{UpdatedSince: since, SortBy: "updated_at", Descending: true},
+			},
+			expected: Expected{
+				filter: bson.D{{Key: "store_id", Value: baseCatalog}, {Key: "updated_at", Value: bson.D{{Key: "$gte", Value: primitive.NewDateTimeFromTime(since)}}}},
+				sort:   bson.D{{Key: "updated_at", Value: int32(-1)}, {Key: "_id", Value: int32(1)}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+		db.Run(name, func(mt *mtest.T) {
+			repo := productRepository{
+				collection: mt.DB.Collection("fake-collection"),
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "product_valid_id"},
+				{Key: "status", Value: 1},
+			}))
+
+			products, err := repo.Find(tenant.WithStore(context.Background(), tc.given.store), tc.given.filter)
+
+			assert.Nil(t, err)
+			assert.Len(t, products, 1)
+
+			command := mt.GetStartedEvent().Command
+			var filter, sort bson.D
+			assert.Nil(t, command.Lookup("filter").Unmarshal(&filter))
+			assert.Equal(t, tc.expected.filter, filter)
+			if tc.expected.sort != nil {
+				assert.Nil(t, command.Lookup("sort").Unmarshal(&sort))
+				assert.Equal(t, tc.expected.sort, sort)
+			}
+		})
+	}
+}
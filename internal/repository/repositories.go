@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repositories are the repositories of one storage backend, so the writes
+// a Transactor groups and the changes a ProductWatcher follows are always
+// kept in the same place.
+type Repositories struct {
+	Products   ProductRepository
+	Audit      AuditRepository
+	Outbox     OutboxRepository
+	Webhooks   WebhookRepository
+	Deliveries DeliveryRepository
+	APIKeys    APIKeyRepository
+	Prices     PriceOverrideRepository
+	Stats      StatsRepository
+	Watcher    ProductWatcher
+	Transactor Transactor
+}
+
+// NewMongoRepositories stores everything in db.
+func NewMongoRepositories(client *mongo.Client, db *mongo.Database, timeout time.Duration) Repositories {
+	return Repositories{
+		Products:   NewProductRepo(db, timeout),
+		Audit:      NewAuditRepo(db, timeout),
+		Outbox:     NewOutboxRepo(db, timeout),
+		Webhooks:   NewWebhookRepo(db, timeout),
+		Deliveries: NewDeliveryRepo(db, timeout),
+		APIKeys:    NewAPIKeyRepo(db, timeout),
+		Prices:     NewPriceOverrideRepo(db, timeout),
+		Stats:      NewStatsRepo(db, timeout),
+		Watcher:    NewProductWatcher(db),
+		Transactor: NewTransactor(client),
+	}
+}
+
+// NewPostgresRepositories stores the catalog in PostgreSQL: the products,
+// with the audit log, outbox and price overrides written in their
+// transactions, their changes and their metrics. Webhooks, deliveries and
+// API keys are left to the caller.
+func NewPostgresRepositories(pool *pgxpool.Pool, timeout time.Duration) Repositories {
+	return Repositories{
+		Products:   NewPostgresProductRepo(pool, timeout),
+		Audit:      NewPostgresAuditRepo(pool, timeout),
+		Outbox:     NewPostgresOutboxRepo(pool, timeout),
+		Prices:     NewPostgresPriceOverrideRepo(pool, timeout),
+		Stats:      NewPostgresStatsRepo(pool, timeout),
+		Watcher:    NewPostgresProductWatcher(pool, timeout),
+		Transactor: NewPostgresTransactor(pool),
+	}
+}
+
+// NewMemoryRepositories keeps everything in process, for development and
+// tests. Nothing survives a restart.
+func NewMemoryRepositories() Repositories {
+	products := newMemoryProductRepo()
+
+	return Repositories{
+		Products:   products,
+		Audit:      &memoryAuditRepository{},
+		Outbox:     newMemoryOutboxRepo(),
+		Webhooks:   &memoryWebhookRepository{},
+		Deliveries: newMemoryDeliveryRepo(),
+		APIKeys:    &memoryAPIKeyRepository{},
+		Prices:     newMemoryPriceOverrideRepo(),
+		Stats:      &memoryStatsRepository{products: products},
+		Watcher:    &memoryProductWatcher{changes: products.changes},
+		Transactor: memoryTransactor{},
+	}
+}
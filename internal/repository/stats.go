@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StatsRepository computes figures over the whole catalog. Unlike the other
+// repositories it is not scoped to a store, so it must not back any
+// endpoint reachable by stores.
+type StatsRepository interface {
+	ActiveByCategory(context.Context) ([]canonical.CategoryCount, error)
+}
+
+type statsRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewStatsRepo(db *mongo.Database, timeout time.Duration) StatsRepository {
+	return &statsRepository{
+		collection: db.Collection(productCollection),
+		timeout:    timeout,
+	}
+}
+
+func (r *statsRepository) ActiveByCategory(ctx context.Context) ([]canonical.CategoryCount, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "status", Value: canonical.STATUS_ACTIVE}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "store_id", Value: "$store_id"}, {Key: "category", Value: "$category"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []struct {
+		ID struct {
+			StoreID  string `bson:"store_id"`
+			Category string `bson:"category"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	counts := make([]canonical.CategoryCount, 0, len(groups))
+	for _, group := range groups {
+		counts = append(counts, canonical.CategoryCount{
+			StoreID:  group.ID.StoreID,
+			Category: group.ID.Category,
+			Count:    group.Count,
+		})
+	}
+	return counts, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+)
+
+type memoryStatsRepository struct {
+	products *memoryProductRepository
+}
+
+func (r *memoryStatsRepository) ActiveByCategory(ctx context.Context) ([]canonical.CategoryCount, error) {
+	type group struct {
+		store    string
+		category string
+	}
+
+	counts := map[group]int{}
+	var groups []group
+	for _, product := range r.products.filter(func(product canonical.Product) bool {
+		return product.Status == canonical.STATUS_ACTIVE
+	}) {
+		key := group{store: product.StoreID, category: product.Category}
+		if _, ok := counts[key]; !ok {
+			groups = append(groups, key)
+		}
+		counts[key]++
+	}
+
+	results := make([]canonical.CategoryCount, 0, len(groups))
+	for _, key := range groups {
+		results = append(results, canonical.CategoryCount{
+			StoreID:  key.store,
+			Category: key.category,
+			Count:    int64(counts[key]),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type postgresStatsRepository struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+func NewPostgresStatsRepo(pool *pgxpool.Pool, timeout time.Duration) StatsRepository {
+	return &postgresStatsRepository{
+		pool:    pool,
+		timeout: timeout,
+	}
+}
+
+func (r *postgresStatsRepository) ActiveByCategory(ctx context.Context) ([]canonical.CategoryCount, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	rows, _ := r.pool.Query(ctx, `SELECT store_id, category, count(*) FROM products
+		WHERE status = $1
+		GROUP BY store_id, category`,
+		canonical.STATUS_ACTIVE)
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[canonical.CategoryCount])
+}
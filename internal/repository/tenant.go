@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// visibleStores matches the store_id of the documents readable from the
+// store ctx is scoped to: its own and the base catalog. Documents written
+// before tenancy have no store_id and belong to the base catalog.
+func visibleStores(ctx context.Context) bson.M {
+	stores := bson.A{"", nil}
+	if store := tenant.Store(ctx); store != "" {
+		stores = append(stores, store)
+	}
+	return bson.M{"$in": stores}
+}
+
+// ownStore matches the store_id of the documents writable from the store
+// ctx is scoped to.
+func ownStore(ctx context.Context) interface{} {
+	if store := tenant.Store(ctx); store != "" {
+		return store
+	}
+	return bson.M{"$in": bson.A{"", nil}}
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Transactor runs fn inside a database transaction. Repository calls made
+// with the context handed to fn join the transaction.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(client *mongo.Client) Transactor {
+	return &mongoTransactor{
+		client: client,
+	}
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	// transactions must read from the primary whatever db.read_preference is
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	}, options.Transaction().SetReadPreference(readpref.Primary()))
+
+	return err
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "context"
+
+// memoryTransactor runs fn as is. The memory repositories apply each write
+// at once, and the audit and outbox writes following a product write can
+// not fail, so there is nothing to roll back.
+type memoryTransactor struct{}
+
+func (memoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type postgresTxKey struct{}
+
+// postgresQuerier runs statements on the pool, or on the transaction the
+// context was given by postgresTransactor.
+type postgresQuerier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
+}
+
+// postgresConn is the transaction ctx carries, or pool outside of one.
+func postgresConn(ctx context.Context, pool *pgxpool.Pool) postgresQuerier {
+	if tx, ok := ctx.Value(postgresTxKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
+
+type postgresTransactor struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTransactor(pool *pgxpool.Pool) Transactor {
+	return &postgresTransactor{
+		pool: pool,
+	}
+}
+
+// WithTransaction commits the writes fn makes with its context once it
+// returns, or rolls them back when it fails. Calls nested in fn join the
+// outer transaction.
+func (t *postgresTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(postgresTxKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	return pgx.BeginFunc(ctx, t.pool, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, postgresTxKey{}, tx))
+	})
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestTransactor_WithTransaction(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock).
+		ClientOptions(options.Client().SetReadPreference(readpref.SecondaryPreferred())))
+	db.Run("given secondary read preference must still read from the primary", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "product.product", mtest.FirstBatch, bson.D{{Key: "_id", Value: "1"}}),
+			mtest.CreateSuccessResponse(),
+		)
+		repo := NewProductRepo(mt.DB, 0)
+
+		err := NewTransactor(mt.Client).WithTransaction(context.Background(), func(ctx context.Context) error {
+			_, err := repo.GetByID(ctx, "1")
+			return err
+		})
+
+		// the driver refuses a transaction inheriting a secondary preference
+		assert.NoError(t, err)
+		assert.Equal(t, "find", mt.GetStartedEvent().CommandName)
+	})
+}
+
+// fakeTx stands for a transaction begun by postgresTransactor.
+type fakeTx struct {
+	pgx.Tx
+}
+
+func TestPostgresTransactor_WithTransaction(t *testing.T) {
+	tx := &fakeTx{}
+	ctx := context.WithValue(context.Background(), postgresTxKey{}, pgx.Tx(tx))
+
+	var conn postgresQuerier
+	// no pool: joining the transaction of ctx must not begin another one
+	err := NewPostgresTransactor(nil).WithTransaction(ctx, func(ctx context.Context) error {
+		conn = postgresConn(ctx, nil)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Same(t, tx, conn)
+	assert.Nil(t, postgresConn(context.Background(), nil))
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// server error codes returned when a resume token can not be used
+	errorCodeChangeStreamHistoryLost = 286
+	errorCodeInvalidResumeToken      = 260
+	errorCodeResumeTokenNotFound     = 280
+)
+
+type ProductWatcher interface {
+	// Watch calls handle for every product change matching filter, starting
+	// after resumeToken when it is set, until ctx is done or handle fails.
+	Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error
+}
+
+type productWatcher struct {
+	collection *mongo.Collection
+}
+
+func NewProductWatcher(db *mongo.Database) ProductWatcher {
+	return &productWatcher{
+		collection: db.Collection(productCollection),
+	}
+}
+
+type changeDocument struct {
+	OperationType string              `bson:"operationType"`
+	ClusterTime   primitive.Timestamp `bson:"clusterTime"`
+	DocumentKey   struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      *canonical.Product `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+func (w *productWatcher) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	streamOptions := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != "" {
+		streamOptions.SetStartAfter(bson.D{{Key: "_data", Value: resumeToken}})
+	}
+
+	stream, err := w.collection.Watch(ctx, changePipeline(ctx, filter), streamOptions)
+	if err != nil {
+		return resumeError(err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var document changeDocument
+		if err := stream.Decode(&document); err != nil {
+			return err
+		}
+
+		change := toProductChange(document)
+		change.Token = stream.ResumeToken().Lookup("_data").StringValue()
+
+		if err := handle(change); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return resumeError(stream.Err())
+}
+
+func changePipeline(ctx context.Context, filter canonical.ChangeFilter) mongo.Pipeline {
+	match := bson.D{
+		{Key: "operationType", Value: bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}},
+		// hard deletes carry no document to attribute them to a store, so
+		// they are not reported; products are soft deleted instead
+		{Key: "fullDocument.store_id", Value: visibleStores(ctx)},
+	}
+	if filter.Category != "" {
+		match = append(match, bson.E{Key: "fullDocument.category", Value: filter.Category})
+	}
+	if len(filter.IDs) > 0 {
+		match = append(match, bson.E{Key: "documentKey._id", Value: bson.M{"$in": filter.IDs}})
+	}
+
+	return mongo.Pipeline{{{Key: "$match", Value: match}}}
+}
+
+func toProductChange(document changeDocument) canonical.ProductChange {
+	change := canonical.ProductChange{
+		ProductID:  document.DocumentKey.ID,
+		Product:    document.FullDocument,
+		OccurredAt: time.Unix(int64(document.ClusterTime.T), 0).UTC(),
+	}
+
+	switch document.OperationType {
+	case "insert":
+		change.Type = canonical.EVENT_PRODUCT_CREATED
+	case "delete":
+		change.Type = canonical.EVENT_PRODUCT_REMOVED
+	default:
+		change.Type = canonical.EVENT_PRODUCT_UPDATED
+		// products are removed by deactivating them
+		_, statusChanged := document.UpdateDescription.UpdatedFields["status"]
+		if document.FullDocument != nil && document.FullDocument.Status == canonical.STATUS_INACTIVE &&
+			(statusChanged || document.OperationType == "replace") {
+			change.Type = canonical.EVENT_PRODUCT_REMOVED
+		}
+	}
+
+	if document.FullDocument != nil && !document.FullDocument.UpdatedAt.IsZero() {
+		change.OccurredAt = document.FullDocument.UpdatedAt
+	}
+
+	return change
+}
+
+func resumeError(err error) error {
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) {
+		switch commandError.Code {
+		case errorCodeChangeStreamHistoryLost, errorCodeResumeTokenNotFound:
+			return canonical.ErrorResumeTokenExpired
+		case errorCodeInvalidResumeToken:
+			return canonical.ErrorInvalidResumeToken
+		}
+	}
+	return err
+}
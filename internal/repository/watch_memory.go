@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"time"
+)
+
+// memoryChangeLogSize is how many changes the memory products keep for
+// watchers resuming after a token.
+const memoryChangeLogSize = 1000
+
+// memoryChangeLog records the changes of the memory products, numbered
+// from 1, and wakes the watchers waiting for the next one. Only the last
+// memoryChangeLogSize changes are kept.
+type memoryChangeLog struct {
+	mu      sync.Mutex
+	entries []memoryChange
+	last    uint64
+	wake    chan struct{}
+}
+
+type memoryChange struct {
+	sequence uint64
+	change   canonical.ProductChange
+}
+
+func newMemoryChangeLog() *memoryChangeLog {
+	return &memoryChangeLog{
+		wake: make(chan struct{}),
+	}
+}
+
+func (l *memoryChangeLog) append(eventType canonical.EventType, product canonical.Product) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.last++
+	change := canonical.ProductChange{
+		Token:      strconv.FormatUint(l.last, 10),
+		Type:       eventType,
+		ProductID:  product.ID,
+		Product:    &product,
+		OccurredAt: product.UpdatedAt,
+	}
+	if change.OccurredAt.IsZero() {
+		change.OccurredAt = time.Now().UTC()
+	}
+
+	l.entries = append(l.entries, memoryChange{sequence: l.last, change: change})
+	if len(l.entries) > memoryChangeLogSize {
+		l.entries = l.entries[len(l.entries)-memoryChangeLogSize:]
+	}
+
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// position is the sequence of the change resumeToken stands for, or of
+// the last change when it is empty.
+func (l *memoryChangeLog) position(resumeToken string) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if resumeToken == "" {
+		return l.last, nil
+	}
+	sequence, err := strconv.ParseUint(resumeToken, 10, 64)
+	if err != nil || sequence > l.last {
+		return 0, canonical.ErrorInvalidResumeToken
+	}
+	return sequence, nil
+}
+
+// since returns the changes after sequence, and a channel closed once the
+// next change is recorded.
+func (l *memoryChangeLog) since(sequence uint64) ([]memoryChange, <-chan struct{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) > 0 && sequence+1 < l.entries[0].sequence {
+		return nil, nil, canonical.ErrorResumeTokenExpired
+	}
+
+	first := len(l.entries)
+	for i, entry := range l.entries {
+		if entry.sequence > sequence {
+			first = i
+			break
+		}
+	}
+	return append([]memoryChange(nil), l.entries[first:]...), l.wake, nil
+}
+
+// memoryProductWatcher follows the changes of the memory products. Resume
+// tokens are the numbers of the changes, and only stay usable as long as
+// the change is kept in the log.
+type memoryProductWatcher struct {
+	changes *memoryChangeLog
+}
+
+func (w *memoryProductWatcher) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	after, err := w.changes.position(resumeToken)
+	if err != nil {
+		return err
+	}
+
+	for {
+		entries, wake, err := w.changes.since(after)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			after = entry.sequence
+			if !watched(ctx, filter, entry.change) {
+				continue
+			}
+			if err := handle(entry.change); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		}
+	}
+}
+
+// watched reports whether change matches filter and is visible from the
+// store ctx is scoped to, as the Mongo change stream pipeline does.
+func watched(ctx context.Context, filter canonical.ChangeFilter, change canonical.ProductChange) bool {
+	if !visibleFrom(ctx, *change.Product) {
+		return false
+	}
+	if filter.Category != "" && change.Product.Category != filter.Category {
+		return false
+	}
+	if len(filter.IDs) == 0 {
+		return true
+	}
+	for _, id := range filter.IDs {
+		if id == change.ProductID {
+			return true
+		}
+	}
+	return false
+}
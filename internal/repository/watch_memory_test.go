@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryProductWatcher_Watch(t *testing.T) {
+	type Given struct {
+		ctx         context.Context
+		filter      canonical.ChangeFilter
+		resumeToken string
+		// before are written before watching, after once the watcher started
+		before []func(repo ProductRepository)
+		after  []func(repo ProductRepository)
+		wanted int
+	}
+	type Expected struct {
+		changes []string
+		err     error
+	}
+
+	create := func(id, store, category string) func(repo ProductRepository) {
+		return func(repo ProductRepository) {
+			ctx := tenant.WithStore(context.Background(), store)
+			_, err := repo.Create(ctx, &canonical.Product{ID: id, StoreID: store, Category: category})
+			require.NoError(t, err)
+		}
+	}
+	update := func(id string, change func(*canonical.Product)) func(repo ProductRepository) {
+		return func(repo ProductRepository) {
+			product, err := repo.GetByID(context.Background(), id)
+			require.NoError(t, err)
+			change(product)
+			require.NoError(t, repo.Update(context.Background(), id, *product))
+		}
+	}
+	remove := func(id string) func(repo ProductRepository) {
+		return update(id, func(product *canonical.Product) { product.Status = canonical.STATUS_INACTIVE })
+	}
+	rename := func(id string) func(repo ProductRepository) {
+		return update(id, func(product *canonical.Product) { product.Name = "renamed" })
+	}
+
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given no resume token must only report the changes after watching": {
+			given: Given{
+				ctx:    context.Background(),
+				before: []func(ProductRepository){create("soda", "", "drinks")},
+				after:  []func(ProductRepository){rename("soda"), remove("soda")},
+				wanted: 2,
+			},
+			expected: Expected{changes: []string{"2 ProductUpdated soda", "3 ProductRemoved soda"}},
+		},
+		"given resume token must replay the changes after it": {
+			given: Given{
+				ctx:         context.Background(),
+				resumeToken: "1",
+				before:      []func(ProductRepository){create("soda", "", "drinks"), create("burger", "", "food")},
+				after:       []func(ProductRepository){rename("burger")},
+				wanted:      2,
+			},
+			expected: Expected{changes: []string{"2 ProductCreated burger", "3 ProductUpdated burger"}},
+		},
+		"given filter must skip the other products": {
+			given: Given{
+				ctx:         context.Background(),
+				filter:      canonical.ChangeFilter{Category: "drinks", IDs: []string{"soda", "tea"}},
+				resumeToken: "0",
+				before:      []func(ProductRepository){create("soda", "", "drinks"), create("burger", "", "food"), create("juice", "", "drinks")},
+				after:       []func(ProductRepository){create("tea", "", "drinks")},
+				wanted:      2,
+			},
+			expected: Expected{changes: []string{"1 ProductCreated soda", "4 ProductCreated tea"}},
+		},
+		"given store must skip the products of other stores": {
+			given: Given{
+				ctx:         tenant.WithStore(context.Background(), "store-1"),
+				resumeToken: "0",
+				before:      []func(ProductRepository){create("tea", "store-1", "drinks"), create("water", "store-2", "drinks")},
+				after:       []func(ProductRepository){create("soda", "", "drinks")},
+				wanted:      2,
+			},
+			expected: Expected{changes: []string{"1 ProductCreated tea", "3 ProductCreated soda"}},
+		},
+		"given token that is not a change must fail with invalid resume token": {
+			given: Given{
+				ctx:         context.Background(),
+				resumeToken: "8265EDA0",
+			},
+			expected: Expected{err: canonical.ErrorInvalidResumeToken},
+		},
+		"given token of a change yet to happen must fail with invalid resume token": {
+			given: Given{
+				ctx:         context.Background(),
+				resumeToken: "2",
+				before:      []func(ProductRepository){create("soda", "", "drinks")},
+			},
+			expected: Expected{err: canonical.ErrorInvalidResumeToken},
+		},
+		"given token of a change no longer kept must fail with resume token expired": {
+			given: Given{
+				ctx:         context.Background(),
+				resumeToken: "1",
+				before: []func(ProductRepository){func(repo ProductRepository) {
+					// change 1 is dropped once the log is full, so the
+					// changes after it can not be replayed
+					for i := 0; i < memoryChangeLogSize+2; i++ {
+						create(fmt.Sprintf("product-%d", i), "", "drinks")(repo)
+					}
+				}},
+			},
+			expected: Expected{err: canonical.ErrorResumeTokenExpired},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repos := NewMemoryRepositories()
+			for _, write := range tc.given.before {
+				write(repos.Products)
+			}
+
+			ctx, cancel := context.WithTimeout(tc.given.ctx, time.Second)
+			defer cancel()
+
+			done := errors.New("done")
+			var changes []string
+			watching := make(chan error, 1)
+			go func() {
+				watching <- repos.Watcher.Watch(ctx, tc.given.filter, tc.given.resumeToken, func(change canonical.ProductChange) error {
+					changes = append(changes, fmt.Sprintf("%s %s %s", change.Token, change.Type, change.ProductID))
+					if len(changes) == tc.given.wanted {
+						return done
+					}
+					return nil
+				})
+			}()
+
+			// the watcher starts after the last change before it, so the
+			// changes written now are always reported
+			time.Sleep(10 * time.Millisecond)
+			for _, write := range tc.given.after {
+				write(repos.Products)
+			}
+
+			err := <-watching
+			if tc.expected.err != nil {
+				assert.ErrorIs(t, err, tc.expected.err)
+				return
+			}
+			assert.ErrorIs(t, err, done)
+			assert.Equal(t, tc.expected.changes, changes)
+		})
+	}
+}
+
+func TestMemoryProductWatcher_WatchStopsWithContext(t *testing.T) {
+	repos := NewMemoryRepositories()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := repos.Watcher.Watch(ctx, canonical.ChangeFilter{}, "", func(canonical.ProductChange) error {
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
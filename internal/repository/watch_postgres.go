@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// postgresChangesChannel is notified by the products trigger after
+	// every change.
+	postgresChangesChannel = "product_changes"
+	// postgresChangesPoll bounds the wait for a notification, in case one
+	// is lost with a connection, and spaces the reconnections of the
+	// listener.
+	postgresChangesPoll = 5 * time.Second
+	// postgresChangesBatch is how many changes are read at once.
+	postgresChangesBatch = 100
+)
+
+// postgresProductWatcher follows the product_changes table the products
+// trigger fills. Resume tokens are the sequence numbers of the changes, and
+// stay usable as long as the change is kept. Like the Mongo change stream,
+// a change carries the product as it is when the change is read.
+type postgresProductWatcher struct {
+	pool     *pgxpool.Pool
+	timeout  time.Duration
+	listener *postgresChangeListener
+}
+
+// NewPostgresProductWatcher reads changes with pool, bounding each read by
+// timeout. The watches share one connection of their own, outside of pool,
+// listening for new changes.
+func NewPostgresProductWatcher(pool *pgxpool.Pool, timeout time.Duration) ProductWatcher {
+	return &postgresProductWatcher{
+		pool:     pool,
+		timeout:  timeout,
+		listener: &postgresChangeListener{pool: pool, wake: make(chan struct{})},
+	}
+}
+
+func (w *postgresProductWatcher) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	w.listener.subscribe()
+	defer w.listener.unsubscribe()
+
+	after, err := w.position(ctx, resumeToken)
+	if err != nil {
+		return watching(ctx, err)
+	}
+
+	for {
+		// taken before reading, so a change committed meanwhile wakes it
+		wake := w.listener.next()
+
+		changes, err := w.since(ctx, after)
+		if err != nil {
+			return watching(ctx, err)
+		}
+
+		for _, change := range changes {
+			after, _ = strconv.ParseInt(change.Token, 10, 64)
+			if !watched(ctx, filter, change) {
+				continue
+			}
+			if err := handle(change); err != nil {
+				return err
+			}
+		}
+		if len(changes) == postgresChangesBatch {
+			continue
+		}
+
+		poll := time.NewTimer(postgresChangesPoll)
+		select {
+		case <-ctx.Done():
+			poll.Stop()
+			return nil
+		case <-wake:
+			poll.Stop()
+		case <-poll.C:
+		}
+	}
+}
+
+// position is the sequence of the change resumeToken stands for, or of
+// the last change when it is empty.
+func (w *postgresProductWatcher) position(ctx context.Context, resumeToken string) (int64, error) {
+	ctx, cancel := withDeadline(ctx, w.timeout)
+	defer cancel()
+
+	var last *int64
+	if err := w.pool.QueryRow(ctx, "SELECT max(change_id) FROM product_changes").Scan(&last); err != nil {
+		return 0, err
+	}
+	if resumeToken == "" {
+		if last == nil {
+			return 0, nil
+		}
+		return *last, nil
+	}
+
+	sequence, err := strconv.ParseInt(resumeToken, 10, 64)
+	if err != nil || sequence < 1 {
+		return 0, canonical.ErrorInvalidResumeToken
+	}
+	if last != nil && sequence > *last {
+		return 0, canonical.ErrorInvalidResumeToken
+	}
+
+	// tokens are only handed out for recorded changes, so a missing one
+	// was pruned
+	var kept bool
+	err = w.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM product_changes WHERE change_id = $1)", sequence).Scan(&kept)
+	if err != nil {
+		return 0, err
+	}
+	if !kept {
+		return 0, canonical.ErrorResumeTokenExpired
+	}
+	return sequence, nil
+}
+
+// since reads the next changes after sequence, with the current state of
+// their product.
+func (w *postgresProductWatcher) since(ctx context.Context, sequence int64) ([]canonical.ProductChange, error) {
+	ctx, cancel := withDeadline(ctx, w.timeout)
+	defer cancel()
+
+	rows, _ := w.pool.Query(ctx, `SELECT change_id, operation, removed, occurred_at, `+productColumns+`
+		FROM product_changes JOIN products ON products.id = product_changes.product_id
+		WHERE change_id > $1
+		ORDER BY change_id
+		LIMIT $2`,
+		sequence, postgresChangesBatch)
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (canonical.ProductChange, error) {
+		var (
+			change    canonical.ProductChange
+			sequence  int64
+			operation string
+			removed   bool
+			product   canonical.Product
+		)
+		err := row.Scan(
+			&sequence, &operation, &removed, &change.OccurredAt,
+			&product.ID, &product.StoreID, &product.Name, &product.Description, &product.Price,
+			&product.Category, &product.Status, &product.ImagePath, &product.Version,
+			&product.CreatedAt, &product.UpdatedAt, &product.CreatedBy, &product.UpdatedBy,
+		)
+		product.CreatedAt = product.CreatedAt.UTC()
+		product.UpdatedAt = product.UpdatedAt.UTC()
+
+		change.Token = strconv.FormatInt(sequence, 10)
+		change.ProductID = product.ID
+		change.Product = &product
+		change.OccurredAt = change.OccurredAt.UTC()
+		switch {
+		case operation == "INSERT":
+			change.Type = canonical.EVENT_PRODUCT_CREATED
+		case removed:
+			change.Type = canonical.EVENT_PRODUCT_REMOVED
+		default:
+			change.Type = canonical.EVENT_PRODUCT_UPDATED
+		}
+		return change, err
+	})
+}
+
+// watching hides the error of a watch ended by ctx, as the Mongo change
+// stream does.
+func watching(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// postgresChangeListener holds the connection listening for the
+// notifications of the products trigger while there are watchers, and
+// wakes all of them on each one. It reconnects after an error, waking the
+// watchers too since notifications may have been lost.
+type postgresChangeListener struct {
+	pool *pgxpool.Pool
+
+	mu       sync.Mutex
+	watchers int
+	stop     context.CancelFunc
+	wake     chan struct{}
+}
+
+// subscribe registers a watcher, starting to listen with the first one.
+func (l *postgresChangeListener) subscribe() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.watchers++
+	if l.watchers == 1 {
+		ctx, stop := context.WithCancel(context.Background())
+		l.stop = stop
+		go l.listen(ctx)
+	}
+}
+
+// unsubscribe removes a watcher, stopping to listen with the last one.
+func (l *postgresChangeListener) unsubscribe() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.watchers--
+	if l.watchers == 0 {
+		l.stop()
+	}
+}
+
+// next returns a channel closed on the next notification.
+func (l *postgresChangeListener) next() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.wake
+}
+
+func (l *postgresChangeListener) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+func (l *postgresChangeListener) listen(ctx context.Context) {
+	for {
+		err := l.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Msg("an error occurred when listen to product changes")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(postgresChangesPoll):
+		}
+	}
+}
+
+func (l *postgresChangeListener) listenOnce(ctx context.Context) error {
+	conn, err := pgx.ConnectConfig(ctx, l.pool.Config().ConnConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+postgresChangesChannel); err != nil {
+		return err
+	}
+	// the changes made before listening started are only read now
+	l.broadcast()
+
+	for {
+		if _, err = conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		l.broadcast()
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestToProductChange(t *testing.T) {
+	clusterTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	updatedAt := clusterTime.Add(-time.Second)
+
+	document := func(operation string, product *canonical.Product, updatedFields bson.M) changeDocument {
+		d := changeDocument{
+			OperationType: operation,
+			ClusterTime:   primitive.Timestamp{T: uint32(clusterTime.Unix())},
+			FullDocument:  product,
+		}
+		d.DocumentKey.ID = "product_valid_id"
+		d.UpdateDescription.UpdatedFields = updatedFields
+		return d
+	}
+
+	type Given struct {
+		document changeDocument
+	}
+	type Expected struct {
+		eventType  canonical.EventType
+		occurredAt time.Time
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given insert must be a creation": {
+			given:    Given{document: document("insert", &canonical.Product{ID: "product_valid_id", UpdatedAt: updatedAt}, nil)},
+			expected: Expected{eventType: canonical.EVENT_PRODUCT_CREATED, occurredAt: updatedAt},
+		},
+		"given field update must be an update": {
+			given:    Given{document: document("update", &canonical.Product{ID: "product_valid_id"}, bson.M{"price": 10.0})},
+			expected: Expected{eventType: canonical.EVENT_PRODUCT_UPDATED, occurredAt: clusterTime},
+		},
+		"given deactivation must be a removal": {
+			given:    Given{document: document("update", &canonical.Product{ID: "product_valid_id", Status: canonical.STATUS_INACTIVE}, bson.M{"status": 1})},
+			expected: Expected{eventType: canonical.EVENT_PRODUCT_REMOVED, occurredAt: clusterTime},
+		},
+		"given delete must be a removal": {
+			given:    Given{document: document("delete", nil, nil)},
+			expected: Expected{eventType: canonical.EVENT_PRODUCT_REMOVED, occurredAt: clusterTime},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			change := toProductChange(tc.given.document)
+
+			assert.Equal(t, tc.expected.eventType, change.Type)
+			assert.Equal(t, "product_valid_id", change.ProductID)
+			assert.Equal(t, tc.expected.occurredAt, change.OccurredAt)
+		})
+	}
+}
+
+func TestChangePipeline(t *testing.T) {
+	ctx := tenant.WithStore(context.Background(), "store_1")
+	pipeline := changePipeline(ctx, canonical.ChangeFilter{Category: "drinks", IDs: []string{"1", "2"}})
+
+	assert.Equal(t, mongo.Pipeline{{{Key: "$match", Value: bson.D{
+		{Key: "operationType", Value: bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}},
+		{Key: "fullDocument.store_id", Value: bson.M{"$in": bson.A{"", nil, "store_1"}}},
+		{Key: "fullDocument.category", Value: "drinks"},
+		{Key: "documentKey._id", Value: bson.M{"$in": []string{"1", "2"}}},
+	}}}}, pipeline)
+}
+
+func TestProductWatcher_Watch(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("given expired resume token must report it", func(mt *mtest.T) {
+		watcher := productWatcher{
+			mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    errorCodeChangeStreamHistoryLost,
+			Name:    "ChangeStreamHistoryLost",
+			Message: "resume point may no longer be in the oplog",
+		}))
+
+		err := watcher.Watch(context.Background(), canonical.ChangeFilter{}, "8263", func(canonical.ProductChange) error {
+			return nil
+		})
+
+		assert.ErrorIs(t, err, canonical.ErrorResumeTokenExpired)
+	})
+
+	db.Run("given changes must hand them to handler with their token", func(mt *mtest.T) {
+		watcher := productWatcher{
+			mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "product.product", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: bson.D{{Key: "_data", Value: "8265EDA0"}}},
+			{Key: "operationType", Value: "insert"},
+			{Key: "documentKey", Value: bson.D{{Key: "_id", Value: "product_valid_id"}}},
+			{Key: "fullDocument", Value: bson.D{{Key: "_id", Value: "product_valid_id"}, {Key: "name", Value: "Soda"}}},
+		}))
+
+		var received []canonical.ProductChange
+		stop := context.Canceled
+		err := watcher.Watch(context.Background(), canonical.ChangeFilter{}, "", func(change canonical.ProductChange) error {
+			received = append(received, change)
+			return stop
+		})
+
+		assert.ErrorIs(t, err, stop)
+		assert.Len(t, received, 1)
+		assert.Equal(t, "8265EDA0", received[0].Token)
+		assert.Equal(t, canonical.EVENT_PRODUCT_CREATED, received[0].Type)
+		assert.Equal(t, "Soda", received[0].Product.Name)
+	})
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhookCollection  = "webhook"
+	deliveryCollection = "webhook_delivery"
+
+	duplicateKeyCode = 11000
+)
+
+// WebhookRepository lists and removes the webhooks of the store ctx is
+// scoped to. GetByID and GetByEvent look across stores for the delivery
+// workers.
+type WebhookRepository interface {
+	Create(context.Context, canonical.Webhook) error
+	GetAll(context.Context) ([]canonical.Webhook, error)
+	GetByID(context.Context, string) (*canonical.Webhook, error)
+	GetByEvent(ctx context.Context, eventType canonical.EventType, store string) ([]canonical.Webhook, error)
+	Remove(context.Context, string) error
+}
+
+type webhookRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewWebhookRepo(db *mongo.Database, timeout time.Duration) WebhookRepository {
+	return &webhookRepository{
+		collection: db.Collection(webhookCollection),
+		timeout:    timeout,
+	}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook canonical.Webhook) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.InsertOne(ctx, webhook)
+	return err
+}
+
+func (r *webhookRepository) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, bson.D{{Key: "store_id", Value: ownStore(ctx)}})
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	var webhook canonical.Webhook
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, canonical.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// GetByEvent returns the webhooks subscribed to eventType, including the
+// ones without an event filter, that get the events of store: the webhooks
+// of that store, or every webhook for the base catalog.
+func (r *webhookRepository) GetByEvent(ctx context.Context, eventType canonical.EventType, store string) ([]canonical.Webhook, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	query := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "events", Value: eventType}},
+		bson.D{{Key: "events", Value: bson.D{{Key: "$size", Value: 0}}}},
+		bson.D{{Key: "events", Value: nil}},
+	}}}
+	if store != "" {
+		query = append(query, bson.E{Key: "store_id", Value: store})
+	}
+	return r.find(ctx, query)
+}
+
+func (r *webhookRepository) Remove(ctx context.Context, id string) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}, {Key: "store_id", Value: ownStore(ctx)}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return canonical.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) find(ctx context.Context, query bson.D) ([]canonical.Webhook, error) {
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.Webhook
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, deliveries ...canonical.WebhookDelivery) error
+	Pending(ctx context.Context, now time.Time, limit int) ([]canonical.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, id string, attempt canonical.DeliveryAttempt, status canonical.DeliveryStatus, nextAttempt time.Time) error
+	GetByWebhook(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error)
+}
+
+type deliveryRepository struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewDeliveryRepo(db *mongo.Database, timeout time.Duration) DeliveryRepository {
+	return &deliveryRepository{
+		collection: db.Collection(deliveryCollection),
+		timeout:    timeout,
+	}
+}
+
+// Create stores new deliveries. Deliveries that already exist are skipped,
+// so fanning out the same event twice does not notify a webhook twice.
+func (r *deliveryRepository) Create(ctx context.Context, deliveries ...canonical.WebhookDelivery) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		documents = append(documents, delivery)
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	return ignoreDuplicates(err)
+}
+
+// ignoreDuplicates drops the duplicate key errors of an unordered insert,
+// keeping any other error of the same batch.
+func ignoreDuplicates(err error) error {
+	var bulk mongo.BulkWriteException
+	if !errors.As(err, &bulk) || bulk.WriteConcernError != nil {
+		return err
+	}
+
+	var others []mongo.BulkWriteError
+	for _, writeError := range bulk.WriteErrors {
+		if writeError.Code != duplicateKeyCode {
+			others = append(others, writeError)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+
+	bulk.WriteErrors = others
+	return bulk
+}
+
+// Pending returns the deliveries due for an attempt, oldest first.
+func (r *deliveryRepository) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.WebhookDelivery, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "status", Value: canonical.DELIVERY_PENDING},
+		{Key: "next_attempt_at", Value: bson.M{"$lte": now}},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	return r.find(ctx, filter, findOptions)
+}
+
+func (r *deliveryRepository) RecordAttempt(ctx context.Context, id string, attempt canonical.DeliveryAttempt, status canonical.DeliveryStatus, nextAttempt time.Time) error {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{
+		"$set":  bson.M{"status": status, "next_attempt_at": nextAttempt},
+		"$push": bson.M{"attempts": attempt},
+	})
+	return err
+}
+
+func (r *deliveryRepository) GetByWebhook(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	ctx, cancel := withDeadline(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, bson.D{{Key: "webhook_id", Value: webhookID}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+}
+
+func (r *deliveryRepository) find(ctx context.Context, query bson.D, findOptions *options.FindOptions) ([]canonical.WebhookDelivery, error) {
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []canonical.WebhookDelivery
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
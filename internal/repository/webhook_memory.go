@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tech-challenge-product/internal/canonical"
+	"time"
+)
+
+type memoryWebhookRepository struct {
+	mu       sync.RWMutex
+	webhooks []canonical.Webhook
+}
+
+func (r *memoryWebhookRepository) Create(ctx context.Context, webhook canonical.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.webhooks = append(r.webhooks, webhook)
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	return r.find(func(webhook canonical.Webhook) bool {
+		return ownedBy(ctx, webhook.StoreID)
+	}), nil
+}
+
+func (r *memoryWebhookRepository) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	webhooks := r.find(func(webhook canonical.Webhook) bool {
+		return webhook.ID == id
+	})
+	if len(webhooks) == 0 {
+		return nil, canonical.ErrorNotFound
+	}
+	return &webhooks[0], nil
+}
+
+func (r *memoryWebhookRepository) GetByEvent(ctx context.Context, eventType canonical.EventType, store string) ([]canonical.Webhook, error) {
+	return r.find(func(webhook canonical.Webhook) bool {
+		return webhook.Accepts(eventType) && (store == "" || webhook.StoreID == store)
+	}), nil
+}
+
+func (r *memoryWebhookRepository) Remove(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, webhook := range r.webhooks {
+		if webhook.ID == id && ownedBy(ctx, webhook.StoreID) {
+			r.webhooks = append(r.webhooks[:i], r.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return canonical.ErrorNotFound
+}
+
+// find returns the matching webhooks, oldest first.
+func (r *memoryWebhookRepository) find(match func(canonical.Webhook) bool) []canonical.Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []canonical.Webhook
+	for _, webhook := range r.webhooks {
+		if match(webhook) {
+			results = append(results, webhook)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	return results
+}
+
+type memoryDeliveryRepository struct {
+	mu         sync.RWMutex
+	deliveries map[string]*canonical.WebhookDelivery
+	order      []string
+}
+
+func newMemoryDeliveryRepo() *memoryDeliveryRepository {
+	return &memoryDeliveryRepository{
+		deliveries: map[string]*canonical.WebhookDelivery{},
+	}
+}
+
+// Create stores new deliveries, skipping the ones that already exist like
+// the Mongo repository does.
+func (r *memoryDeliveryRepository) Create(ctx context.Context, deliveries ...canonical.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		if _, ok := r.deliveries[delivery.ID]; ok {
+			continue
+		}
+		delivery := delivery
+		r.deliveries[delivery.ID] = &delivery
+		r.order = append(r.order, delivery.ID)
+	}
+	return nil
+}
+
+func (r *memoryDeliveryRepository) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.WebhookDelivery, error) {
+	results := r.find(func(delivery canonical.WebhookDelivery) bool {
+		return delivery.Status == canonical.DELIVERY_PENDING && !delivery.NextAttemptAt.After(now)
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (r *memoryDeliveryRepository) RecordAttempt(ctx context.Context, id string, attempt canonical.DeliveryAttempt, status canonical.DeliveryStatus, nextAttempt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delivery, ok := r.deliveries[id]; ok {
+		delivery.Status = status
+		delivery.NextAttemptAt = nextAttempt
+		delivery.Attempts = append(delivery.Attempts, attempt)
+	}
+	return nil
+}
+
+func (r *memoryDeliveryRepository) GetByWebhook(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	results := r.find(func(delivery canonical.WebhookDelivery) bool {
+		return delivery.WebhookID == webhookID
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	return results, nil
+}
+
+func (r *memoryDeliveryRepository) find(match func(canonical.WebhookDelivery) bool) []canonical.WebhookDelivery {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []canonical.WebhookDelivery
+	for _, id := range r.order {
+		delivery := *r.deliveries[id]
+		if match(delivery) {
+			delivery.Attempts = append([]canonical.DeliveryAttempt(nil), delivery.Attempts...)
+			results = append(results, delivery)
+		}
+	}
+	return results
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestWebhookRepository_GetByID(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := webhookRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "product.webhook", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "webhook_valid_id"},
+				{Key: "url", Value: "https://partner.example.com/hooks"},
+				{Key: "events", Value: bson.A{"ProductCreated"}},
+			}),
+			mtest.CreateCursorResponse(0, "product.webhook", mtest.FirstBatch),
+		)
+
+		webhook, err := repo.GetByID(context.Background(), "webhook_valid_id")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []canonical.EventType{canonical.EVENT_PRODUCT_CREATED}, webhook.Events)
+
+		_, err = repo.GetByID(context.Background(), "webhook_invalid_id")
+
+		assert.ErrorIs(t, err, canonical.ErrorNotFound)
+	})
+}
+
+func TestWebhookRepository_Remove(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := webhookRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+		)
+
+		assert.Nil(t, repo.Remove(context.Background(), "webhook_valid_id"))
+		assert.ErrorIs(t, repo.Remove(context.Background(), "webhook_invalid_id"), canonical.ErrorNotFound)
+	})
+}
+
+func TestDeliveryRepository_Create(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := deliveryRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}),
+		)
+
+		delivery := canonical.WebhookDelivery{
+			ID:        "event_valid_id:webhook_valid_id",
+			WebhookID: "webhook_valid_id",
+			Status:    canonical.DELIVERY_PENDING,
+		}
+
+		assert.Nil(t, repo.Create(context.Background(), delivery))
+		assert.Nil(t, repo.Create(context.Background(), delivery))
+	})
+}
+
+func TestDeliveryRepository_CreateKeepsOtherWriteErrors(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := deliveryRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(
+			mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"},
+			mtest.WriteError{Index: 1, Code: 121, Message: "document failed validation"},
+		))
+
+		err := repo.Create(context.Background(),
+			canonical.WebhookDelivery{ID: "event_valid_id:webhook_valid_id"},
+			canonical.WebhookDelivery{ID: "event_valid_id:webhook_other_id"},
+		)
+
+		var bulk mongo.BulkWriteException
+		assert.ErrorAs(t, err, &bulk)
+		assert.Len(t, bulk.WriteErrors, 1)
+		assert.Equal(t, 121, bulk.WriteErrors[0].Code)
+	})
+}
+
+func TestWebhookRepository_GetByEvent(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	db.Run("given store event must query the webhooks of the store", func(mt *mtest.T) {
+		repo := webhookRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.webhook", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "webhook_store_1"},
+			{Key: "store_id", Value: "store_1"},
+		}))
+
+		webhooks, err := repo.GetByEvent(context.Background(), canonical.EVENT_PRODUCT_CREATED, "store_1")
+
+		assert.Nil(t, err)
+		assert.Len(t, webhooks, 1)
+		assert.Equal(t, "store_1", webhooks[0].StoreID)
+
+		var filter bson.D
+		assert.Nil(t, mt.GetStartedEvent().Command.Lookup("filter").Unmarshal(&filter))
+		assert.Equal(t, "store_1", filter.Map()["store_id"])
+	})
+
+	db.Run("given base catalog event must query every webhook", func(mt *mtest.T) {
+		repo := webhookRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.webhook", mtest.FirstBatch))
+
+		_, err := repo.GetByEvent(context.Background(), canonical.EVENT_PRODUCT_CREATED, "")
+
+		assert.Nil(t, err)
+
+		var filter bson.D
+		assert.Nil(t, mt.GetStartedEvent().Command.Lookup("filter").Unmarshal(&filter))
+		assert.NotContains(t, filter.Map(), "store_id")
+	})
+}
+
+func TestWebhookRepository_GetAllScopedToStore(t *testing.T) {
+	db := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	db.Run("", func(mt *mtest.T) {
+		repo := webhookRepository{
+			collection: mt.DB.Collection("fake-collection"),
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "product.webhook", mtest.FirstBatch))
+
+		_, err := repo.GetAll(tenant.WithStore(context.Background(), "store_1"))
+
+		assert.Nil(t, err)
+
+		var filter bson.D
+		assert.Nil(t, mt.GetStartedEvent().Command.Lookup("filter").Unmarshal(&filter))
+		assert.Equal(t, "store_1", filter.Map()["store_id"])
+	})
+}
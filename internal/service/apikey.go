@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/repository"
+	"tech-challenge-product/internal/tenant"
+	"time"
+)
+
+const (
+	apiKeyPrefix       = "pk_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+type APIKeyService interface {
+	// Create stores a new key and returns it along with the plaintext key,
+	// which is not kept and cannot be read again.
+	Create(context.Context, canonical.APIKey) (*canonical.APIKey, string, error)
+	GetAll(context.Context) ([]canonical.APIKey, error)
+	Revoke(context.Context, string) error
+	// Authenticate resolves a plaintext key, failing with
+	// canonical.ErrorRejectedAPIKey when it is unknown, expired or revoked.
+	Authenticate(ctx context.Context, key string) (*canonical.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &tracedAPIKeyService{&apiKeyService{
+		repo: repo,
+	}}
+}
+
+// Create binds the key to the store of ctx, so a caller can only hand out
+// access to its own store.
+func (s *apiKeyService) Create(ctx context.Context, key canonical.APIKey) (*canonical.APIKey, string, error) {
+	now := time.Now()
+	if err := validateAPIKey(key, now); err != nil {
+		return nil, "", err
+	}
+
+	store := tenant.Store(ctx)
+	if key.StoreID != "" && key.StoreID != store {
+		return nil, "", fmt.Errorf("%w: store_id must be the store of the request", canonical.ErrorInvalidAPIKey)
+	}
+	key.StoreID = store
+
+	plaintext, err := newAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key.ID = canonical.NewUUID()
+	key.Prefix = plaintext[:apiKeyPrefixLength]
+	key.Hash = hashAPIKey(plaintext)
+	key.RevokedAt = nil
+	key.CreatedAt = now
+	key.CreatedBy = token.Subject(ctx)
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return &key, plaintext, nil
+}
+
+func (s *apiKeyService) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id string) error {
+	return s.repo.Revoke(ctx, id, time.Now())
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, plaintext string) (*canonical.APIKey, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return nil, canonical.ErrorRejectedAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, hashAPIKey(plaintext))
+	if errors.Is(err, canonical.ErrorNotFound) {
+		return nil, canonical.ErrorRejectedAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !key.Active(time.Now()) {
+		return nil, canonical.ErrorRejectedAPIKey
+	}
+
+	return key, nil
+}
+
+func validateAPIKey(key canonical.APIKey, now time.Time) error {
+	if strings.TrimSpace(key.Name) == "" {
+		return fmt.Errorf("%w: name is required", canonical.ErrorInvalidAPIKey)
+	}
+	if len(key.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", canonical.ErrorInvalidAPIKey)
+	}
+	for _, scope := range key.Scopes {
+		if !knownPermission(scope) {
+			return fmt.Errorf("%w: unknown scope %q", canonical.ErrorInvalidAPIKey, scope)
+		}
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", canonical.ErrorInvalidAPIKey)
+	}
+	return nil
+}
+
+func knownPermission(scope string) bool {
+	for _, permission := range rbac.Permissions {
+		if string(permission) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// newAPIKey returns a random key carrying 192 bits of entropy, which is why
+// a plain SHA-256 is enough to store it.
+func newAPIKey() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
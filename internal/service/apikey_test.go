@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	type Given struct {
+		store string
+		key   canonical.APIKey
+	}
+	type Expected struct {
+		err   error
+		store string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given valid key must store its hash": {
+			given: Given{
+				key: canonical.APIKey{Name: "price-sync", Scopes: []string{"product:write"}, ExpiresAt: &future},
+			},
+		},
+		"given key without name must be rejected": {
+			given: Given{
+				key: canonical.APIKey{Scopes: []string{"product:read"}},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidAPIKey,
+			},
+		},
+		"given key without scopes must be rejected": {
+			given: Given{
+				key: canonical.APIKey{Name: "price-sync"},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidAPIKey,
+			},
+		},
+		"given unknown scope must be rejected": {
+			given: Given{
+				key: canonical.APIKey{Name: "price-sync", Scopes: []string{"product:purge"}},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidAPIKey,
+			},
+		},
+		"given expiry in the past must be rejected": {
+			given: Given{
+				key: canonical.APIKey{Name: "price-sync", Scopes: []string{"product:read"}, ExpiresAt: &past},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidAPIKey,
+			},
+		},
+		"given store bound request must bind the key to its store": {
+			given: Given{
+				store: "store_1",
+				key:   canonical.APIKey{Name: "price-sync", Scopes: []string{"product:read"}},
+			},
+			expected: Expected{
+				store: "store_1",
+			},
+		},
+		"given store_id of another store must be rejected": {
+			given: Given{
+				store: "store_1",
+				key:   canonical.APIKey{Name: "price-sync", Scopes: []string{"product:read"}, StoreID: "store_2"},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidAPIKey,
+			},
+		},
+		"given store_id without store in the request must be rejected": {
+			given: Given{
+				key: canonical.APIKey{Name: "price-sync", Scopes: []string{"product:read"}, StoreID: "store_2"},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidAPIKey,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoMock := &APIKeyRepositoryMock{}
+			repoMock.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+			svc := apiKeyService{
+				repo: repoMock,
+			}
+
+			key, plaintext, err := svc.Create(tenant.WithStore(context.Background(), tc.given.store), tc.given.key)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			if tc.expected.err != nil {
+				repoMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NotEmpty(t, key.ID)
+			assert.Equal(t, tc.expected.store, key.StoreID)
+			assert.True(t, strings.HasPrefix(plaintext, key.Prefix))
+			assert.Equal(t, hashAPIKey(plaintext), key.Hash)
+			assert.NotContains(t, key.Hash, plaintext)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	type Given struct {
+		key    string
+		stored *canonical.APIKey
+		err    error
+	}
+	type Expected struct {
+		err error
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given active key must authenticate": {
+			given: Given{
+				key:    "pk_active",
+				stored: &canonical.APIKey{ID: "api_key_valid_id", ExpiresAt: &future},
+			},
+		},
+		"given unknown key must be rejected": {
+			given: Given{
+				key: "pk_unknown",
+				err: canonical.ErrorNotFound,
+			},
+			expected: Expected{
+				err: canonical.ErrorRejectedAPIKey,
+			},
+		},
+		"given expired key must be rejected": {
+			given: Given{
+				key:    "pk_expired",
+				stored: &canonical.APIKey{ID: "api_key_valid_id", ExpiresAt: &past},
+			},
+			expected: Expected{
+				err: canonical.ErrorRejectedAPIKey,
+			},
+		},
+		"given revoked key must be rejected": {
+			given: Given{
+				key:    "pk_revoked",
+				stored: &canonical.APIKey{ID: "api_key_valid_id", RevokedAt: &past},
+			},
+			expected: Expected{
+				err: canonical.ErrorRejectedAPIKey,
+			},
+		},
+		"given key without prefix must be rejected": {
+			given: Given{
+				key: "not-a-key",
+			},
+			expected: Expected{
+				err: canonical.ErrorRejectedAPIKey,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoMock := &APIKeyRepositoryMock{}
+			repoMock.On("GetByHash", mock.Anything, hashAPIKey(tc.given.key)).Return(tc.given.stored, tc.given.err)
+
+			svc := apiKeyService{
+				repo: repoMock,
+			}
+
+			key, err := svc.Authenticate(context.Background(), tc.given.key)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			if tc.expected.err == nil {
+				assert.Equal(t, "api_key_valid_id", key.ID)
+			}
+		})
+	}
+}
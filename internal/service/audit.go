@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/repository"
+)
+
+type AuditService interface {
+	History(ctx context.Context, productID string) ([]canonical.AuditEntry, error)
+	Search(context.Context, canonical.AuditFilter) ([]canonical.AuditEntry, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &tracedAuditService{&auditService{
+		repo: repo,
+	}}
+}
+
+func (s *auditService) History(ctx context.Context, productID string) ([]canonical.AuditEntry, error) {
+	return s.repo.GetByEntity(ctx, productID)
+}
+
+func (s *auditService) Search(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	return s.repo.Find(ctx, filter)
+}
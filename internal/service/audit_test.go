@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditService_History(t *testing.T) {
+	repoMock := &AuditRepositoryMock{}
+	repoMock.On("GetByEntity", mock.Anything, "product_valid_id").Return([]canonical.AuditEntry{
+		{ID: "1", EntityID: "product_valid_id", Action: canonical.AUDIT_UPDATE},
+	}, nil)
+
+	svc := auditService{
+		repo: repoMock,
+	}
+
+	entries, err := svc.History(context.Background(), "product_valid_id")
+
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestAuditService_Search(t *testing.T) {
+	filter := canonical.AuditFilter{
+		Actor: "admin",
+		From:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	type Given struct {
+		repo func() *AuditRepositoryMock
+	}
+	type Expected struct {
+		err assert.ErrorAssertionFunc
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given entries for actor, must return them": {
+			given: Given{
+				repo: func() *AuditRepositoryMock {
+					repoMock := &AuditRepositoryMock{}
+					repoMock.On("Find", mock.Anything, filter).Return([]canonical.AuditEntry{{ID: "1", Actor: "admin"}}, nil)
+					return repoMock
+				},
+			},
+			expected: Expected{
+				err: assert.NoError,
+			},
+		},
+		"given error searching, must return error": {
+			given: Given{
+				repo: func() *AuditRepositoryMock {
+					repoMock := &AuditRepositoryMock{}
+					repoMock.On("Find", mock.Anything, filter).Return([]canonical.AuditEntry(nil), errors.New("error searching"))
+					return repoMock
+				},
+			},
+			expected: Expected{
+				err: assert.Error,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		svc := auditService{
+			repo: tc.given.repo(),
+		}
+
+		_, err := svc.Search(context.Background(), filter)
+
+		tc.expected.err(t, err)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"tech-challenge-product/internal/canonical"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -39,8 +40,190 @@ func (m *ProductRepositoryMock) GetByCategory(ctx context.Context, category stri
 	return args.Get(0).([]canonical.Product), args.Error(1)
 }
 
+func (m *ProductRepositoryMock) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]canonical.Product), args.Error(1)
+}
+
 func (m *ProductRepositoryMock) GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error) {
 	args := m.Called()
 
 	return args.Get(0).([]canonical.Product), args.Error(1)
 }
+
+func (m *ProductRepositoryMock) Touch(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+type AuditRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *AuditRepositoryMock) Create(ctx context.Context, entry canonical.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *AuditRepositoryMock) GetByEntity(ctx context.Context, entityID string) ([]canonical.AuditEntry, error) {
+	args := m.Called(ctx, entityID)
+	return args.Get(0).([]canonical.AuditEntry), args.Error(1)
+}
+
+func (m *AuditRepositoryMock) Find(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]canonical.AuditEntry), args.Error(1)
+}
+
+func newAuditRepositoryMock() *AuditRepositoryMock {
+	auditMock := &AuditRepositoryMock{}
+	auditMock.On("Create", mock.Anything, mock.Anything).Return(nil)
+	return auditMock
+}
+
+type OutboxRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OutboxRepositoryMock) Add(ctx context.Context, events ...canonical.Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func (m *OutboxRepositoryMock) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.OutboxMessage, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]canonical.OutboxMessage), args.Error(1)
+}
+
+func (m *OutboxRepositoryMock) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *OutboxRepositoryMock) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	args := m.Called(ctx, id, reason, nextAttempt)
+	return args.Error(0)
+}
+
+func newOutboxRepositoryMock() *OutboxRepositoryMock {
+	outboxMock := &OutboxRepositoryMock{}
+	outboxMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+	return outboxMock
+}
+
+// TransactorMock runs the transaction body inline.
+type TransactorMock struct{}
+
+func (TransactorMock) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type WebhookRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *WebhookRepositoryMock) Create(ctx context.Context, webhook canonical.Webhook) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (m *WebhookRepositoryMock) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]canonical.Webhook), args.Error(1)
+}
+
+func (m *WebhookRepositoryMock) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *WebhookRepositoryMock) GetByEvent(ctx context.Context, eventType canonical.EventType, store string) ([]canonical.Webhook, error) {
+	args := m.Called(ctx, eventType, store)
+	return args.Get(0).([]canonical.Webhook), args.Error(1)
+}
+
+func (m *WebhookRepositoryMock) Remove(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type DeliveryRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *DeliveryRepositoryMock) Create(ctx context.Context, deliveries ...canonical.WebhookDelivery) error {
+	args := m.Called(ctx, deliveries)
+	return args.Error(0)
+}
+
+func (m *DeliveryRepositoryMock) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]canonical.WebhookDelivery), args.Error(1)
+}
+
+func (m *DeliveryRepositoryMock) RecordAttempt(ctx context.Context, id string, attempt canonical.DeliveryAttempt, status canonical.DeliveryStatus, nextAttempt time.Time) error {
+	args := m.Called(ctx, id, attempt, status, nextAttempt)
+	return args.Error(0)
+}
+
+func (m *DeliveryRepositoryMock) GetByWebhook(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID)
+	return args.Get(0).([]canonical.WebhookDelivery), args.Error(1)
+}
+
+type APIKeyRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *APIKeyRepositoryMock) Create(ctx context.Context, key canonical.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *APIKeyRepositoryMock) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]canonical.APIKey), args.Error(1)
+}
+
+func (m *APIKeyRepositoryMock) GetByHash(ctx context.Context, hash string) (*canonical.APIKey, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *APIKeyRepositoryMock) Revoke(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+type PriceOverrideRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PriceOverrideRepositoryMock) Set(ctx context.Context, override canonical.PriceOverride) error {
+	args := m.Called(ctx, override)
+	return args.Error(0)
+}
+
+func (m *PriceOverrideRepositoryMock) Get(ctx context.Context, productID string) (*canonical.PriceOverride, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.PriceOverride), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *PriceOverrideRepositoryMock) GetByProducts(ctx context.Context, productIDs []string) ([]canonical.PriceOverride, error) {
+	args := m.Called(ctx, productIDs)
+	return args.Get(0).([]canonical.PriceOverride), args.Error(1)
+}
+
+func (m *PriceOverrideRepositoryMock) Remove(ctx context.Context, productID string) error {
+	args := m.Called(ctx, productID)
+	return args.Error(0)
+}
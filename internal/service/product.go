@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"tech-challenge-product/internal/auth/token"
 	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/logging"
 	"tech-challenge-product/internal/repository"
-
-	"github.com/rs/zerolog/log"
+	"tech-challenge-product/internal/tenant"
+	"time"
 )
 
 type ProductService interface {
@@ -14,67 +17,344 @@ type ProductService interface {
 	Update(context.Context, string, canonical.Product) error
 	GetByID(context.Context, string) (*canonical.Product, error)
 	GetByCategory(context.Context, string) ([]canonical.Product, error)
-	Remove(context.Context, string) error
+	Find(context.Context, canonical.ProductFilter) ([]canonical.Product, error)
+	Remove(ctx context.Context, id string, version int64) error
 	GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error)
+	Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error
+	SetPrice(ctx context.Context, id string, price float64) error
+	RemovePrice(ctx context.Context, id string) error
 }
 
 type productService struct {
-	repo repository.ProductRepository
+	repo      repository.ProductRepository
+	audit     repository.AuditRepository
+	outbox    repository.OutboxRepository
+	tx        repository.Transactor
+	watcher   repository.ProductWatcher
+	overrides repository.PriceOverrideRepository
+	now       func() time.Time
+	newID     func() string
 }
 
-func NewProductService() ProductService {
-	return &productService{
-		repo: repository.NewProductRepo(),
-	}
+func NewProductService(
+	repo repository.ProductRepository,
+	audit repository.AuditRepository,
+	outbox repository.OutboxRepository,
+	tx repository.Transactor,
+	watcher repository.ProductWatcher,
+	overrides repository.PriceOverrideRepository,
+) ProductService {
+	return &tracedProductService{&productService{
+		repo:      repo,
+		audit:     audit,
+		outbox:    outbox,
+		tx:        tx,
+		watcher:   watcher,
+		overrides: overrides,
+		now:       time.Now,
+		newID:     canonical.NewUUID,
+	}}
 }
 
 func (s *productService) GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error) {
-	return s.repo.GetProductsWithId(ctx, ids)
+	products, err := s.repo.GetProductsWithId(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyOverrides(ctx, products)
 }
 
 func (s *productService) GetAll(ctx context.Context) ([]canonical.Product, error) {
-	return s.repo.GetAll(ctx)
+	products, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyOverrides(ctx, products)
 }
 
 func (s *productService) Create(ctx context.Context, product *canonical.Product) (*canonical.Product, error) {
-	product.ID = canonical.NewUUID()
+	product.ID = s.newID()
+	product.StoreID = tenant.Store(ctx)
+	product.CreatedAt = s.now()
+	product.UpdatedAt = product.CreatedAt
+	product.CreatedBy = token.Subject(ctx)
+	product.UpdatedBy = product.CreatedBy
+
+	var created *canonical.Product
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		p, err := s.repo.Create(ctx, product)
+		if err != nil {
+			return err
+		}
 
-	p, err := s.repo.Create(ctx, product)
+		created = p
+		return s.record(ctx, canonical.AUDIT_CREATE, canonical.Product{}, *p)
+	})
 	if err != nil {
-		log.Error().Err(err).Msg("an error occurred when create product")
+		logging.From(ctx).Error().Err(err).Msg("an error occurred when create product")
 		return nil, err
 	}
 
-	return p, nil
+	return created, nil
 }
 
 func (s *productService) Update(ctx context.Context, id string, updatedProduct canonical.Product) error {
 	if updatedProduct.ID == "" {
 		updatedProduct.ID = id
 	}
-	return s.repo.Update(ctx, id, updatedProduct)
+	updatedProduct.UpdatedAt = s.now()
+	updatedProduct.UpdatedBy = token.Subject(ctx)
+
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return canonical.ErrorNotFound
+		}
+		if before.StoreID != tenant.Store(ctx) {
+			return canonical.ErrorBaseProduct
+		}
+		// updates never reactivate a removed product
+		updatedProduct.Status = before.Status
+
+		if err = s.repo.Update(ctx, id, updatedProduct); err != nil {
+			return err
+		}
+
+		return s.record(ctx, canonical.AUDIT_UPDATE, *before, stored(*before, updatedProduct))
+	})
 }
 
 func (s *productService) GetByID(ctx context.Context, id string) (*canonical.Product, error) {
-	return s.repo.GetByID(ctx, id)
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil || product == nil {
+		return product, err
+	}
+
+	products, err := s.applyOverrides(ctx, []canonical.Product{*product})
+	if err != nil {
+		return nil, err
+	}
+	return &products[0], nil
 }
 
 func (s *productService) GetByCategory(ctx context.Context, id string) ([]canonical.Product, error) {
-	return s.repo.GetByCategory(ctx, id)
+	products, err := s.repo.GetByCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyOverrides(ctx, products)
 }
 
-func (s *productService) Remove(ctx context.Context, id string) error {
-	product, err := s.repo.GetByID(ctx, id)
+func (s *productService) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	products, err := s.repo.Find(ctx, filter)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return s.applyOverrides(ctx, products)
+}
+
+// Watch streams product changes as they are committed. It blocks until ctx
+// is done or handle fails.
+func (s *productService) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	return s.watcher.Watch(ctx, filter, resumeToken, func(change canonical.ProductChange) error {
+		if change.Product != nil {
+			products, err := s.applyOverrides(ctx, []canonical.Product{*change.Product})
+			if err != nil {
+				return err
+			}
+			change.Product = &products[0]
+		}
+		return handle(change)
+	})
+}
+
+// SetPrice overrides the price of a base catalog product for the store ctx
+// is scoped to. The product is touched in the same transaction, so watchers
+// and updated_since listings see the new price.
+func (s *productService) SetPrice(ctx context.Context, id string, price float64) error {
+	if tenant.Store(ctx) == "" {
+		return canonical.ErrorStoreRequired
+	}
+	if price < 0 {
+		return fmt.Errorf("%w: price must not be negative", canonical.ErrorInvalidPriceOverride)
+	}
+
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.visibleProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+		if before.StoreID != "" {
+			return fmt.Errorf("%w: only base catalog products can be overridden", canonical.ErrorInvalidPriceOverride)
+		}
+
+		after := *before
+		after.Price = price
+		after.Version = before.Version + 1
+		after.UpdatedAt = s.now()
+		after.UpdatedBy = token.Subject(ctx)
+		after.Override = &canonical.PriceOverride{
+			StoreID:   tenant.Store(ctx),
+			ProductID: id,
+			Price:     price,
+			UpdatedAt: after.UpdatedAt,
+			UpdatedBy: after.UpdatedBy,
+		}
+
+		if err = s.overrides.Set(ctx, *after.Override); err != nil {
+			return err
+		}
+		if err = s.repo.Touch(ctx, id, after.UpdatedAt); err != nil {
+			return err
+		}
+
+		return s.record(ctx, canonical.AUDIT_UPDATE, *before, after)
+	})
+}
+
+// RemovePrice drops the price override of the store ctx is scoped to, so
+// the base catalog price applies again. Like SetPrice it touches the
+// product.
+func (s *productService) RemovePrice(ctx context.Context, id string) error {
+	if tenant.Store(ctx) == "" {
+		return canonical.ErrorStoreRequired
+	}
+
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.visibleProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = s.overrides.Remove(ctx, id); err != nil {
+			return err
+		}
+		now := s.now()
+		if err = s.repo.Touch(ctx, id, now); err != nil {
+			return err
+		}
+
+		after, err := s.visibleProduct(ctx, id)
+		if err != nil {
+			return err
+		}
+		after.UpdatedAt = now
+		after.UpdatedBy = token.Subject(ctx)
+
+		return s.record(ctx, canonical.AUDIT_UPDATE, *before, *after)
+	})
+}
+
+// visibleProduct returns the product id as seen by the store ctx is scoped
+// to, failing with canonical.ErrorNotFound when it is not visible there.
+func (s *productService) visibleProduct(ctx context.Context, id string) (*canonical.Product, error) {
+	products, err := s.GetProductsWithId(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, canonical.ErrorNotFound
 	}
-	if product == nil {
-		return canonical.ErrorNotFound
+	return &products[0], nil
+}
+
+// applyOverrides replaces the price of base catalog products with the
+// overrides of the store ctx is scoped to.
+func (s *productService) applyOverrides(ctx context.Context, products []canonical.Product) ([]canonical.Product, error) {
+	if tenant.Store(ctx) == "" {
+		return products, nil
 	}
-	product.Status = 1
-	err = s.repo.Update(ctx, id, *product)
+
+	var ids []string
+	for _, product := range products {
+		if product.StoreID == "" {
+			ids = append(ids, product.ID)
+		}
+	}
+
+	overrides, err := s.overrides.GetByProducts(ctx, ids)
 	if err != nil {
+		return nil, err
+	}
+
+	byProduct := map[string]canonical.PriceOverride{}
+	for _, override := range overrides {
+		byProduct[override.ProductID] = override
+	}
+	for i := range products {
+		if override, ok := byProduct[products[i].ID]; ok && products[i].StoreID == "" {
+			products[i].Price = override.Price
+			products[i].Override = &override
+		}
+	}
+	return products, nil
+}
+
+func (s *productService) Remove(ctx context.Context, id string, version int64) error {
+	return s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		product, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return canonical.ErrorNotFound
+		}
+		if product.StoreID != tenant.Store(ctx) {
+			return canonical.ErrorBaseProduct
+		}
+		if product.Version != version {
+			return canonical.ErrorVersionMismatch
+		}
+
+		before := *product
+		product.Status = 1
+		product.UpdatedAt = s.now()
+		product.UpdatedBy = token.Subject(ctx)
+		err = s.repo.Update(ctx, id, *product)
+		if err != nil {
+			return err
+		}
+
+		return s.record(ctx, canonical.AUDIT_REMOVE, before, stored(before, *product))
+	})
+}
+
+// stored is the product as the repository keeps it once updated was
+// written over before: the identity, ownership and creation fields are
+// kept and the version is incremented.
+func stored(before, updated canonical.Product) canonical.Product {
+	updated.ID = before.ID
+	updated.StoreID = before.StoreID
+	updated.Version = before.Version + 1
+	updated.CreatedAt = before.CreatedAt
+	updated.CreatedBy = before.CreatedBy
+	return updated
+}
+
+// record stores the audit entry and the domain events for a change. It must
+// run in the same transaction as the change so neither can be lost.
+func (s *productService) record(ctx context.Context, action canonical.AuditAction, before, after canonical.Product) error {
+	entry := canonical.AuditEntry{
+		ID:        s.newID(),
+		EntityID:  after.ID,
+		StoreID:   tenant.Store(ctx),
+		Action:    action,
+		Actor:     token.Subject(ctx),
+		Timestamp: after.UpdatedAt,
+		Changes:   canonical.Diff(before, after),
+	}
+
+	if err := s.audit.Create(ctx, entry); err != nil {
 		return err
 	}
-	return nil
+
+	events := canonical.ProductEvents(action, before, after)
+	for i := range events {
+		events[i].StoreID = entry.StoreID
+	}
+
+	return s.outbox.Add(ctx, events...)
 }
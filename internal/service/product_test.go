@@ -3,18 +3,19 @@ package service
 import (
 	"context"
 	"errors"
+	"tech-challenge-product/internal/auth/token"
 	"tech-challenge-product/internal/canonical"
 	"tech-challenge-product/internal/repository"
+	"tech-challenge-product/internal/tenant"
 	"testing"
 	"time"
 
+	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"github.com/undefinedlabs/go-mpatch"
 )
 
 func TestProductService_GetByID(t *testing.T) {
-
 	type Given struct {
 		id          string
 		productRepo func() repository.ProductRepository
@@ -54,7 +55,12 @@ func TestProductService_GetByID(t *testing.T) {
 
 	for _, tc := range tests {
 		svc := productService{
-			repo: tc.given.productRepo(),
+			now:    fixedNow,
+			newID:  fixedID,
+			repo:   tc.given.productRepo(),
+			audit:  newAuditRepositoryMock(),
+			outbox: newOutboxRepositoryMock(),
+			tx:     TransactorMock{},
 		}
 		_, err := svc.GetByID(context.Background(), tc.given.id)
 
@@ -63,7 +69,6 @@ func TestProductService_GetByID(t *testing.T) {
 }
 
 func TestProductService_GetAll(t *testing.T) {
-
 	type Given struct {
 		productRepo func() repository.ProductRepository
 	}
@@ -110,7 +115,12 @@ func TestProductService_GetAll(t *testing.T) {
 
 	for _, tc := range tests {
 		svc := productService{
-			repo: tc.given.productRepo(),
+			now:    fixedNow,
+			newID:  fixedID,
+			repo:   tc.given.productRepo(),
+			audit:  newAuditRepositoryMock(),
+			outbox: newOutboxRepositoryMock(),
+			tx:     TransactorMock{},
 		}
 
 		_, err := svc.GetAll(context.Background())
@@ -120,7 +130,6 @@ func TestProductService_GetAll(t *testing.T) {
 }
 
 func TestProductService_GetByCategory(t *testing.T) {
-
 	type Given struct {
 		category    string
 		productRepo func() repository.ProductRepository
@@ -169,7 +178,12 @@ func TestProductService_GetByCategory(t *testing.T) {
 
 	for _, tc := range tests {
 		svc := productService{
-			repo: tc.given.productRepo(),
+			now:    fixedNow,
+			newID:  fixedID,
+			repo:   tc.given.productRepo(),
+			audit:  newAuditRepositoryMock(),
+			outbox: newOutboxRepositoryMock(),
+			tx:     TransactorMock{},
 		}
 		_, err := svc.GetByCategory(context.Background(), tc.given.category)
 
@@ -178,14 +192,6 @@ func TestProductService_GetByCategory(t *testing.T) {
 }
 
 func TestProductService_Create(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-	mpatch.PatchMethod(canonical.NewUUID, func() string {
-		return "product_valid_id"
-	})
-
 	type Given struct {
 		product     *canonical.Product
 		productRepo func() repository.ProductRepository
@@ -216,6 +222,8 @@ func TestProductService_Create(t *testing.T) {
 						Category:    "product_valid_category",
 						Status:      0,
 						ImagePath:   "product_valid_imgpath",
+						CreatedAt:   time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC),
+						UpdatedAt:   time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC),
 					}
 					repoMock := &ProductRepositoryMock{}
 					repoMock.On("Create", mock.Anything, product).Return(product, nil)
@@ -250,7 +258,12 @@ func TestProductService_Create(t *testing.T) {
 
 	for _, tc := range tests {
 		svc := productService{
-			repo: tc.given.productRepo(),
+			now:    fixedNow,
+			newID:  fixedID,
+			repo:   tc.given.productRepo(),
+			audit:  newAuditRepositoryMock(),
+			outbox: newOutboxRepositoryMock(),
+			tx:     TransactorMock{},
 		}
 		_, err := svc.Create(context.Background(), tc.given.product)
 
@@ -259,14 +272,6 @@ func TestProductService_Create(t *testing.T) {
 }
 
 func TestProductService_Update(t *testing.T) {
-
-	mpatch.PatchMethod(time.Now, func() time.Time {
-		return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
-	})
-	mpatch.PatchMethod(canonical.NewUUID, func() string {
-		return "product_valid_id"
-	})
-
 	type Given struct {
 		product     canonical.Product
 		productID   string
@@ -299,8 +304,10 @@ func TestProductService_Update(t *testing.T) {
 						Category:    "product_valid_category",
 						Status:      0,
 						ImagePath:   "product_valid_imgpath",
+						UpdatedAt:   time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC),
 					}
 					repoMock := &ProductRepositoryMock{}
+					repoMock.On("GetByID", mock.Anything, "product_valid_id").Return(&canonical.Product{ID: "product_valid_id"}, nil)
 					repoMock.On("Update", mock.Anything, "product_valid_id", product).Return(nil)
 					return repoMock
 				},
@@ -322,6 +329,7 @@ func TestProductService_Update(t *testing.T) {
 				},
 				productRepo: func() repository.ProductRepository {
 					repoMock := &ProductRepositoryMock{}
+					repoMock.On("GetByID", mock.Anything, mock.Anything).Return(&canonical.Product{ID: "product_valid_id"}, nil)
 					repoMock.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("error creating product"))
 					return repoMock
 				},
@@ -334,7 +342,12 @@ func TestProductService_Update(t *testing.T) {
 
 	for _, tc := range tests {
 		svc := productService{
-			repo: tc.given.productRepo(),
+			now:    fixedNow,
+			newID:  fixedID,
+			repo:   tc.given.productRepo(),
+			audit:  newAuditRepositoryMock(),
+			outbox: newOutboxRepositoryMock(),
+			tx:     TransactorMock{},
 		}
 
 		err := svc.Update(context.Background(), tc.given.productID, tc.given.product)
@@ -344,9 +357,9 @@ func TestProductService_Update(t *testing.T) {
 }
 
 func TestProductService_Remove(t *testing.T) {
-
 	type Given struct {
 		id          string
+		version     int64
 		productRepo func() repository.ProductRepository
 	}
 	type Expected struct {
@@ -379,6 +392,7 @@ func TestProductService_Remove(t *testing.T) {
 						Category:    "product_valid_category",
 						Status:      1,
 						ImagePath:   "product_valid_imgpath",
+						UpdatedAt:   time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC),
 					}).Return(nil)
 					return repoMock
 				},
@@ -421,6 +435,27 @@ func TestProductService_Remove(t *testing.T) {
 				err: assert.Error,
 			},
 		},
+		"given stale version, must return version mismatch without updating": {
+			given: Given{
+				id:      "product_valid_id",
+				version: 1,
+				productRepo: func() repository.ProductRepository {
+					repoMock := &ProductRepositoryMock{}
+					repoMock.On("GetByID", mock.Anything, "product_valid_id").Return(&canonical.Product{
+						ID:      "product_valid_id",
+						Name:    "product_valid_name",
+						Status:  0,
+						Version: 2,
+					}, nil)
+					return repoMock
+				},
+			},
+			expected: Expected{
+				err: func(t assert.TestingT, err error, i ...interface{}) bool {
+					return assert.ErrorIs(t, err, canonical.ErrorVersionMismatch, i...)
+				},
+			},
+		},
 		"given error on product update, must return error": {
 			given: Given{
 				id: "product_valid_id",
@@ -444,6 +479,7 @@ func TestProductService_Remove(t *testing.T) {
 						Category:    "product_valid_category",
 						Status:      1,
 						ImagePath:   "product_valid_imgpath",
+						UpdatedAt:   time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC),
 					}).Return(errors.New("error updating product"))
 					return repoMock
 				},
@@ -456,9 +492,14 @@ func TestProductService_Remove(t *testing.T) {
 
 	for _, tc := range tests {
 		svc := productService{
-			repo: tc.given.productRepo(),
+			now:    fixedNow,
+			newID:  fixedID,
+			repo:   tc.given.productRepo(),
+			audit:  newAuditRepositoryMock(),
+			outbox: newOutboxRepositoryMock(),
+			tx:     TransactorMock{},
 		}
-		err := svc.Remove(context.Background(), tc.given.id)
+		err := svc.Remove(context.Background(), tc.given.id, tc.given.version)
 
 		tc.expected.err(t, err)
 	}
@@ -468,7 +509,9 @@ func TestGetProductsWithId(t *testing.T) {
 	mock := &ProductRepositoryMock{}
 
 	svc := productService{
-		repo: mock,
+		now:   fixedNow,
+		newID: fixedID,
+		repo:  mock,
 	}
 
 	mock.On("GetProductsWithId").Return([]canonical.Product{
@@ -490,3 +533,184 @@ func TestGetProductsWithId(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, products)
 }
+
+func TestProductService_RecordsChanges(t *testing.T) {
+	ctx := token.WithClaims(context.Background(), jwt.MapClaims{"sub": "user_valid_subject"})
+	now := time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
+
+	repoMock := &ProductRepositoryMock{}
+	auditMock := &AuditRepositoryMock{}
+	outboxMock := &OutboxRepositoryMock{}
+	svc := productService{
+		now:    fixedNow,
+		newID:  fixedID,
+		repo:   repoMock,
+		audit:  auditMock,
+		outbox: outboxMock,
+		tx:     TransactorMock{},
+	}
+
+	created := &canonical.Product{
+		ID:        "product_valid_id",
+		Name:      "product_valid_name",
+		CreatedAt: now,
+		UpdatedAt: now,
+		CreatedBy: "user_valid_subject",
+		UpdatedBy: "user_valid_subject",
+	}
+	repoMock.On("Create", mock.Anything, created).Return(created, nil)
+	auditMock.On("Create", mock.Anything, canonical.AuditEntry{
+		ID:        "product_valid_id",
+		EntityID:  "product_valid_id",
+		Action:    canonical.AUDIT_CREATE,
+		Actor:     "user_valid_subject",
+		Timestamp: now,
+		Changes:   []canonical.FieldChange{{Field: "name", Before: "", After: "product_valid_name"}},
+	}).Return(nil)
+
+	outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(events []canonical.Event) bool {
+		return len(events) == 1 && events[0].Type == canonical.EVENT_PRODUCT_CREATED
+	})).Return(nil).Once()
+
+	_, err := svc.Create(ctx, &canonical.Product{Name: "product_valid_name"})
+	assert.NoError(t, err)
+
+	repoMock.On("GetByID", mock.Anything, "product_valid_id").Return(&canonical.Product{ID: "product_valid_id", Name: "product_old_name"}, nil)
+	repoMock.On("Update", mock.Anything, "product_valid_id", canonical.Product{
+		ID:        "product_valid_id",
+		Name:      "product_valid_name",
+		UpdatedAt: now,
+		UpdatedBy: "user_valid_subject",
+		Version:   1,
+	}).Return(nil)
+	auditMock.On("Create", mock.Anything, canonical.AuditEntry{
+		ID:        "product_valid_id",
+		EntityID:  "product_valid_id",
+		Action:    canonical.AUDIT_UPDATE,
+		Actor:     "user_valid_subject",
+		Timestamp: now,
+		Changes:   []canonical.FieldChange{{Field: "name", Before: "product_old_name", After: "product_valid_name"}},
+	}).Return(nil)
+
+	outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(events []canonical.Event) bool {
+		return len(events) == 1 && events[0].Type == canonical.EVENT_PRODUCT_UPDATED && events[0].AggregateID == "product_valid_id"
+	})).Return(nil).Once()
+
+	err = svc.Update(ctx, "product_valid_id", canonical.Product{Name: "product_valid_name", Version: 1})
+	assert.NoError(t, err)
+
+	repoMock.AssertExpectations(t)
+	auditMock.AssertExpectations(t)
+	outboxMock.AssertExpectations(t)
+}
+
+func TestProductService_UpdatePriceEvents(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	outboxMock := &OutboxRepositoryMock{}
+	svc := productService{
+		now:    fixedNow,
+		newID:  fixedID,
+		repo:   repoMock,
+		audit:  newAuditRepositoryMock(),
+		outbox: outboxMock,
+		tx:     TransactorMock{},
+	}
+
+	repoMock.On("GetByID", mock.Anything, "product_valid_id").Return(&canonical.Product{ID: "product_valid_id", Price: 10}, nil)
+	repoMock.On("Update", mock.Anything, "product_valid_id", mock.Anything).Return(nil)
+	outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(events []canonical.Event) bool {
+		return len(events) == 2 &&
+			events[0].Type == canonical.EVENT_PRODUCT_UPDATED &&
+			events[1].Type == canonical.EVENT_PRODUCT_PRICE_CHANGED &&
+			events[1].Changes[0] == canonical.FieldChange{Field: "price", Before: 10.0, After: 12.5}
+	})).Return(nil)
+
+	err := svc.Update(context.Background(), "product_valid_id", canonical.Product{Price: 12.5})
+
+	assert.NoError(t, err)
+	outboxMock.AssertExpectations(t)
+}
+
+func TestProductService_RecordFailureAbortsChange(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	outboxMock := &OutboxRepositoryMock{}
+	svc := productService{
+		now:    fixedNow,
+		newID:  fixedID,
+		repo:   repoMock,
+		audit:  newAuditRepositoryMock(),
+		outbox: outboxMock,
+		tx:     TransactorMock{},
+	}
+
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(&canonical.Product{ID: "product_valid_id"}, nil)
+	outboxMock.On("Add", mock.Anything, mock.Anything).Return(errors.New("error writing outbox"))
+
+	_, err := svc.Create(context.Background(), &canonical.Product{Name: "product_valid_name"})
+
+	assert.Error(t, err)
+}
+
+func TestProductService_UpdateKeepsStatus(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	svc := productService{
+		now:    fixedNow,
+		newID:  fixedID,
+		repo:   repoMock,
+		audit:  newAuditRepositoryMock(),
+		outbox: newOutboxRepositoryMock(),
+		tx:     TransactorMock{},
+	}
+
+	repoMock.On("GetByID", mock.Anything, "product_valid_id").Return(&canonical.Product{ID: "product_valid_id", Status: canonical.STATUS_INACTIVE}, nil)
+	repoMock.On("Update", mock.Anything, "product_valid_id", mock.MatchedBy(func(product canonical.Product) bool {
+		return product.Status == canonical.STATUS_INACTIVE
+	})).Return(nil)
+
+	err := svc.Update(context.Background(), "product_valid_id", canonical.Product{Name: "renamed", Status: canonical.STATUS_ACTIVE})
+
+	assert.NoError(t, err)
+	repoMock.AssertExpectations(t)
+}
+
+func TestProductService_UpdateEventSnapshot(t *testing.T) {
+	created := time.Date(2020, 10, 01, 00, 00, 00, 0, time.UTC)
+	repoMock := &ProductRepositoryMock{}
+	outboxMock := &OutboxRepositoryMock{}
+	svc := productService{
+		now:    fixedNow,
+		newID:  fixedID,
+		repo:   repoMock,
+		audit:  newAuditRepositoryMock(),
+		outbox: outboxMock,
+		tx:     TransactorMock{},
+	}
+
+	ctx := tenant.WithStore(context.Background(), "store-1")
+	repoMock.On("GetByID", mock.Anything, "product_valid_id").Return(&canonical.Product{
+		ID: "product_valid_id", StoreID: "store-1", Version: 3, CreatedAt: created, CreatedBy: "creator",
+	}, nil)
+	repoMock.On("Update", mock.Anything, "product_valid_id", mock.Anything).Return(nil)
+	outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(events []canonical.Event) bool {
+		product := events[0].Product
+		return product.ID == "product_valid_id" &&
+			product.StoreID == "store-1" &&
+			product.Version == 4 &&
+			product.CreatedAt.Equal(created) &&
+			product.CreatedBy == "creator"
+	})).Return(nil)
+
+	err := svc.Update(ctx, "product_valid_id", canonical.Product{Name: "renamed", Version: 3})
+
+	assert.NoError(t, err)
+	outboxMock.AssertExpectations(t)
+}
+
+// fixedNow and fixedID make the products written by the service predictable.
+func fixedNow() time.Time {
+	return time.Date(2020, 11, 01, 00, 00, 00, 0, time.UTC)
+}
+
+func fixedID() string {
+	return "product_valid_id"
+}
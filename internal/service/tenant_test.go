@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/repository"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductService_AppliesPriceOverrides(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	overridesMock := &PriceOverrideRepositoryMock{}
+	svc := productService{
+		now:       fixedNow,
+		newID:     fixedID,
+		repo:      repoMock,
+		overrides: overridesMock,
+	}
+
+	repoMock.On("GetAll", mock.Anything).Return([]canonical.Product{
+		{ID: "product_base_id", Price: 10},
+		{ID: "product_store_id", StoreID: "store_1", Price: 7},
+	}, nil)
+	overridesMock.On("GetByProducts", mock.Anything, []string{"product_base_id"}).Return([]canonical.PriceOverride{
+		{StoreID: "store_1", ProductID: "product_base_id", Price: 12.5},
+	}, nil)
+
+	products, err := svc.GetAll(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 10.0, products[0].Price)
+	overridesMock.AssertNotCalled(t, "GetByProducts", mock.Anything, mock.Anything)
+
+	products, err = svc.GetAll(tenant.WithStore(context.Background(), "store_1"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 12.5, products[0].Price)
+	assert.Equal(t, 7.0, products[1].Price)
+}
+
+func TestProductService_StoreCanNotChangeBaseCatalog(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	svc := productService{
+		now:       fixedNow,
+		newID:     fixedID,
+		repo:      repoMock,
+		audit:     newAuditRepositoryMock(),
+		outbox:    newOutboxRepositoryMock(),
+		tx:        TransactorMock{},
+		overrides: &PriceOverrideRepositoryMock{},
+	}
+
+	repoMock.On("GetByID", mock.Anything, "product_base_id").Return(&canonical.Product{ID: "product_base_id", Price: 10}, nil)
+
+	ctx := tenant.WithStore(context.Background(), "store_1")
+
+	err := svc.Update(ctx, "product_base_id", canonical.Product{Price: 12.5})
+	assert.ErrorIs(t, err, canonical.ErrorBaseProduct)
+
+	err = svc.Remove(ctx, "product_base_id", 0)
+	assert.ErrorIs(t, err, canonical.ErrorBaseProduct)
+
+	repoMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_CreateInStore(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	svc := productService{
+		now:    fixedNow,
+		newID:  fixedID,
+		repo:   repoMock,
+		audit:  newAuditRepositoryMock(),
+		outbox: newOutboxRepositoryMock(),
+		tx:     TransactorMock{},
+	}
+
+	repoMock.On("Create", mock.Anything, mock.MatchedBy(func(product *canonical.Product) bool {
+		return product.StoreID == "store_1"
+	})).Return(&canonical.Product{ID: "product_valid_id", StoreID: "store_1"}, nil)
+
+	product, err := svc.Create(tenant.WithStore(context.Background(), "store_1"), &canonical.Product{Name: "product_valid_name", StoreID: "store_2"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "store_1", product.StoreID)
+}
+
+func TestProductService_SetPrice(t *testing.T) {
+	type Given struct {
+		store   string
+		price   float64
+		product *canonical.Product
+	}
+	type Expected struct {
+		err error
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given base catalog product must store the override and record it": {
+			given: Given{
+				store:   "store_1",
+				price:   12.5,
+				product: &canonical.Product{ID: "product_valid_id", Price: 10},
+			},
+		},
+		"given no store must be refused": {
+			given: Given{
+				price: 12.5,
+			},
+			expected: Expected{
+				err: canonical.ErrorStoreRequired,
+			},
+		},
+		"given negative price must be refused": {
+			given: Given{
+				store: "store_1",
+				price: -1,
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidPriceOverride,
+			},
+		},
+		"given product of the store must be refused": {
+			given: Given{
+				store:   "store_1",
+				price:   12.5,
+				product: &canonical.Product{ID: "product_valid_id", StoreID: "store_1", Price: 10},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidPriceOverride,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoMock := &ProductRepositoryMock{}
+			var products []canonical.Product
+			if tc.given.product != nil {
+				products = append(products, *tc.given.product)
+			}
+			repoMock.On("GetProductsWithId").Return(products, nil)
+			repoMock.On("Touch", mock.Anything, "product_valid_id", fixedNow()).Return(nil)
+			overridesMock := &PriceOverrideRepositoryMock{}
+			overridesMock.On("GetByProducts", mock.Anything, mock.Anything).Return([]canonical.PriceOverride{}, nil)
+			overridesMock.On("Set", mock.Anything, mock.Anything).Return(nil)
+			auditMock := &AuditRepositoryMock{}
+			auditMock.On("Create", mock.Anything, mock.Anything).Return(nil)
+			outboxMock := &OutboxRepositoryMock{}
+			outboxMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+
+			svc := productService{
+				now:       fixedNow,
+				newID:     fixedID,
+				repo:      repoMock,
+				audit:     auditMock,
+				outbox:    outboxMock,
+				tx:        TransactorMock{},
+				overrides: overridesMock,
+			}
+
+			err := svc.SetPrice(tenant.WithStore(context.Background(), tc.given.store), "product_valid_id", tc.given.price)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			if tc.expected.err != nil {
+				overridesMock.AssertNotCalled(t, "Set", mock.Anything, mock.Anything)
+				repoMock.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+
+			repoMock.AssertCalled(t, "Touch", mock.Anything, "product_valid_id", fixedNow())
+
+			overridesMock.AssertCalled(t, "Set", mock.Anything, mock.MatchedBy(func(override canonical.PriceOverride) bool {
+				return override.ProductID == "product_valid_id" && override.Price == 12.5
+			}))
+			auditMock.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(entry canonical.AuditEntry) bool {
+				return entry.StoreID == "store_1" && entry.Changes[0] == canonical.FieldChange{Field: "price", Before: 10.0, After: 12.5}
+			}))
+			outboxMock.AssertCalled(t, "Add", mock.Anything, mock.MatchedBy(func(events []canonical.Event) bool {
+				return len(events) == 2 && events[1].Type == canonical.EVENT_PRODUCT_PRICE_CHANGED && events[1].StoreID == "store_1" &&
+					events[1].Product.Version == tc.given.product.Version+1
+			}))
+		})
+	}
+}
+
+func TestProductService_RemovePrice(t *testing.T) {
+	repoMock := &ProductRepositoryMock{}
+	repoMock.On("GetProductsWithId").Return([]canonical.Product{{ID: "product_valid_id", Price: 10, Version: 2}}, nil).Once()
+	repoMock.On("GetProductsWithId").Return([]canonical.Product{{ID: "product_valid_id", Price: 10, Version: 3}}, nil)
+	repoMock.On("Touch", mock.Anything, "product_valid_id", fixedNow()).Return(nil)
+	overridesMock := &PriceOverrideRepositoryMock{}
+	overridesMock.On("GetByProducts", mock.Anything, mock.Anything).Return([]canonical.PriceOverride{
+		{StoreID: "store_1", ProductID: "product_valid_id", Price: 12.5},
+	}, nil).Once()
+	overridesMock.On("GetByProducts", mock.Anything, mock.Anything).Return([]canonical.PriceOverride{}, nil)
+	overridesMock.On("Remove", mock.Anything, "product_valid_id").Return(nil)
+	auditMock := &AuditRepositoryMock{}
+	auditMock.On("Create", mock.Anything, mock.Anything).Return(nil)
+	outboxMock := &OutboxRepositoryMock{}
+	outboxMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+
+	svc := productService{
+		now:       fixedNow,
+		newID:     fixedID,
+		repo:      repoMock,
+		audit:     auditMock,
+		outbox:    outboxMock,
+		tx:        TransactorMock{},
+		overrides: overridesMock,
+	}
+
+	err := svc.RemovePrice(tenant.WithStore(context.Background(), "store_1"), "product_valid_id")
+
+	assert.Nil(t, err)
+	repoMock.AssertCalled(t, "Touch", mock.Anything, "product_valid_id", fixedNow())
+	auditMock.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(entry canonical.AuditEntry) bool {
+		return entry.StoreID == "store_1" && entry.Timestamp.Equal(fixedNow()) &&
+			entry.Changes[0] == canonical.FieldChange{Field: "price", Before: 12.5, After: 10.0}
+	}))
+}
+
+func TestProductService_PriceOverrideReachesWatchers(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	now := fixedNow()
+	svc := productService{
+		repo:      repos.Products,
+		audit:     repos.Audit,
+		outbox:    repos.Outbox,
+		tx:        repos.Transactor,
+		watcher:   repos.Watcher,
+		overrides: repos.Prices,
+		now:       func() time.Time { return now },
+		newID:     canonical.NewUUID,
+	}
+	store1 := tenant.WithStore(context.Background(), "store_1")
+
+	soda, err := svc.Create(context.Background(), &canonical.Product{Name: "Soda", Price: 5})
+	require.NoError(t, err)
+
+	// the memory change log numbers its changes from 1, the creation
+	token := "1"
+	for _, write := range []struct {
+		apply func() error
+		price float64
+	}{
+		{apply: func() error { return svc.SetPrice(store1, soda.ID, 6.5) }, price: 6.5},
+		{apply: func() error { return svc.RemovePrice(store1, soda.ID) }, price: 5},
+	} {
+		now = now.Add(time.Minute)
+		require.NoError(t, write.apply())
+
+		products, err := svc.Find(store1, canonical.ProductFilter{UpdatedSince: now})
+		assert.NoError(t, err)
+		assert.Len(t, products, 1)
+
+		change := watchOne(t, &svc, store1, soda.ID, token)
+		assert.Equal(t, canonical.EVENT_PRODUCT_UPDATED, change.Type)
+		assert.Equal(t, write.price, change.Product.Price)
+		assert.Equal(t, now, change.OccurredAt)
+		token = change.Token
+	}
+}
+
+// watchOne returns the first change of product id after token.
+func watchOne(t *testing.T, svc ProductService, ctx context.Context, id string, token string) canonical.ProductChange {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	done := errors.New("done")
+	var change canonical.ProductChange
+	err := svc.Watch(ctx, canonical.ChangeFilter{IDs: []string{id}}, token, func(c canonical.ProductChange) error {
+		change = c
+		return done
+	})
+
+	require.ErrorIs(t, err, done)
+	return change
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// The traced services open a span around every call of the service they
+// wrap, so the Mongo commands it sends are grouped under the operation.
+
+type tracedProductService struct {
+	next ProductService
+}
+
+func (s *tracedProductService) GetAll(ctx context.Context) ([]canonical.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetAll")
+	products, err := s.next.GetAll(ctx)
+	tracing.End(span, err)
+	return products, err
+}
+
+func (s *tracedProductService) Create(ctx context.Context, product *canonical.Product) (*canonical.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.Create")
+	created, err := s.next.Create(ctx, product)
+	if created != nil {
+		span.SetAttributes(attribute.String("product.id", created.ID))
+	}
+	tracing.End(span, err)
+	return created, err
+}
+
+func (s *tracedProductService) Update(ctx context.Context, id string, product canonical.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductService.Update", attribute.String("product.id", id))
+	err := s.next.Update(ctx, id, product)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedProductService) GetByID(ctx context.Context, id string) (*canonical.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetByID", attribute.String("product.id", id))
+	product, err := s.next.GetByID(ctx, id)
+	tracing.End(span, err)
+	return product, err
+}
+
+func (s *tracedProductService) GetByCategory(ctx context.Context, category string) ([]canonical.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetByCategory", attribute.String("product.category", category))
+	products, err := s.next.GetByCategory(ctx, category)
+	tracing.End(span, err)
+	return products, err
+}
+
+func (s *tracedProductService) Find(ctx context.Context, filter canonical.ProductFilter) ([]canonical.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.Find")
+	products, err := s.next.Find(ctx, filter)
+	tracing.End(span, err)
+	return products, err
+}
+
+func (s *tracedProductService) Remove(ctx context.Context, id string, version int64) error {
+	ctx, span := tracing.Start(ctx, "ProductService.Remove", attribute.String("product.id", id))
+	err := s.next.Remove(ctx, id, version)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedProductService) GetProductsWithId(ctx context.Context, ids []string) ([]canonical.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetProductsWithId", attribute.StringSlice("product.ids", ids))
+	products, err := s.next.GetProductsWithId(ctx, ids)
+	tracing.End(span, err)
+	return products, err
+}
+
+// Watch spans the whole stream, until the client goes away.
+func (s *tracedProductService) Watch(ctx context.Context, filter canonical.ChangeFilter, resumeToken string, handle func(canonical.ProductChange) error) error {
+	ctx, span := tracing.Start(ctx, "ProductService.Watch")
+	err := s.next.Watch(ctx, filter, resumeToken, handle)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedProductService) SetPrice(ctx context.Context, id string, price float64) error {
+	ctx, span := tracing.Start(ctx, "ProductService.SetPrice", attribute.String("product.id", id))
+	err := s.next.SetPrice(ctx, id, price)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedProductService) RemovePrice(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "ProductService.RemovePrice", attribute.String("product.id", id))
+	err := s.next.RemovePrice(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedAuditService struct {
+	next AuditService
+}
+
+func (s *tracedAuditService) History(ctx context.Context, productID string) ([]canonical.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.History", attribute.String("product.id", productID))
+	entries, err := s.next.History(ctx, productID)
+	tracing.End(span, err)
+	return entries, err
+}
+
+func (s *tracedAuditService) Search(ctx context.Context, filter canonical.AuditFilter) ([]canonical.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Search")
+	entries, err := s.next.Search(ctx, filter)
+	tracing.End(span, err)
+	return entries, err
+}
+
+type tracedWebhookService struct {
+	next WebhookService
+}
+
+func (s *tracedWebhookService) Create(ctx context.Context, webhook canonical.Webhook) (*canonical.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Create")
+	created, err := s.next.Create(ctx, webhook)
+	tracing.End(span, err)
+	return created, err
+}
+
+func (s *tracedWebhookService) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetAll")
+	webhooks, err := s.next.GetAll(ctx)
+	tracing.End(span, err)
+	return webhooks, err
+}
+
+func (s *tracedWebhookService) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.GetByID", attribute.String("webhook.id", id))
+	webhook, err := s.next.GetByID(ctx, id)
+	tracing.End(span, err)
+	return webhook, err
+}
+
+func (s *tracedWebhookService) Remove(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.Remove", attribute.String("webhook.id", id))
+	err := s.next.Remove(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedWebhookService) Deliveries(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Deliveries", attribute.String("webhook.id", webhookID))
+	deliveries, err := s.next.Deliveries(ctx, webhookID)
+	tracing.End(span, err)
+	return deliveries, err
+}
+
+type tracedAPIKeyService struct {
+	next APIKeyService
+}
+
+func (s *tracedAPIKeyService) Create(ctx context.Context, key canonical.APIKey) (*canonical.APIKey, string, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Create")
+	created, plaintext, err := s.next.Create(ctx, key)
+	tracing.End(span, err)
+	return created, plaintext, err
+}
+
+func (s *tracedAPIKeyService) GetAll(ctx context.Context) ([]canonical.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.GetAll")
+	keys, err := s.next.GetAll(ctx)
+	tracing.End(span, err)
+	return keys, err
+}
+
+func (s *tracedAPIKeyService) Revoke(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Revoke", attribute.String("api_key.id", id))
+	err := s.next.Revoke(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedAPIKeyService) Authenticate(ctx context.Context, key string) (*canonical.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	authenticated, err := s.next.Authenticate(ctx, key)
+	if authenticated != nil {
+		span.SetAttributes(attribute.String("api_key.id", authenticated.ID))
+	}
+	tracing.End(span, err)
+	return authenticated, err
+}
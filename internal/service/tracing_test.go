@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedProductService(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	var repoCtx context.Context
+	repoMock := ProductRepositoryMock{}
+	repoMock.On("GetByID", mock.Anything, "1234").Run(func(args mock.Arguments) {
+		repoCtx = args.Get(0).(context.Context)
+	}).Return(nil, errors.New("connection refused"))
+
+	svc := tracedProductService{&productService{repo: &repoMock}}
+
+	_, err := svc.GetByID(context.Background(), "1234")
+
+	assert.NotNil(t, err)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "ProductService.GetByID", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("product.id", "1234"))
+	assert.Equal(t, spans[0].SpanContext().SpanID(), trace.SpanContextFromContext(repoCtx).SpanID())
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"tech-challenge-product/internal/auth/token"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/repository"
+	"tech-challenge-product/internal/tenant"
+	"time"
+)
+
+var knownEvents = map[canonical.EventType]bool{
+	canonical.EVENT_PRODUCT_CREATED:       true,
+	canonical.EVENT_PRODUCT_UPDATED:       true,
+	canonical.EVENT_PRODUCT_REMOVED:       true,
+	canonical.EVENT_PRODUCT_PRICE_CHANGED: true,
+}
+
+type WebhookService interface {
+	Create(context.Context, canonical.Webhook) (*canonical.Webhook, error)
+	GetAll(context.Context) ([]canonical.Webhook, error)
+	GetByID(context.Context, string) (*canonical.Webhook, error)
+	Remove(context.Context, string) error
+	Deliveries(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo       repository.WebhookRepository
+	deliveries repository.DeliveryRepository
+}
+
+func NewWebhookService(repo repository.WebhookRepository, deliveries repository.DeliveryRepository) WebhookService {
+	return &tracedWebhookService{&webhookService{
+		repo:       repo,
+		deliveries: deliveries,
+	}}
+}
+
+// Create validates and stores a subscription to the events of the store of
+// ctx. A secret is generated when none is given; it is only returned here,
+// so the caller must keep it.
+func (s *webhookService) Create(ctx context.Context, webhook canonical.Webhook) (*canonical.Webhook, error) {
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+
+	if webhook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+	webhook.ID = canonical.NewUUID()
+	webhook.StoreID = tenant.Store(ctx)
+	webhook.CreatedAt = time.Now()
+	webhook.CreatedBy = token.Subject(ctx)
+
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (s *webhookService) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// GetByID only finds the webhooks of the store of ctx.
+func (s *webhookService) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	webhook, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.StoreID != tenant.Store(ctx) {
+		return nil, canonical.ErrorNotFound
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) Remove(ctx context.Context, id string) error {
+	return s.repo.Remove(ctx, id)
+}
+
+func (s *webhookService) Deliveries(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	if _, err := s.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return s.deliveries.GetByWebhook(ctx, webhookID)
+}
+
+func validateWebhook(webhook canonical.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", canonical.ErrorInvalidWebhook)
+	}
+
+	for _, event := range webhook.Events {
+		if !knownEvents[event] {
+			return fmt.Errorf("%w: unknown event %q", canonical.ErrorInvalidWebhook, event)
+		}
+	}
+
+	return nil
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
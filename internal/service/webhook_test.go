@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookService_Create(t *testing.T) {
+	type Given struct {
+		webhook canonical.Webhook
+	}
+	type Expected struct {
+		err    error
+		secret string
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given valid webhook with secret must keep the secret": {
+			given: Given{
+				webhook: canonical.Webhook{
+					URL:    "https://partner.example.com/hooks",
+					Events: []canonical.EventType{canonical.EVENT_PRODUCT_PRICE_CHANGED},
+					Secret: "s3cr3t",
+				},
+			},
+			expected: Expected{
+				secret: "s3cr3t",
+			},
+		},
+		"given valid webhook without secret must generate one": {
+			given: Given{
+				webhook: canonical.Webhook{URL: "http://localhost:9000"},
+			},
+		},
+		"given relative url must be rejected": {
+			given: Given{
+				webhook: canonical.Webhook{URL: "/hooks"},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidWebhook,
+			},
+		},
+		"given unsupported scheme must be rejected": {
+			given: Given{
+				webhook: canonical.Webhook{URL: "ftp://partner.example.com"},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidWebhook,
+			},
+		},
+		"given unknown event must be rejected": {
+			given: Given{
+				webhook: canonical.Webhook{
+					URL:    "https://partner.example.com/hooks",
+					Events: []canonical.EventType{"ProductDeleted"},
+				},
+			},
+			expected: Expected{
+				err: canonical.ErrorInvalidWebhook,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			repoMock := &WebhookRepositoryMock{}
+			repoMock.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+			svc := webhookService{
+				repo: repoMock,
+			}
+
+			webhook, err := svc.Create(context.Background(), tc.given.webhook)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			if tc.expected.err != nil {
+				repoMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NotEmpty(t, webhook.ID)
+			assert.False(t, webhook.CreatedAt.IsZero())
+			if tc.expected.secret != "" {
+				assert.Equal(t, tc.expected.secret, webhook.Secret)
+			} else {
+				assert.Len(t, webhook.Secret, 64)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	repoMock := &WebhookRepositoryMock{}
+	repoMock.On("GetByID", mock.Anything, "webhook_valid_id").Return(&canonical.Webhook{ID: "webhook_valid_id"}, nil)
+	repoMock.On("GetByID", mock.Anything, "webhook_invalid_id").Return(nil, canonical.ErrorNotFound)
+
+	deliveryMock := &DeliveryRepositoryMock{}
+	deliveryMock.On("GetByWebhook", mock.Anything, "webhook_valid_id").Return([]canonical.WebhookDelivery{
+		{ID: "event_1:webhook_valid_id", Status: canonical.DELIVERY_DELIVERED},
+	}, nil)
+
+	svc := webhookService{
+		repo:       repoMock,
+		deliveries: deliveryMock,
+	}
+
+	deliveries, err := svc.Deliveries(context.Background(), "webhook_valid_id")
+
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, 1)
+
+	_, err = svc.Deliveries(context.Background(), "webhook_invalid_id")
+
+	assert.ErrorIs(t, err, canonical.ErrorNotFound)
+}
+
+func TestWebhookService_GetByIDScopedToStore(t *testing.T) {
+	repoMock := &WebhookRepositoryMock{}
+	repoMock.On("GetByID", mock.Anything, "webhook_store_1").Return(&canonical.Webhook{ID: "webhook_store_1", StoreID: "store_1"}, nil)
+
+	svc := webhookService{
+		repo: repoMock,
+	}
+
+	webhook, err := svc.GetByID(tenant.WithStore(context.Background(), "store_1"), "webhook_store_1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "webhook_store_1", webhook.ID)
+
+	_, err = svc.GetByID(tenant.WithStore(context.Background(), "store_2"), "webhook_store_1")
+
+	assert.ErrorIs(t, err, canonical.ErrorNotFound)
+
+	_, err = svc.Deliveries(tenant.WithStore(context.Background(), "store_2"), "webhook_store_1")
+
+	assert.ErrorIs(t, err, canonical.ErrorNotFound)
+}
@@ -0,0 +1,93 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/config"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrorStoreMismatch   = errors.New("store does not match the credentials")
+	ErrorStoreNotGranted = errors.New("credentials are not granted other stores")
+)
+
+type storeKey struct{}
+
+// WithStore returns a copy of ctx scoped to store. The empty store is the
+// shared base catalog.
+func WithStore(ctx context.Context, store string) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// Store returns the store ctx is scoped to, or the empty string for the
+// base catalog.
+func Store(ctx context.Context) string {
+	store, _ := ctx.Value(storeKey{}).(string)
+	return store
+}
+
+// Resolver picks the store of requests from the claim and header
+// configured under tenancy.
+type Resolver struct {
+	settings config.Tenancy
+	policy   rbac.Policy
+}
+
+// New reads the roles of the callers with policy to find the admins, who
+// may pick any store.
+func New(settings config.Tenancy, policy rbac.Policy) Resolver {
+	return Resolver{
+		settings: settings,
+		policy:   policy,
+	}
+}
+
+// Header is the header, or gRPC metadata key, callers pick a store with.
+func (r Resolver) Header() string {
+	return r.settings.Header
+}
+
+// Claim is the claim holding the store of callers bound to one.
+func (r Resolver) Claim() string {
+	return r.settings.Claim
+}
+
+// Resolve picks the store of a request from the tenancy claim of claims.
+// Callers bound to a store by their credentials can not ask for another
+// one. Callers without a store work on the base catalog, and only the ones
+// granted every store (see allStores) may pick one with requested, the
+// value of the tenancy header.
+func (r Resolver) Resolve(claims jwt.MapClaims, requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+
+	bound, _ := claims[r.settings.Claim].(string)
+	if bound != "" {
+		if requested != "" && requested != bound {
+			return "", ErrorStoreMismatch
+		}
+		return bound, nil
+	}
+
+	if requested != "" && !r.allStores(claims) {
+		return "", ErrorStoreNotGranted
+	}
+	return requested, nil
+}
+
+// allStores reports whether claims grant every store, through the admin
+// role or the all stores claim.
+func (r Resolver) allStores(claims jwt.MapClaims) bool {
+	if granted, _ := claims[r.settings.AllStoresClaim].(bool); granted && r.settings.AllStoresClaim != "" {
+		return true
+	}
+	for _, role := range r.policy.Roles(claims) {
+		if role == rbac.ROLE_ADMIN {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package tenant
+
+import (
+	"context"
+	"tech-challenge-product/internal/auth/rbac"
+	"tech-challenge-product/internal/config"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	resolver := New(
+		config.Tenancy{Claim: "store_id", Header: "X-Store-ID", AllStoresClaim: "all_stores"},
+		rbac.New(config.Auth{RolesClaim: "roles"}, nil),
+	)
+
+	type Given struct {
+		claims    jwt.MapClaims
+		requested string
+	}
+	type Expected struct {
+		store string
+		err   error
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given store claim must use it": {
+			given:    Given{claims: jwt.MapClaims{"store_id": "store_1"}},
+			expected: Expected{store: "store_1"},
+		},
+		"given store claim and the same header must use it": {
+			given:    Given{claims: jwt.MapClaims{"store_id": "store_1"}, requested: "store_1"},
+			expected: Expected{store: "store_1"},
+		},
+		"given store claim and another header must be refused": {
+			given:    Given{claims: jwt.MapClaims{"store_id": "store_1"}, requested: "store_2"},
+			expected: Expected{err: ErrorStoreMismatch},
+		},
+		"given admin without store claim must use the header": {
+			given:    Given{claims: jwt.MapClaims{"sub": "admin", "roles": []interface{}{"admin"}}, requested: " store_2 "},
+			expected: Expected{store: "store_2"},
+		},
+		"given all stores claim must use the header": {
+			given:    Given{claims: jwt.MapClaims{"sub": "sync", "all_stores": true}, requested: "store_2"},
+			expected: Expected{store: "store_2"},
+		},
+		"given manager without store claim asking for a store must be refused": {
+			given:    Given{claims: jwt.MapClaims{"sub": "maria", "roles": []interface{}{"manager"}}, requested: "store_2"},
+			expected: Expected{err: ErrorStoreNotGranted},
+		},
+		"given api key without store asking for a store must be refused": {
+			given:    Given{claims: jwt.MapClaims{"sub": "api_key:1", "principal": "api_key"}, requested: "store_2"},
+			expected: Expected{err: ErrorStoreNotGranted},
+		},
+		"given anonymous caller asking for a store must be refused": {
+			given:    Given{requested: "store_2"},
+			expected: Expected{err: ErrorStoreNotGranted},
+		},
+		"given manager without store claim must use the base catalog": {
+			given:    Given{claims: jwt.MapClaims{"sub": "maria", "roles": []interface{}{"manager"}}},
+			expected: Expected{store: ""},
+		},
+		"given no store at all must use the base catalog": {
+			given:    Given{claims: jwt.MapClaims{"sub": "admin"}},
+			expected: Expected{store: ""},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			store, err := resolver.Resolve(tc.given.claims, tc.given.requested)
+
+			assert.ErrorIs(t, err, tc.expected.err)
+			assert.Equal(t, tc.expected.store, store)
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	assert.Equal(t, "", Store(context.Background()))
+	assert.Equal(t, "store_1", Store(WithStore(context.Background(), "store_1")))
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"tech-challenge-product/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentation = "tech-challenge-product"
+)
+
+// Setup installs the tracer provider selected by tracing.exporter and the
+// W3C trace context propagator. The returned function flushes the pending
+// spans and must be called before exiting.
+func Setup(ctx context.Context, settings config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		// spans are not recorded, but incoming trace context is still
+		// passed on to the calls made while handling a request
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(settings.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(settings.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, settings config.Tracing) (sdktrace.SpanExporter, error) {
+	switch settings.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New()
+	case "otlp":
+		var options []otlptracegrpc.Option
+		if settings.Endpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(settings.Endpoint))
+		}
+		if settings.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", settings.Exporter)
+	}
+}
+
+// Start opens a span named after the operation, e.g. ProductService.Update.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End closes span, marking it as failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
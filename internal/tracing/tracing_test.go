@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	type Given struct {
+		exporter string
+	}
+	type Expected struct {
+		err assert.ErrorAssertionFunc
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given no exporter must only install the propagator": {
+			given:    Given{exporter: "none"},
+			expected: Expected{err: assert.NoError},
+		},
+		"given stdout exporter must install a tracer provider": {
+			given:    Given{exporter: "stdout"},
+			expected: Expected{err: assert.NoError},
+		},
+		"given unknown exporter must fail": {
+			given:    Given{exporter: "zipkin"},
+			expected: Expected{err: assert.Error},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			shutdown, err := Setup(context.Background(), config.Tracing{Exporter: tc.given.exporter, SampleRatio: 1})
+
+			tc.expected.err(t, err)
+			if err == nil {
+				assert.Nil(t, shutdown(context.Background()))
+				assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+			}
+		})
+	}
+}
+
+func TestStartAndEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, span := Start(context.Background(), "ProductService.Update", attribute.String("product.id", "1234"))
+	End(span, errors.New("product not found"))
+	_, span = Start(context.Background(), "ProductService.GetAll")
+	End(span, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "ProductService.Update", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "product not found", spans[0].Status().Description)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("product.id", "1234"))
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
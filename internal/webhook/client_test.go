@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublic(t *testing.T) {
+	tests := map[string]struct {
+		given    string
+		expected bool
+	}{
+		"given public ipv4 must allow":           {given: "93.184.216.34", expected: true},
+		"given public ipv6 must allow":           {given: "2606:2800:220:1:248:1893:25c8:1946", expected: true},
+		"given loopback must refuse":             {given: "127.0.0.1", expected: false},
+		"given ipv6 loopback must refuse":        {given: "::1", expected: false},
+		"given private must refuse":              {given: "10.0.0.7", expected: false},
+		"given cloud metadata must refuse":       {given: "169.254.169.254", expected: false},
+		"given unspecified must refuse":          {given: "0.0.0.0", expected: false},
+		"given shared address space must refuse": {given: "100.64.0.1", expected: false},
+		"given ipv4 mapped loopback must refuse": {given: "::ffff:127.0.0.1", expected: false},
+		"given unique local ipv6 must refuse":    {given: "fd00::1", expected: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, public(netip.MustParseAddr(tc.given)))
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("given receiver on loopback must refuse to connect", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		_, err := newClient(time.Second).Post(server.URL, "application/json", nil)
+
+		assert.ErrorIs(t, err, ErrorNonPublicAddress)
+	})
+
+	t.Run("given receiver redirects must not follow", func(t *testing.T) {
+		followed := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/internal" {
+				followed = true
+				return
+			}
+			http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+		}))
+		defer server.Close()
+
+		client := newClient(time.Second)
+		client.Transport = server.Client().Transport
+
+		response, err := client.Post(server.URL, "application/json", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusTemporaryRedirect, response.StatusCode)
+		assert.False(t, followed)
+	})
+}
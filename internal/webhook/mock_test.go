@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *WebhookRepositoryMock) Create(ctx context.Context, webhook canonical.Webhook) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (m *WebhookRepositoryMock) GetAll(ctx context.Context) ([]canonical.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]canonical.Webhook), args.Error(1)
+}
+
+func (m *WebhookRepositoryMock) GetByID(ctx context.Context, id string) (*canonical.Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*canonical.Webhook), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *WebhookRepositoryMock) GetByEvent(ctx context.Context, eventType canonical.EventType, store string) ([]canonical.Webhook, error) {
+	args := m.Called(ctx, eventType, store)
+	return args.Get(0).([]canonical.Webhook), args.Error(1)
+}
+
+func (m *WebhookRepositoryMock) Remove(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type DeliveryRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *DeliveryRepositoryMock) Create(ctx context.Context, deliveries ...canonical.WebhookDelivery) error {
+	args := m.Called(ctx, deliveries)
+	return args.Error(0)
+}
+
+func (m *DeliveryRepositoryMock) Pending(ctx context.Context, now time.Time, limit int) ([]canonical.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]canonical.WebhookDelivery), args.Error(1)
+}
+
+func (m *DeliveryRepositoryMock) RecordAttempt(ctx context.Context, id string, attempt canonical.DeliveryAttempt, status canonical.DeliveryStatus, nextAttempt time.Time) error {
+	args := m.Called(ctx, id, attempt, status, nextAttempt)
+	return args.Error(0)
+}
+
+func (m *DeliveryRepositoryMock) GetByWebhook(ctx context.Context, webhookID string) ([]canonical.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID)
+	return args.Get(0).([]canonical.WebhookDelivery), args.Error(1)
+}
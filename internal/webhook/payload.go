@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"tech-challenge-product/internal/canonical"
+	"time"
+)
+
+type Payload struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	StoreID    string          `json:"store_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Product    ProductPayload  `json:"product"`
+	Changes    []ChangePayload `json:"changes,omitempty"`
+}
+
+type ProductPayload struct {
+	ID          string    `json:"id"`
+	StoreID     string    `json:"store_id,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	Category    string    `json:"category"`
+	Status      int       `json:"status"`
+	ImagePath   string    `json:"image_path"`
+	Version     int64     `json:"version"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ChangePayload struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+func toPayload(event canonical.Event) Payload {
+	payload := Payload{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		StoreID:    event.StoreID,
+		OccurredAt: event.OccurredAt,
+		Product: ProductPayload{
+			ID:          event.Product.ID,
+			StoreID:     event.Product.StoreID,
+			Name:        event.Product.Name,
+			Description: event.Product.Description,
+			Price:       event.Product.Price,
+			Category:    event.Product.Category,
+			Status:      int(event.Product.Status),
+			ImagePath:   event.Product.ImagePath,
+			Version:     event.Product.Version,
+			UpdatedAt:   event.Product.UpdatedAt,
+		},
+	}
+
+	for _, change := range event.Changes {
+		payload.Changes = append(payload.Changes, ChangePayload{
+			Field:  change.Field,
+			Before: change.Before,
+			After:  change.After,
+		})
+	}
+
+	return payload
+}
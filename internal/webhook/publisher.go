@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"context"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/repository"
+	"time"
+)
+
+// Publisher turns each event into one pending delivery per subscribed
+// webhook of the store of the event (see WebhookRepository.GetByEvent).
+// The HTTP calls are made later by the Worker, so a slow partner never
+// holds up the outbox.
+type Publisher struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.DeliveryRepository
+}
+
+func NewPublisher(webhooks repository.WebhookRepository, deliveries repository.DeliveryRepository) *Publisher {
+	return &Publisher{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+	}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event canonical.Event) error {
+	webhooks, err := p.webhooks.GetByEvent(ctx, event.Type, event.StoreID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]canonical.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, canonical.WebhookDelivery{
+			// one delivery per event and webhook, even if the event is published twice
+			ID:            event.ID + ":" + webhook.ID,
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Status:        canonical.DELIVERY_PENDING,
+			Attempts:      []canonical.DeliveryAttempt{},
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return p.deliveries.Create(ctx, deliveries...)
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPublisher_Publish(t *testing.T) {
+	event := canonical.Event{ID: "event_valid_id", Type: canonical.EVENT_PRODUCT_CREATED, StoreID: "store_1"}
+
+	type Given struct {
+		webhooks []canonical.Webhook
+		err      error
+	}
+	type Expected struct {
+		deliveries []string
+		err        bool
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given subscribed webhooks must create one delivery per webhook": {
+			given: Given{
+				webhooks: []canonical.Webhook{{ID: "webhook_1"}, {ID: "webhook_2"}},
+			},
+			expected: Expected{
+				deliveries: []string{"event_valid_id:webhook_1", "event_valid_id:webhook_2"},
+			},
+		},
+		"given no subscriptions must create nothing": {
+			given: Given{
+				webhooks: []canonical.Webhook{},
+			},
+			expected: Expected{
+				deliveries: []string{},
+			},
+		},
+		"given repository error must return it": {
+			given: Given{
+				webhooks: []canonical.Webhook{},
+				err:      errors.New("connection refused"),
+			},
+			expected: Expected{
+				err: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			webhooks := &WebhookRepositoryMock{}
+			webhooks.On("GetByEvent", mock.Anything, canonical.EVENT_PRODUCT_CREATED, "store_1").Return(tc.given.webhooks, tc.given.err)
+
+			var created []string
+			deliveries := &DeliveryRepositoryMock{}
+			deliveries.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				created = []string{}
+				for _, delivery := range args.Get(1).([]canonical.WebhookDelivery) {
+					assert.Equal(t, canonical.DELIVERY_PENDING, delivery.Status)
+					created = append(created, delivery.ID)
+				}
+			}).Return(nil)
+
+			err := (&Publisher{webhooks: webhooks, deliveries: deliveries}).Publish(context.Background(), event)
+
+			assert.Equal(t, tc.expected.err, err != nil)
+			if !tc.expected.err {
+				assert.Equal(t, tc.expected.deliveries, created)
+			}
+		})
+	}
+}
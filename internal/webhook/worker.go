@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"tech-challenge-product/internal/canonical"
+	"tech-challenge-product/internal/config"
+	"tech-challenge-product/internal/events"
+	"tech-challenge-product/internal/logging"
+	"tech-challenge-product/internal/repository"
+	"time"
+)
+
+// Worker sends pending deliveries to their webhooks. Every attempt is
+// recorded on the delivery; failed ones are retried with exponential
+// backoff until maxAttempts is reached.
+type Worker struct {
+	webhooks     repository.WebhookRepository
+	deliveries   repository.DeliveryRepository
+	client       *http.Client
+	interval     time.Duration
+	batchSize    int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+}
+
+func NewWorker(webhooks repository.WebhookRepository, deliveries repository.DeliveryRepository, settings config.Webhooks) *Worker {
+	return &Worker{
+		webhooks:     webhooks,
+		deliveries:   deliveries,
+		client:       newClient(settings.Timeout),
+		interval:     settings.Interval,
+		batchSize:    settings.BatchSize,
+		retryBackoff: settings.RetryBackoff,
+		maxBackoff:   settings.MaxBackoff,
+		maxAttempts:  settings.MaxAttempts,
+	}
+}
+
+// Run delivers pending webhooks every interval until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Deliver(ctx); err != nil {
+			logging.From(ctx).Error().Err(err).Msg("an error occurred when deliver webhooks")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver makes one attempt for each delivery that is due.
+func (w *Worker) Deliver(ctx context.Context) error {
+	pending, err := w.deliveries.Pending(ctx, time.Now(), w.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range pending {
+		attempt, final := w.attempt(ctx, delivery)
+
+		status := canonical.DELIVERY_DELIVERED
+		next := attempt.Timestamp
+		if final {
+			status = canonical.DELIVERY_FAILED
+		} else if attempt.Error != "" {
+			status, next = w.retry(ctx, delivery, attempt)
+		}
+
+		if err := w.deliveries.RecordAttempt(ctx, delivery.ID, attempt, status, next); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Worker) retry(ctx context.Context, delivery canonical.WebhookDelivery, attempt canonical.DeliveryAttempt) (canonical.DeliveryStatus, time.Time) {
+	attempts := len(delivery.Attempts) + 1
+
+	logging.From(ctx).Warn().
+		Str("delivery_id", delivery.ID).
+		Str("webhook_id", delivery.WebhookID).
+		Int("attempts", attempts).
+		Str("error", attempt.Error).
+		Msg("an error occurred when deliver webhook")
+
+	if attempts >= w.maxAttempts {
+		return canonical.DELIVERY_FAILED, attempt.Timestamp
+	}
+	return canonical.DELIVERY_PENDING, attempt.Timestamp.Add(events.Backoff(w.retryBackoff, w.maxBackoff, attempts-1))
+}
+
+// attempt sends delivery once. It reports final when the delivery can
+// never succeed, such as when its webhook was removed, so it is not retried.
+func (w *Worker) attempt(ctx context.Context, delivery canonical.WebhookDelivery) (attempt canonical.DeliveryAttempt, final bool) {
+	start := time.Now()
+	attempt = canonical.DeliveryAttempt{Timestamp: start}
+
+	webhook, err := w.webhooks.GetByID(ctx, delivery.WebhookID)
+	if errors.Is(err, canonical.ErrorNotFound) {
+		attempt.Error = "webhook was removed"
+		return attempt, true
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+
+	attempt.StatusCode, err = w.send(ctx, *webhook, delivery, start)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	return attempt, false
+}
+
+func (w *Worker) send(ctx context.Context, webhook canonical.Webhook, delivery canonical.WebhookDelivery, now time.Time) (int, error) {
+	body, err := json.Marshal(toPayload(delivery.Event))
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, string(delivery.Event.Type))
+	request.Header.Set(HeaderDelivery, delivery.ID)
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, now, body))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("receiver answered %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"tech-challenge-product/internal/canonical"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type received struct {
+	headers http.Header
+	payload Payload
+	valid   bool
+}
+
+// receiver starts a partner endpoint that answers with the given status
+// codes in order and records every request it gets.
+func receiver(secret string, statuses ...int) (*httptest.Server, *[]received) {
+	requests := &[]received{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var payload Payload
+		_ = json.Unmarshal(body, &payload)
+		*requests = append(*requests, received{
+			headers: r.Header,
+			payload: payload,
+			valid:   Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body),
+		})
+
+		w.WriteHeader(statuses[(len(*requests)-1)%len(statuses)])
+	}))
+	return server, requests
+}
+
+func newWorker(webhooks *WebhookRepositoryMock, deliveries *DeliveryRepositoryMock) *Worker {
+	return &Worker{
+		webhooks:     webhooks,
+		deliveries:   deliveries,
+		client:       &http.Client{Timeout: time.Second},
+		interval:     time.Millisecond,
+		batchSize:    10,
+		retryBackoff: time.Second,
+		maxBackoff:   time.Minute,
+		maxAttempts:  3,
+	}
+}
+
+func TestWorker_Deliver(t *testing.T) {
+	event := canonical.Event{
+		ID:          "event_valid_id",
+		Type:        canonical.EVENT_PRODUCT_PRICE_CHANGED,
+		AggregateID: "product_valid_id",
+		Product:     canonical.Product{ID: "product_valid_id", Name: "X-Burger", Price: 12.5},
+		Changes:     []canonical.FieldChange{{Field: "price", Before: 10.0, After: 12.5}},
+	}
+
+	type Given struct {
+		status   int
+		attempts int
+	}
+	type Expected struct {
+		status  canonical.DeliveryStatus
+		retryIn time.Duration
+		error   bool
+	}
+	tests := map[string]struct {
+		given    Given
+		expected Expected
+	}{
+		"given receiver accepts must mark delivered": {
+			given:    Given{status: http.StatusNoContent},
+			expected: Expected{status: canonical.DELIVERY_DELIVERED},
+		},
+		"given receiver fails must retry with backoff": {
+			given:    Given{status: http.StatusServiceUnavailable, attempts: 1},
+			expected: Expected{status: canonical.DELIVERY_PENDING, retryIn: 2 * time.Second, error: true},
+		},
+		"given receiver fails on last attempt must give up": {
+			given:    Given{status: http.StatusInternalServerError, attempts: 2},
+			expected: Expected{status: canonical.DELIVERY_FAILED, error: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, requests := receiver("s3cr3t", tc.given.status)
+			defer server.Close()
+
+			webhooks := &WebhookRepositoryMock{}
+			webhooks.On("GetByID", mock.Anything, "webhook_valid_id").Return(&canonical.Webhook{
+				ID:     "webhook_valid_id",
+				URL:    server.URL,
+				Secret: "s3cr3t",
+			}, nil)
+
+			deliveries := &DeliveryRepositoryMock{}
+			deliveries.On("Pending", mock.Anything, mock.Anything, 10).Return([]canonical.WebhookDelivery{{
+				ID:        "event_valid_id:webhook_valid_id",
+				WebhookID: "webhook_valid_id",
+				Event:     event,
+				Status:    canonical.DELIVERY_PENDING,
+				Attempts:  make([]canonical.DeliveryAttempt, tc.given.attempts),
+			}}, nil)
+			deliveries.On("RecordAttempt", mock.Anything, "event_valid_id:webhook_valid_id", mock.Anything, tc.expected.status, mock.Anything).Return(nil)
+
+			err := newWorker(webhooks, deliveries).Deliver(context.Background())
+
+			assert.Nil(t, err)
+			assert.Len(t, *requests, 1)
+
+			request := (*requests)[0]
+			assert.True(t, request.valid)
+			assert.Equal(t, "ProductPriceChanged", request.headers.Get(HeaderEvent))
+			assert.Equal(t, "event_valid_id:webhook_valid_id", request.headers.Get(HeaderDelivery))
+			assert.Equal(t, "event_valid_id", request.payload.ID)
+			assert.Equal(t, 12.5, request.payload.Product.Price)
+			assert.Equal(t, "price", request.payload.Changes[0].Field)
+
+			call := deliveries.Calls[len(deliveries.Calls)-1]
+			attempt := call.Arguments.Get(2).(canonical.DeliveryAttempt)
+			next := call.Arguments.Get(4).(time.Time)
+			assert.Equal(t, tc.given.status, attempt.StatusCode)
+			assert.Equal(t, tc.expected.error, attempt.Error != "")
+			assert.Equal(t, tc.expected.retryIn, next.Sub(attempt.Timestamp))
+		})
+	}
+}
+
+func TestWorker_DeliverRemovedWebhook(t *testing.T) {
+	webhooks := &WebhookRepositoryMock{}
+	webhooks.On("GetByID", mock.Anything, "webhook_valid_id").Return(nil, canonical.ErrorNotFound)
+
+	deliveries := &DeliveryRepositoryMock{}
+	deliveries.On("Pending", mock.Anything, mock.Anything, 10).Return([]canonical.WebhookDelivery{{
+		ID:        "event_valid_id:webhook_valid_id",
+		WebhookID: "webhook_valid_id",
+	}}, nil)
+	// the first attempt already fails for good, a removed webhook never comes back
+	deliveries.On("RecordAttempt", mock.Anything, "event_valid_id:webhook_valid_id",
+		mock.MatchedBy(func(attempt canonical.DeliveryAttempt) bool { return attempt.Error == "webhook was removed" }),
+		canonical.DELIVERY_FAILED, mock.Anything).Return(nil)
+
+	err := newWorker(webhooks, deliveries).Deliver(context.Background())
+
+	assert.Nil(t, err)
+	deliveries.AssertExpectations(t)
+}
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1710072000, 0)
+	signature := Sign("s3cr3t", timestamp, []byte(`{"id":"1"}`))
+
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	assert.True(t, Verify("s3cr3t", "1710072000", signature, []byte(`{"id":"1"}`)))
+	assert.False(t, Verify("other", "1710072000", signature, []byte(`{"id":"1"}`)))
+	assert.False(t, Verify("s3cr3t", "1710072001", signature, []byte(`{"id":"1"}`)))
+}
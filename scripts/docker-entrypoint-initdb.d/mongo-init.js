@@ -2,6 +2,7 @@
 print('Start #################################################################');
 
 db = db.getSiblingDB('product');
-db.createCollection('products');
+// indexes are created by the service migrations, see internal/repository/migration.go
+db.createCollection('product');
 
 print('END #################################################################');
\ No newline at end of file